@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -9,17 +10,45 @@ import (
 	"strings"
 	"time"
 
+	"github.com/rxhunter00/XSS-Taint/pkg/container"
+	"github.com/rxhunter00/XSS-Taint/pkg/pathgenerator"
+	"github.com/rxhunter00/XSS-Taint/pkg/rules"
 	"github.com/rxhunter00/XSS-Taint/pkg/scanner"
+	"github.com/rxhunter00/XSS-Taint/pkg/taint"
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: [directory path] [optional output path]")
+	entryPoints := flag.String("entry-points", "", "comma separated patterns of functions which return value is the response, such as *Controller::*")
+	responseClasses := flag.String("response-classes", "", "comma separated response classes which constructor take the body, as Class or Class=content/type")
+	contentSetters := flag.String("content-setters", "", "comma separated methods setting the response body, such as setContent")
+	checkedRules := flag.String("rules", "", "comma separated rules to check, every rule if empty: "+getRuleList())
+	ruleFiles := flag.String("rule-files", "", "comma separated JSON files of sources, sinks and sanitizers added to the built-in rules")
+	flag.Usage = func() {
+		fmt.Println("Usage: [flags] [directory path] [optional output path]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
 		os.Exit(1)
 	}
 
-	srcPath := os.Args[1]
+	srcPath := flag.Arg(0)
 	outPath := getOutputPath(srcPath)
+	config := pathgenerator.NewConfig()
+	config.Response = getResponseConfig(*entryPoints, *responseClasses, *contentSetters)
+	ruleIDs, err := taint.ParseRuleIDs(*checkedRules)
+	if err != nil {
+		log.Fatalf("Error parsing rules: %v", err)
+	}
+	config.Rules = ruleIDs
+	for _, ruleFile := range splitList(*ruleFiles) {
+		file, err := rules.LoadFile(ruleFile)
+		if err != nil {
+			log.Fatalf("Error loading rule file:\n%v", err)
+		}
+		config.RuleSet.Add(file)
+	}
 
 	start := time.Now()
 
@@ -29,10 +58,16 @@ func main() {
 	}
 	fmt.Printf("Scanning %d PHP files...\n", len(filePaths))
 
-	result := scanner.Scan(srcPath, filePaths)
+	result := scanner.Scan(srcPath, filePaths, config)
 
 	elapsed := time.Since(start)
-	fmt.Printf("Detected %d XSS vulnerabilities in %.2f seconds.\n", result.TotalFinding, elapsed.Seconds())
+	if len(result.Packs) > 0 {
+		fmt.Printf("Enabled rule packs: %s\n", strings.Join(result.Packs, ", "))
+	}
+	for _, warning := range result.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	fmt.Printf("Detected %d vulnerabilities (%d suppressed) in %.2f seconds.\n", result.TotalFinding, result.TotalSuppressed, elapsed.Seconds())
 
 	if err := saveJSON(outPath, result); err != nil {
 		log.Fatalf("Failed to save results: %v", err)
@@ -44,16 +79,56 @@ func getOutputPath(srcPath string) string {
 	folderName := filepath.Base(srcPath)
 	outPath := "results-" + folderName + ".json"
 
-	if len(os.Args) > 2 {
-		outPath = os.Args[2]
+	if flag.NArg() > 1 {
+		outPath = flag.Arg(1)
 	}
 	return outPath
 }
 
-// getPhpFiles recursively scans the directory and returns a list of PHP files.
+// getResponseConfig adds the response producing sinks given by flags to the default ones.
+func getResponseConfig(entryPoints, responseClasses, contentSetters string) *pathgenerator.ResponseConfig {
+	response := pathgenerator.NewResponseConfig()
+	for _, pattern := range splitList(entryPoints) {
+		response.AddEntryPoint(pattern)
+	}
+	for _, class := range splitList(responseClasses) {
+		className, contentType, found := strings.Cut(class, "=")
+		if !found {
+			contentType = "text/html"
+		}
+		response.AddResponseClass(strings.TrimSpace(className), strings.TrimSpace(contentType))
+	}
+	for _, method := range splitList(contentSetters) {
+		response.AddContentSetter(method)
+	}
+	return response
+}
+
+// getRuleList lists the ID of every rule.
+func getRuleList() string {
+	ids := make([]string, 0)
+	for _, rule := range taint.GetRules() {
+		ids = append(ids, string(rule.ID))
+	}
+	return strings.Join(ids, ", ")
+}
+
+// splitList splits comma separated flag value, ignoring empty items.
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getPhpFiles recursively scans the directory and returns a list of PHP files, Drupal modules and themes,
+// Twig templates and the service definitions of the dependency injection container.
 func getPhpFiles(dirPath string) ([]string, error) {
 	var files []string
-	extension := ".php"
+	extensions := []string{".php", ".module", ".theme", ".inc", ".twig"}
 
 	err := filepath.WalkDir(dirPath, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
@@ -63,8 +138,18 @@ func getPhpFiles(dirPath string) ([]string, error) {
 		if d.IsDir() && d.Name() == "vendor" {
 			return filepath.SkipDir
 		}
-		if !d.IsDir() && strings.HasSuffix(d.Name(), extension) {
+		if d.IsDir() {
+			return nil
+		}
+		if container.IsDefinitionFile(path) {
 			files = append(files, path)
+			return nil
+		}
+		for _, extension := range extensions {
+			if strings.HasSuffix(d.Name(), extension) {
+				files = append(files, path)
+				break
+			}
 		}
 		return nil
 	})
@@ -7,7 +7,12 @@ import (
 	"strings"
 
 	"github.com/VKCOM/php-parser/pkg/ast"
+	"github.com/VKCOM/php-parser/pkg/conf"
+	"github.com/VKCOM/php-parser/pkg/errors"
 	"github.com/VKCOM/php-parser/pkg/position"
+	"github.com/VKCOM/php-parser/pkg/scanner"
+	"github.com/VKCOM/php-parser/pkg/token"
+	"github.com/VKCOM/php-parser/pkg/version"
 )
 
 // Some Stmt is List and some not so make as list
@@ -99,3 +104,33 @@ func CreateNameParts(name string, pos *position.Position) []ast.Vertex {
 
 	return nameParts
 }
+
+// Get config of the parser, errors are passed to errorHandler
+func NewParserConfig(errorHandler func(e *errors.Error)) conf.Config {
+	return conf.Config{
+		Version:          &version.Version{Major: 8},
+		ErrorHandlerFunc: errorHandler,
+	}
+}
+
+// Get comments of the source, which are free floating tokens before each token,
+// the source is lexed as the parser with config does
+func GetComments(src []byte, config conf.Config) []*token.Token {
+	// syntax errors are reported by the parser
+	config.ErrorHandlerFunc = func(e *errors.Error) {}
+	lexer := scanner.NewLexer(src, config)
+	comments := make([]*token.Token, 0)
+	for {
+		tkn := lexer.Lex()
+		for _, freeFloating := range tkn.FreeFloating {
+			if freeFloating.ID == token.T_COMMENT || freeFloating.ID == token.T_DOC_COMMENT {
+				comments = append(comments, freeFloating)
+			}
+		}
+		// end of file
+		if tkn.ID == 0 {
+			break
+		}
+	}
+	return comments
+}
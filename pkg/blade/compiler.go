@@ -0,0 +1,302 @@
+package blade
+
+import (
+	"bytes"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/templating"
+)
+
+// Directives compiled to nothing, the content between them is always output
+var ignoredDirectives = map[string]struct{}{
+	"extends": {}, "section": {}, "endsection": {}, "yield": {}, "show": {}, "stop": {},
+	"append": {}, "overwrite": {}, "parent": {}, "csrf": {}, "method": {},
+	"auth": {}, "endauth": {}, "guest": {}, "endguest": {}, "can": {}, "endcan": {},
+	"cannot": {}, "endcannot": {}, "env": {}, "endenv": {}, "production": {}, "endproduction": {},
+	"push": {}, "endpush": {}, "prepend": {}, "endprepend": {}, "stack": {},
+	"once": {}, "endonce": {}, "error": {}, "enderror": {}, "props": {}, "lang": {},
+	"switch": {}, "case": {}, "default": {}, "break": {}, "continue": {}, "endswitch": {},
+	"component": {}, "endcomponent": {}, "slot": {}, "endslot": {},
+}
+
+// Compile Blade template to PHP keeping the lines of the template, such as
+// {{ $x }} to <?php echo e($x); ?> and {!! $x !!} to <?php echo $x; ?>
+func Compile(src []byte) ([]byte, *templating.SourceMap) {
+	c := &compiler{src: src, out: templating.NewOutput(src)}
+	c.compile()
+	return c.out.Bytes(), c.out.SourceMap()
+}
+
+type compiler struct {
+	src []byte
+	out *templating.Output
+	// open @forelse loops, true once @empty is reached
+	forelse []bool
+}
+
+func (c *compiler) compile() {
+	src := c.src
+	textStart := 0
+	for i := 0; i < len(src); {
+		switch {
+		case bytes.HasPrefix(src[i:], []byte("{{--")):
+			end := c.indexFrom(i+4, "--}}")
+			c.out.Copy(textStart, i)
+			closeEnd := c.clamp(end + 4)
+			c.out.Comment(i, i+4, end, closeEnd)
+			i, textStart = closeEnd, closeEnd
+		case bytes.HasPrefix(src[i:], []byte("@{{")), bytes.HasPrefix(src[i:], []byte("@{!!")):
+			// escaped echo is output as is without the @
+			c.out.Copy(textStart, i)
+			i, textStart = i+3, i+1
+		case bytes.HasPrefix(src[i:], []byte("{!!")):
+			end := bytes.Index(src[i+3:], []byte("!!}"))
+			if end < 0 {
+				i++
+				continue
+			}
+			end += i + 3
+			c.out.Copy(textStart, i)
+			c.out.Emit("<?php echo ", i, i+3)
+			c.out.Copy(i+3, end)
+			c.out.Emit("; ?>", end, end+3)
+			i, textStart = end+3, end+3
+		case bytes.HasPrefix(src[i:], []byte("{{")):
+			end := bytes.Index(src[i+2:], []byte("}}"))
+			if end < 0 {
+				i++
+				continue
+			}
+			end += i + 2
+			c.out.Copy(textStart, i)
+			c.out.Emit("<?php echo e(", i, i+2)
+			c.out.Copy(i+2, end)
+			c.out.Emit("); ?>", end, end+2)
+			i, textStart = end+2, end+2
+		case src[i] == '@' && (i == 0 || !isWordChar(src[i-1])):
+			if i+1 < len(src) && src[i+1] == '@' {
+				// @@if is output as @if
+				c.out.Copy(textStart, i)
+				textStart = i + 1
+				i += 2
+				for i < len(src) && isWordChar(src[i]) {
+					i++
+				}
+				continue
+			}
+			nameEnd := i + 1
+			for nameEnd < len(src) && isWordChar(src[nameEnd]) {
+				nameEnd++
+			}
+			name := string(src[i+1 : nameEnd])
+			if !isDirective(name) {
+				i++
+				continue
+			}
+			c.out.Copy(textStart, i)
+			i = c.compileDirective(name, i, nameEnd)
+			textStart = i
+		default:
+			i++
+		}
+	}
+	c.out.Copy(textStart, len(src))
+}
+
+func isDirective(name string) bool {
+	switch name {
+	case "if", "elseif", "else", "endif", "unless", "endunless", "isset", "endisset", "empty", "endempty",
+		"foreach", "endforeach", "forelse", "endforelse", "for", "endfor", "while", "endwhile",
+		"php", "endphp", "verbatim", "json", "include":
+		return true
+	}
+	_, ok := ignoredDirectives[name]
+	return ok
+}
+
+// Compile directive starting at start, return the end of the compiled text
+func (c *compiler) compileDirective(name string, start, nameEnd int) int {
+	argsStart, argsEnd := c.parseArgs(nameEnd)
+	hasArgs := argsStart >= 0
+	end := nameEnd
+	if hasArgs {
+		end = argsEnd
+	}
+
+	switch name {
+	case "if", "elseif", "foreach", "for", "while", "unless", "isset", "forelse":
+		if !hasArgs {
+			c.out.Drop(start, end)
+			return end
+		}
+		switch name {
+		case "unless":
+			c.out.Emit("<?php if (!", start, nameEnd)
+		case "isset":
+			c.out.Emit("<?php if (isset", start, nameEnd)
+		case "forelse":
+			c.forelse = append(c.forelse, false)
+			c.out.Emit("<?php foreach", start, nameEnd)
+		default:
+			c.out.Emit("<?php "+name, start, nameEnd)
+		}
+		c.out.Copy(nameEnd, argsEnd)
+		if name == "unless" || name == "isset" {
+			c.out.Emit("): ?>", argsEnd-1, argsEnd)
+		} else {
+			c.out.Emit(": ?>", argsEnd-1, argsEnd)
+		}
+	case "empty":
+		if hasArgs {
+			c.out.Emit("<?php if (empty", start, nameEnd)
+			c.out.Copy(nameEnd, argsEnd)
+			c.out.Emit("): ?>", argsEnd-1, argsEnd)
+		} else if len(c.forelse) > 0 {
+			// @empty of @forelse is output when there is no item
+			c.forelse[len(c.forelse)-1] = true
+			c.out.Emit("<?php endforeach; if (true): ?>", start, end)
+		} else {
+			c.out.Drop(start, end)
+		}
+	case "endforelse":
+		if len(c.forelse) == 0 {
+			c.out.Drop(start, end)
+			break
+		}
+		hasEmpty := c.forelse[len(c.forelse)-1]
+		c.forelse = c.forelse[:len(c.forelse)-1]
+		if hasEmpty {
+			c.out.Emit("<?php endif; ?>", start, end)
+		} else {
+			c.out.Emit("<?php endforeach; ?>", start, end)
+		}
+	case "else":
+		c.out.Emit("<?php else: ?>", start, end)
+	case "endif", "endunless", "endisset", "endempty":
+		c.out.Emit("<?php endif; ?>", start, end)
+	case "endforeach", "endfor", "endwhile":
+		c.out.Emit("<?php "+name+"; ?>", start, end)
+	case "php":
+		if hasArgs {
+			c.out.Emit("<?php ", start, nameEnd)
+			c.out.Copy(nameEnd, argsEnd)
+			c.out.Emit("; ?>", argsEnd-1, argsEnd)
+			break
+		}
+		// block of PHP code until @endphp
+		blockEnd := c.indexFrom(nameEnd, "@endphp")
+		c.out.Emit("<?php ", start, nameEnd)
+		c.out.Copy(nameEnd, blockEnd)
+		end = c.clamp(blockEnd + len("@endphp"))
+		c.out.Emit(" ?>", blockEnd, end)
+	case "verbatim":
+		blockEnd := c.indexFrom(nameEnd, "@endverbatim")
+		c.out.Copy(nameEnd, blockEnd)
+		end = c.clamp(blockEnd + len("@endverbatim"))
+	case "json":
+		if !hasArgs {
+			c.out.Drop(start, end)
+			break
+		}
+		// the default flags of @json make it safe in script and attribute
+		c.out.Emit("<?php echo json_encode(", start, argsStart+1)
+		c.out.Copy(argsStart+1, argsEnd-1)
+		if !hasTopLevelComma(c.src[argsStart+1 : argsEnd-1]) {
+			c.out.Emit(", JSON_HEX_TAG | JSON_HEX_APOS | JSON_HEX_AMP | JSON_HEX_QUOT", argsEnd-1, argsEnd-1)
+		}
+		c.out.Emit("); ?>", argsEnd-1, argsEnd)
+	case "include":
+		if !hasArgs {
+			c.out.Drop(start, end)
+			break
+		}
+		c.out.Emit("<?php echo view", start, nameEnd)
+		c.out.Copy(nameEnd, argsEnd)
+		c.out.Emit("; ?>", argsEnd-1, argsEnd)
+	default:
+		c.out.Drop(start, end)
+	}
+	return end
+}
+
+// Get the directive arguments in parentheses after the name, -1 if there is none
+func (c *compiler) parseArgs(from int) (int, int) {
+	i := from
+	for i < len(c.src) && c.src[i] == ' ' {
+		i++
+	}
+	if i >= len(c.src) || c.src[i] != '(' {
+		return -1, -1
+	}
+	depth := 0
+	var quote byte
+	for j := i; j < len(c.src); j++ {
+		ch := c.src[j]
+		switch {
+		case quote != 0:
+			if ch == '\\' {
+				j++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+			if depth == 0 {
+				return i, j + 1
+			}
+		}
+	}
+	return -1, -1
+}
+
+// Get index of the delimiter from the offset, end of the template if not found
+func (c *compiler) indexFrom(from int, delim string) int {
+	if from > len(c.src) {
+		return len(c.src)
+	}
+	i := bytes.Index(c.src[from:], []byte(delim))
+	if i < 0 {
+		return len(c.src)
+	}
+	return from + i
+}
+
+// Limit the offset to the end of the template
+func (c *compiler) clamp(i int) int {
+	if i > len(c.src) {
+		return len(c.src)
+	}
+	return i
+}
+
+func hasTopLevelComma(args []byte) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(args); i++ {
+		ch := args[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case ch == '(' || ch == '[':
+			depth++
+		case ch == ')' || ch == ']':
+			depth--
+		case ch == ',' && depth == 0:
+			return true
+		}
+	}
+	return false
+}
+
+func isWordChar(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
@@ -0,0 +1,50 @@
+package blade
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"escaped echo", "<p>{{ $name }}</p>", "<p><?php echo e( $name ); ?></p>"},
+		{"raw echo", "<div>{!! $html !!}</div>", "<div><?php echo  $html ; ?></div>"},
+		{"escaped blade echo", "@{{ $name }}", "{{ $name }}"},
+		{"comment", "{{-- xss-taint-ignore: trusted --}}", "<?php /* xss-taint-ignore: trusted */ ?>"},
+		{"if", "@if($show)\n{{ $a }}\n@else\nnone\n@endif", "<?php if($show): ?>\n<?php echo e( $a ); ?>\n<?php else: ?>\nnone\n<?php endif; ?>"},
+		{"unless", "@unless($hide)x @endunless", "<?php if (!($hide)): ?>x <?php endif; ?>"},
+		{"foreach", "@foreach($items as $item)<li>{!! $item !!}</li>@endforeach", "<?php foreach($items as $item): ?><li><?php echo  $item ; ?></li><?php endforeach; ?>"},
+		{"forelse with empty", "@forelse($items as $item)x\n@empty\nnone\n@endforelse", "<?php foreach($items as $item): ?>x\n<?php endforeach; if (true): ?>\nnone\n<?php endif; ?>"},
+		{"forelse without empty", "@forelse($items as $item)x\n@endforelse", "<?php foreach($items as $item): ?>x\n<?php endforeach; ?>"},
+		{"php block", "@php $x = 1; @endphp", "<?php  $x = 1;  ?>"},
+		{"json", "<script>var d = @json($data);</script>", "<script>var d = <?php echo json_encode($data, JSON_HEX_TAG | JSON_HEX_APOS | JSON_HEX_AMP | JSON_HEX_QUOT); ?>;</script>"},
+		{"json with flags", "@json($data, JSON_PRETTY_PRINT)", "<?php echo json_encode($data, JSON_PRETTY_PRINT); ?>"},
+		{"include", "@include('partials.nav', ['user' => $user])", "<?php echo view('partials.nav', ['user' => $user]); ?>"},
+		{"ignored directive", "@extends('layout')\n@section('content')x @endsection", "\nx "},
+		{"directive after word", "x@endif", "x@endif"},
+		{"verbatim", "@verbatim{{ $raw }}@endverbatim", "{{ $raw }}"},
+		{"escaped directive", "@@if", "@if"},
+		{"email address", "admin@example.com", "admin@example.com"},
+		{"unknown directive", "@media print", "@media print"},
+		{"unclosed echo", "{{ $name", "{{ $name"},
+	}
+	const prefix = "<?php extract($__data); ?>"
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, _ := Compile([]byte(tt.src))
+			if !bytes.HasPrefix(out, []byte(prefix)) {
+				t.Fatalf("output %q doesn't extract the view data", out)
+			}
+			if got := string(out[len(prefix):]); got != tt.want {
+				t.Errorf("Compile() = %q, want %q", got, tt.want)
+			}
+			if bytes.Count(out, []byte("\n")) != bytes.Count([]byte(tt.src), []byte("\n")) {
+				t.Errorf("Compile() doesn't keep the lines")
+			}
+		})
+	}
+}
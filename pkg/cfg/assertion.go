@@ -62,3 +62,27 @@ func (ca *CompositeAssertion) GetNegation() Assertion {
 func (ca *CompositeAssertion) Negated() bool {
 	return ca.IsNegated
 }
+
+// Value start with one of the prefixes, such as str_starts_with($url, 'https://')
+type PrefixAssertion struct {
+	Prefixes  []string
+	IsNegated bool
+}
+
+func NewPrefixAssertion(prefixes []string, isNegated bool) *PrefixAssertion {
+	return &PrefixAssertion{
+		Prefixes:  prefixes,
+		IsNegated: isNegated,
+	}
+}
+
+func (pa *PrefixAssertion) GetNegation() Assertion {
+	return &PrefixAssertion{
+		Prefixes:  pa.Prefixes,
+		IsNegated: !pa.IsNegated,
+	}
+}
+
+func (pa *PrefixAssertion) Negated() bool {
+	return pa.IsNegated
+}
@@ -90,7 +90,8 @@ func (builder *CFGBuilder) parseStmtNode(vertexNode ast.Vertex) {
 	case *ast.StmtHaltCompiler:
 		//do nothing
 	case *ast.StmtInlineHtml:
-		// TODO Parse for Context
+		// html context of the next output
+		builder.FuncContex.AppendOutput(string(nodeType.Value))
 	case *ast.StmtInterface:
 		builder.parseStmtInterface(nodeType)
 	case *ast.StmtLabel:
@@ -372,6 +373,11 @@ func (builder *CFGBuilder) parseStmtPropertyList(stmt *ast.StmtPropertyList) {
 		}
 
 		name := builder.parseExprNode(prop.(*ast.StmtProperty).Var)
+		if classType := getClassType(stmt.Type); classType != "" {
+			if propName, err := GetOperandName(name); err == nil {
+				builder.addPropertyType(propName, classType)
+			}
+		}
 		op := NewOpStmtProperty(name, visibility, static, readonly, attrGroups, defaultVar, defaultBlock, declaredType, prop.GetPosition())
 		builder.currentBlock.AddInstructions(op)
 	}
@@ -464,6 +470,14 @@ func (builder *CFGBuilder) parseStmtGlobal(stmt *ast.StmtGlobal) {
 		vrOper := builder.writeVariable(builder.parseExprNode(vr))
 		op := NewOpGlobalVar(vrOper, vr.GetPosition())
 		builder.currentBlock.AddInstructions(op)
+
+		// local variable hold the value of global variable, later write of it is a write to the global variable
+		if name := GetOperNamed(vrOper); name != nil && builder.currentFunc != builder.Script.Main {
+			globalVar := builder.Script.GetGlobalVar(name.Val)
+			assign := NewOpExprAssign(vrOper, globalVar, vr.GetPosition(), vr.GetPosition(), vr.GetPosition())
+			builder.currentBlock.AddInstructions(assign)
+			builder.FuncContex.GlobalNames[name.Val] = struct{}{}
+		}
 	}
 }
 
@@ -483,6 +497,7 @@ func (builder *CFGBuilder) parseStmtFunction(stmt *ast.StmtFunction) {
 	if err != nil {
 		log.Fatalf("Error in parseStmtFunction: %v", err)
 	}
+	fn.DocComment = builder.getDocComment(stmt.Position)
 	builder.Script.AddFunc(fn)
 
 	// parse function
@@ -514,8 +529,7 @@ func (builder *CFGBuilder) parseStmtEcho(stmt *ast.StmtEcho) {
 		if err != nil {
 			log.Fatalf("Error in parseStmtEcho: %v", err)
 		}
-		echoOp := NewOpEcho(exprOper, stmt.GetPosition())
-		builder.currentBlock.AddInstructions(echoOp)
+		builder.addOutput(exprOper, len(builder.FuncContex.OutputBuffers), stmt.GetPosition())
 	}
 }
 
@@ -633,31 +647,28 @@ func (builder *CFGBuilder) parseStmtForeach(stmt *ast.StmtForeach) {
 	endBlock.AddPredecessor(builder.currentBlock)
 
 	// parse body
+	// key and value op are positioned at the iterable,
+	// the assignment to loop variable show the binding
 	builder.currentBlock = bodyBlock
 	if stmt.Key != nil {
-		keyOp := NewOpExprKey(iterable, stmt.Key.GetPosition())
-		keyVar, err := builder.readVariable(builder.parseExprNode(stmt.Key))
-		if err != nil {
-			log.Fatalf("Error in parseStmtForEach (key): %v", err)
-		}
+		keyOp := NewOpExprKey(iterable, stmt.Expr.GetPosition())
+		keyVar := builder.writeVariable(builder.parseExprNode(stmt.Key))
 		builder.currentBlock.AddInstructions(keyOp)
-		assignOp := NewOpExprAssign(keyVar, keyOp.Result, stmt.Key.GetPosition(), stmt.Key.GetPosition(), stmt.Key.GetPosition())
+		assignOp := NewOpExprAssign(keyVar, keyOp.Result, stmt.Key.GetPosition(), stmt.Expr.GetPosition(), stmt.Key.GetPosition())
 		builder.currentBlock.AddInstructions(assignOp)
 	}
 	isRef := stmt.AmpersandTkn != nil
-	valueOp := NewOpExprValue(iterable, isRef, stmt.Var.GetPosition())
+	valueOp := NewOpExprValue(iterable, isRef, stmt.Expr.GetPosition())
+	builder.currentBlock.AddInstructions(valueOp)
 
 	// assign each item to variable
 	switch v := stmt.Var.(type) {
 	case *ast.ExprList:
-		builder.parseAssignList(v.Items, valueOp.Result, nil)
+		builder.parseAssignList(v.Items, valueOp.Result, v.Position)
 	case *ast.ExprArray:
-		builder.parseAssignList(v.Items, valueOp.Result, nil)
+		builder.parseAssignList(v.Items, valueOp.Result, v.Position)
 	default:
-		vr, err := builder.readVariable(builder.parseExprNode(stmt.Var))
-		if err != nil {
-			log.Fatalf("Error in parseStmtForEach (default): %v", err)
-		}
+		vr := builder.writeVariable(builder.parseExprNode(stmt.Var))
 		if isRef {
 			builder.currentBlock.AddInstructions(NewOpExprAssignRef(vr, valueOp.Result, stmt.Var.GetPosition()))
 		} else {
@@ -877,6 +888,7 @@ func (builder *CFGBuilder) parseStmtClassMethod(stmt *ast.StmtClassMethod) {
 	if err != nil {
 		log.Fatalf("Error in parseStmtClassMethod: %v", err)
 	}
+	fn.DocComment = builder.getDocComment(stmt.Position)
 	builder.Script.AddFunc(fn)
 
 	// parse function
@@ -909,6 +921,16 @@ func (builder *CFGBuilder) parseStmtClass(stmt *ast.StmtClass) {
 	modifFlags := builder.parseClassModifier(stmt.Modifiers)
 	extends := builder.parseExprNode(stmt.Extends)
 	implements, _ := builder.parseExprList(stmt.Implements, PARSER_MODE_NONE)
+	if extends != nil {
+		if parentName, err := GetOperandName(extends); err == nil {
+			builder.Script.ClassParents[builder.currClassOper.Val] = parentName
+		}
+	}
+	for _, implemented := range implements {
+		if interfaceName, err := GetOperandName(implemented); err == nil {
+			builder.Script.ClassInterfaces[builder.currClassOper.Val] = append(builder.Script.ClassInterfaces[builder.currClassOper.Val], interfaceName)
+		}
+	}
 
 	op := NewOpStmtClass(name, stmts, modifFlags, extends, implements, attrGroups, stmt.Position)
 	builder.currentBlock.AddInstructions(op)
@@ -6,13 +6,14 @@ import (
 	"log"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/VKCOM/php-parser/pkg/ast"
-	"github.com/VKCOM/php-parser/pkg/conf"
 	"github.com/VKCOM/php-parser/pkg/errors"
 	"github.com/VKCOM/php-parser/pkg/parser"
-	"github.com/VKCOM/php-parser/pkg/version"
+	"github.com/VKCOM/php-parser/pkg/position"
+	"github.com/VKCOM/php-parser/pkg/token"
 	"github.com/rxhunter00/XSS-Taint/pkg/asttraverser"
 	"github.com/rxhunter00/XSS-Taint/pkg/asttraverser/astutils"
 	"github.com/rxhunter00/XSS-Taint/pkg/asttraverser/nodetraverser/loopresolver"
@@ -33,6 +34,9 @@ type CFGBuilder struct {
 	FuncContex FunctionContex
 
 	VariableNames map[string]struct{}
+	mainDefs      map[string][]Operand // Definitions of each variable in main scope
+	mainReads     map[string][]Operand // Value of each variable read in main scope
+	globalDefs    map[string][]Operand // Definitions in function scope of variable declared global
 
 	ConstsDef      map[string]Operand
 	BlockIdCounter int
@@ -42,6 +46,11 @@ type CFGBuilder struct {
 	CurrNamespace string
 	currentBlock  *Block
 	currentFunc   *Func
+
+	src         []byte
+	docComments []*token.Token
+	outputFuncs OutputFuncs
+	byRefArgs   ByRefArgs
 }
 
 func (builder *CFGBuilder) GetBlockIdCount() int {
@@ -55,12 +64,24 @@ func (builder *CFGBuilder) GetAnonIdCount() int {
 	return id
 }
 
-func BuildCFG(src []byte, filePath string) *Script {
+func BuildCFG(src []byte, filePath string, outputFuncs OutputFuncs, byRefArgs ByRefArgs) *Script {
 	builder := &CFGBuilder{
 		VariableNames:  make(map[string]struct{}),
+		mainDefs:       make(map[string][]Operand),
+		mainReads:      make(map[string][]Operand),
+		globalDefs:     make(map[string][]Operand),
 		ConstsDef:      make(map[string]Operand),
 		BlockIdCounter: 0,
 		AnnonIdCounter: 0,
+		src:            src,
+		docComments:    make([]*token.Token, 0),
+		outputFuncs:    outputFuncs,
+		byRefArgs:      byRefArgs,
+	}
+	for _, comment := range astutils.GetComments(src, astutils.NewParserConfig(nil)) {
+		if comment.ID == token.T_DOC_COMMENT && comment.Position != nil {
+			builder.docComments = append(builder.docComments, comment)
+		}
 	}
 	fileName := filepath.Base(filePath)
 
@@ -76,19 +97,64 @@ func BuildCFG(src []byte, filePath string) *Script {
 	}
 	builder.Script = NewScript(mainFunction, filePath)
 	builder.parseFunc(mainFunction, nil, rootNode.Stmts)
+	builder.linkGlobalVars()
 	return builder.Script
 }
 
+// Get doc comment right before the declaration, attributes can be between them
+func (builder *CFGBuilder) getDocComment(pos *position.Position) string {
+	if pos == nil {
+		return ""
+	}
+	for i := len(builder.docComments) - 1; i >= 0; i-- {
+		comment := builder.docComments[i]
+		if comment.Position.EndPos > pos.StartPos {
+			continue
+		}
+		gap := strings.TrimSpace(strings.TrimPrefix(string(builder.src[comment.Position.EndPos:pos.StartPos]), "/"))
+		if gap == "" || (strings.HasPrefix(gap, "#[") && !strings.ContainsAny(gap, ";{}")) {
+			return string(comment.Value)
+		}
+		return ""
+	}
+	return ""
+}
+
+// Global variable read in function scope through $GLOBALS or global statement
+// may hold any definition of the variable in main scope, and variable read in main
+// scope may hold any write to the global variable in function scope
+func (builder *CFGBuilder) linkGlobalVars() {
+	names := make([]string, 0, len(builder.Script.GlobalVars))
+	for name := range builder.Script.GlobalVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		globalVar := builder.Script.GlobalVars[name]
+		for _, def := range builder.mainDefs[name] {
+			for _, user := range globalVar.GetUsers() {
+				def.AddUser(user)
+			}
+		}
+		// $GLOBALS['name'] write is the global variable, global $name write is a local definition
+		funcDefs := append([]Operand{globalVar}, builder.globalDefs[name]...)
+		for _, read := range builder.mainReads[name] {
+			for _, user := range read.GetUsers() {
+				for _, def := range funcDefs {
+					def.AddUser(user)
+				}
+			}
+		}
+	}
+}
+
 func (builder *CFGBuilder) parseAST(src []byte, filename string) *ast.Root {
 
 	var parserErrors []*errors.Error
 	errorHandler := func(e *errors.Error) {
 		parserErrors = append(parserErrors, e)
 	}
-	config := conf.Config{
-		Version:          &version.Version{Major: 8},
-		ErrorHandlerFunc: errorHandler,
-	}
+	config := astutils.NewParserConfig(errorHandler)
 
 	rootNode, err := parser.Parse(src, config)
 	if err != nil {
@@ -168,6 +234,11 @@ func (builder *CFGBuilder) parseFunc(functionF *Func, functionParams []ast.Verte
 		)
 
 		opParam.Result.(*TemporaryOperand).Original = NewOperandVariable(paramName, nil)
+		opParam.ClassType = getClassType(param.Type)
+		if len(param.Modifiers) > 0 && opParam.ClassType != "" {
+			// promoted constructor parameter is also a property
+			builder.addPropertyType(paramName.Val, opParam.ClassType)
+		}
 
 		functionF.Params = append(functionF.Params, opParam)
 
@@ -182,6 +253,12 @@ func (builder *CFGBuilder) parseFunc(functionF *Func, functionParams []ast.Verte
 		log.Fatalf("parseFunc: Error %v", err)
 	}
 
+	// buffers left open are flushed at the end
+	if !endBlock.Dead && len(builder.FuncContex.OutputBuffers) > 0 {
+		builder.currentBlock = endBlock
+		builder.flushOutputBuffers(nil)
+	}
+
 	builder.currentBlock = prevBlock
 	if endBlock.Dead {
 		endBlock.AddInstructions(NewOpReturn(nil, nil))
@@ -202,11 +279,41 @@ func (builder *CFGBuilder) parseFunc(functionF *Func, functionParams []ast.Verte
 			block.AddPhi(phi)
 		}
 	}
+	if functionF == builder.Script.Main && !endBlock.Dead {
+		builder.saveExitSuperGlobals(endBlock)
+	}
 	builder.currentFunc = prevFunc
 	builder.FuncContex = prevFuncContex
 
 }
 
+// Record class of the property of the current class, the first declaration is kept
+func (builder *CFGBuilder) addPropertyType(propName string, classType string) {
+	if builder.currClassOper == nil {
+		return
+	}
+	key := builder.currClassOper.Val + "::" + strings.TrimPrefix(propName, "$")
+	if _, ok := builder.Script.PropertyTypes[key]; !ok {
+		builder.Script.PropertyTypes[key] = classType
+	}
+}
+
+// Get class name of the declared type, such as Request of ?Request $request
+func getClassType(typeNode ast.Vertex) string {
+	if nullable, ok := typeNode.(*ast.Nullable); ok {
+		typeNode = nullable.Expr
+	}
+	switch typeNode.(type) {
+	case *ast.Name, *ast.NameFullyQualified, *ast.NameRelative:
+		typename, err := astutils.GetNameString(typeNode)
+		if err != nil || typename == "mixed" || typename == "void" || IsBuiltInType(typename) {
+			return ""
+		}
+		return typename
+	}
+	return ""
+}
+
 func (builder *CFGBuilder) parseTypeNode(parType ast.Vertex) OpType {
 	switch parT := parType.(type) {
 	case nil:
@@ -221,8 +328,8 @@ func (builder *CFGBuilder) parseTypeNode(parType ast.Vertex) OpType {
 		} else if IsBuiltInType(typename) {
 			return NewOpTypeLiteral(typename, false, parT.GetPosition())
 		} else {
-			//reference here
-			return nil
+			// class or interface name
+			return NewOpTypeReference(NewOperandString(typename), false, parT.GetPosition())
 		}
 	case *ast.Nullable:
 		// In case nullable parameter such as ?float
@@ -0,0 +1,130 @@
+package cfg
+
+import "testing"
+
+// Build the script of PHP source
+func buildTestScript(t *testing.T, src string) *Script {
+	t.Helper()
+	return BuildCFG([]byte(src), "/app/test.php", NewOutputFuncs(), make(ByRefArgs))
+}
+
+// Instructions of every block of the function
+func getOps(fn *Func) []Op {
+	ops := make([]Op, 0)
+	visited := make(map[*Block]struct{})
+	var visit func(block *Block)
+	visit = func(block *Block) {
+		if _, ok := visited[block]; ok || block == nil {
+			return
+		}
+		visited[block] = struct{}{}
+		for _, op := range block.Instructions {
+			ops = append(ops, op)
+			for _, subBlock := range GetSubBlocks(op) {
+				visit(subBlock)
+			}
+		}
+	}
+	visit(fn.CFGBlock)
+	return ops
+}
+
+// Echo statements of the function in order
+func getEchoes(fn *Func) []*OpEcho {
+	echoes := make([]*OpEcho, 0)
+	for _, op := range getOps(fn) {
+		if echo, ok := op.(*OpEcho); ok {
+			echoes = append(echoes, echo)
+		}
+	}
+	return echoes
+}
+
+func TestHeaderContentType(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers [][]Operand
+		// content type of the echo after the header() calls, nil if never set
+		want Operand
+	}{
+		{name: "never set"},
+		{name: "json", headers: [][]Operand{{NewOperandString("Content-Type: application/json")}}, want: NewOperandString("application/json")},
+		{
+			name:    "other header",
+			headers: [][]Operand{{NewOperandString("X-Frame-Options: DENY")}},
+		},
+		{
+			name: "non constant content type",
+			headers: [][]Operand{{
+				NewOpExprBinaryConcat(NewOperandString("Content-Type: "), NewTemporaryOperand(nil), nil, nil, nil).Result,
+			}},
+			want: NewOperandString(""),
+		},
+		{
+			name:    "removed",
+			headers: [][]Operand{{NewOperandString("Content-Type: text/plain")}, nil},
+			want:    NewOperandString("text/html"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := newTestBuilder(t)
+			for _, args := range tt.headers {
+				if args == nil {
+					builder.parseHeaderCall("header_remove", nil)
+				} else {
+					builder.parseHeaderCall("header", args)
+				}
+			}
+			echo := builder.addOutput(NewTemporaryOperand(nil), 0, nil)
+			if tt.want == nil {
+				if echo.ContentType != nil {
+					t.Errorf("ContentType = %v, want nil", echo.ContentType)
+				}
+				return
+			}
+			got, ok := GetConstString(echo.ContentType)
+			if want, _ := GetConstString(tt.want); !ok || got != want {
+				t.Errorf("ContentType = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestLinkGlobalVars(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		// echo in main can output the value written in function f
+		wantLinked bool
+	}{
+		{"$GLOBALS write", `<?php function f() { $GLOBALS['x'] = $_GET['x']; } f(); echo $x;`, true},
+		{"global statement write", `<?php function f() { global $x; $x = $_GET['x']; } f(); echo $x;`, true},
+		{"local write", `<?php function f() { $x = $_GET['x']; } f(); echo $x;`, false},
+		{"write before global statement", `<?php function f() { $x = $_GET['x']; global $x; } f(); echo $x;`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := buildTestScript(t, tt.src)
+			echoes := getEchoes(script.Main)
+			if len(echoes) != 1 {
+				t.Fatalf("got %d echo, want 1", len(echoes))
+			}
+			linked := false
+			for _, op := range getOps(script.FuncsMap["f"]) {
+				assign, ok := op.(*OpExprAssign)
+				if !ok {
+					continue
+				}
+				if _, ok := assign.Expr.GetWriter().(*OpExprArrayDimFetch); ok {
+					for _, user := range assign.Var.GetUsers() {
+						linked = linked || user == echoes[0]
+					}
+				}
+			}
+			if linked != tt.wantLinked {
+				t.Errorf("echo is linked to the write = %v, want %v", linked, tt.wantLinked)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package cfg
+
+import "strings"
+
+// Argument positions passed by reference which the call write to, keyed by lowercased
+// function name, class::method for static call and ::method for method call
+type ByRefArgs map[string][]int
+
+// Add arguments passed by reference, the call result is written to them
+func (byRefArgs ByRefArgs) Add(callName string, args []int) {
+	callName = strings.ToLower(callName)
+	for _, arg := range args {
+		if !containsInt(byRefArgs[callName], arg) {
+			byRefArgs[callName] = append(byRefArgs[callName], arg)
+		}
+	}
+}
+
+func containsInt(list []int, val int) bool {
+	for _, v := range list {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
+
+func (byRefArgs ByRefArgs) get(callName string) []int {
+	callName = strings.ToLower(callName)
+	className, methodName, isMethod := strings.Cut(callName, "::")
+	if !isMethod {
+		return byRefArgs[withoutNamespace(callName)]
+	}
+	if args, ok := byRefArgs[withoutNamespace(className)+"::"+methodName]; ok {
+		return args
+	}
+	return byRefArgs["::"+methodName]
+}
+
+func withoutNamespace(name string) string {
+	if i := strings.LastIndex(name, "\\"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// Write the call result to the arguments passed by reference, such as the output array of parse_str
+func (cb *CFGBuilder) writeByRefArgs(callName string, args []Operand, result Operand) {
+	for _, i := range cb.byRefArgs.get(callName) {
+		if i < 0 || i >= len(args) || !isVariableOperand(args[i]) {
+			continue
+		}
+		write := cb.writeVariable(args[i])
+		cb.currentBlock.AddInstructions(NewOpExprAssign(write, result, nil, nil, nil))
+	}
+}
+
+func isVariableOperand(oper Operand) bool {
+	for {
+		temp, ok := oper.(*TemporaryOperand)
+		if !ok || temp.Original == nil {
+			break
+		}
+		oper = temp.Original
+	}
+	_, ok := oper.(*OperandVariable)
+	return ok
+}
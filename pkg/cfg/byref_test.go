@@ -0,0 +1,33 @@
+package cfg
+
+import "testing"
+
+func TestWriteByRefArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		byRefArgs ByRefArgs
+		// echo outputs the result of parse_str
+		wantWritten bool
+	}{
+		{"registered argument", ByRefArgs{"parse_str": {1}}, true},
+		{"other argument", ByRefArgs{"parse_str": {0}}, false},
+		{"not registered", ByRefArgs{}, false},
+	}
+	src := `<?php parse_str($_SERVER['QUERY_STRING'], $out); echo $out;`
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := BuildCFG([]byte(src), "/app/test.php", NewOutputFuncs(), tt.byRefArgs)
+			echoes := getEchoes(script.Main)
+			if len(echoes) != 1 {
+				t.Fatalf("got %d echo, want 1", len(echoes))
+			}
+			written := false
+			if assign, ok := echoes[0].Expr.GetWriter().(*OpExprAssign); ok {
+				_, written = assign.Expr.GetWriter().(*OpExprFunctionCall)
+			}
+			if written != tt.wantWritten {
+				t.Errorf("echo outputs the call result = %v, want %v", written, tt.wantWritten)
+			}
+		})
+	}
+}
@@ -7,6 +7,242 @@ import (
 	"strings"
 )
 
+// Superglobal variable and the name of its symbolic value
+var SuperGlobals = map[string]string{
+	"$_GET":     "globalgets",
+	"$_POST":    "globalposts",
+	"$_REQUEST": "globalrequest",
+	"$_FILES":   "globalfiles",
+	"$_COOKIE":  "globalcookies",
+	"$_SERVER":  "globalservers",
+}
+
+func IsSuperGlobal(name string) bool {
+	_, ok := SuperGlobals[name]
+	return ok
+}
+
+// Get string value of operand that can be known at compile time,
+// such as literal or concatenation of literals
+func GetConstString(oper Operand) (string, bool) {
+	switch o := GetOperVal(oper).(type) {
+	case *OperandString:
+		return o.Val, true
+	case *OperandNumber:
+		return strconv.FormatFloat(o.Val, 'f', -1, 64), true
+	}
+	switch writer := oper.GetWriter().(type) {
+	case *OpExprBinaryConcat:
+		left, ok := GetConstString(writer.Left)
+		if !ok {
+			return "", false
+		}
+		right, ok := GetConstString(writer.Right)
+		if !ok {
+			return "", false
+		}
+		return left + right, true
+	case *OpExprConcatList:
+		res := ""
+		for _, part := range writer.List {
+			partStr, ok := GetConstString(part)
+			if !ok {
+				return "", false
+			}
+			res += partStr
+		}
+		return res, true
+	}
+	return "", false
+}
+
+// Get names of constants which value is or-ed into operand,
+// such as FILTER_FLAG_ALLOW_FRACTION | FILTER_FLAG_ALLOW_THOUSAND
+func GetConstNames(oper Operand) ([]string, bool) {
+	switch writer := oper.GetWriter().(type) {
+	case *OpExprConstFetch:
+		name, err := GetOperandName(writer.Name)
+		if err != nil {
+			return nil, false
+		}
+		// unqualified constant fall back to global namespace
+		if i := strings.LastIndex(name, "\\"); i >= 0 {
+			name = name[i+1:]
+		}
+		return []string{name}, true
+	case *OpExprBinaryBitwiseOr:
+		left, ok := GetConstNames(writer.Left)
+		if !ok {
+			return nil, false
+		}
+		right, ok := GetConstNames(writer.Right)
+		if !ok {
+			return nil, false
+		}
+		return append(left, right...), true
+	case *OpExprAssign:
+		return GetConstNames(writer.Expr)
+	}
+	return nil, false
+}
+
+// Get array literal which value is assigned to operand
+func GetArrayLiteral(oper Operand) (*OpExprArray, bool) {
+	switch writer := oper.GetWriter().(type) {
+	case *OpExprArray:
+		return writer, true
+	case *OpExprAssign:
+		return GetArrayLiteral(writer.Expr)
+	}
+	return nil, false
+}
+
+// Get literal prefixes of the subject matched by anchored regex pattern,
+// such as #^https?://# which match subject starting with http:// or https://
+func GetRegexPrefixes(pattern string) ([]string, bool) {
+	if len(pattern) < 2 {
+		return nil, false
+	}
+	endDelim := pattern[0]
+	switch endDelim {
+	case '(':
+		endDelim = ')'
+	case '[':
+		endDelim = ']'
+	case '{':
+		endDelim = '}'
+	case '<':
+		endDelim = '>'
+	}
+	end := strings.LastIndexByte(pattern, endDelim)
+	// ^ match start of each line in multiline mode
+	if end <= 0 || strings.ContainsAny(pattern[end+1:], "mx") {
+		return nil, false
+	}
+	prefixes := make([]string, 0)
+	for _, alt := range splitRegexAlternatives(pattern[1:end]) {
+		if !strings.HasPrefix(alt, "^") {
+			return nil, false
+		}
+		altPrefixes, _ := expandRegexLiteral(alt[1:])
+		prefixes = append(prefixes, altPrefixes...)
+	}
+	return prefixes, true
+}
+
+const maxRegexPrefixes = 32
+
+// Expand literal start of regex into the strings it can match,
+// false if it stop before the end of regex
+func expandRegexLiteral(re string) ([]string, bool) {
+	prefixes := []string{""}
+	for i := 0; i < len(re); {
+		var alts []string
+		switch c := re[i]; {
+		case c == '\\':
+			// escaped character class such as \d
+			if i+1 >= len(re) || isAlnum(re[i+1]) {
+				return prefixes, false
+			}
+			alts = []string{re[i+1 : i+2]}
+			i += 2
+		case c == '(':
+			end := findRegexGroupEnd(re, i)
+			if end < 0 {
+				return prefixes, false
+			}
+			for _, alt := range splitRegexAlternatives(strings.TrimPrefix(re[i+1:end], "?:")) {
+				altPrefixes, complete := expandRegexLiteral(alt)
+				if !complete {
+					return prefixes, false
+				}
+				alts = append(alts, altPrefixes...)
+			}
+			i = end + 1
+		case strings.IndexByte(".[]{}*+?|$^)", c) >= 0:
+			return prefixes, false
+		default:
+			alts = []string{re[i : i+1]}
+			i++
+		}
+		if i < len(re) && re[i] == '?' {
+			alts = append(alts, "")
+			i++
+		} else if i < len(re) && strings.IndexByte("*+{", re[i]) >= 0 {
+			return prefixes, false
+		}
+		if len(prefixes)*len(alts) > maxRegexPrefixes {
+			return prefixes, false
+		}
+		next := make([]string, 0, len(prefixes)*len(alts))
+		for _, prefix := range prefixes {
+			for _, alt := range alts {
+				next = append(next, prefix+alt)
+			}
+		}
+		prefixes = next
+	}
+	return prefixes, true
+}
+
+// Split regex on top level |
+func splitRegexAlternatives(re string) []string {
+	alts := make([]string, 0)
+	depth, start := 0, 0
+	for i := 0; i < len(re); i++ {
+		switch re[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '|':
+			if depth == 0 {
+				alts = append(alts, re[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(alts, re[start:])
+}
+
+func findRegexGroupEnd(re string, start int) int {
+	depth := 0
+	for i := start; i < len(re); i++ {
+		switch re[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isAlnum(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// Get lowercased media type of Content-Type header line, such as application/json
+// of "Content-Type: application/json; charset=utf-8"
+func GetContentTypeHeader(header string) (string, bool) {
+	i := strings.IndexByte(header, ':')
+	if i < 0 || !strings.EqualFold(strings.TrimSpace(header[:i]), "content-type") {
+		return "", false
+	}
+	mediaType := header[i+1:]
+	if j := strings.IndexByte(mediaType, ';'); j >= 0 {
+		mediaType = mediaType[:j]
+	}
+	return strings.ToLower(strings.TrimSpace(mediaType)), true
+}
+
 func IsBuiltInType(name string) bool {
 	builtInTypes := map[string]struct{}{
 		"self":     {},
@@ -0,0 +1,59 @@
+package cfg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetRegexPrefixes(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+		wantOk  bool
+	}{
+		{"#^https?://#", []string{"https://", "http://"}, true},
+		{"#^https?://#i", []string{"https://", "http://"}, true},
+		{"/^(https|mailto):/", []string{"https:", "mailto:"}, true},
+		{"/^(?:https|ftp):\\/\\//", []string{"https://", "ftp://"}, true},
+		{"{^/[a-z]+}", []string{"/"}, true},
+		{"/^\\w+:/", []string{""}, true},
+		{"/^a|^b/", []string{"a", "b"}, true},
+		{"/https:/", nil, false},
+		{"/^a|b/", nil, false},
+		{"/^https:/m", nil, false},
+		{"/", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, ok := GetRegexPrefixes(tt.pattern)
+			if ok != tt.wantOk {
+				t.Fatalf("GetRegexPrefixes() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetRegexPrefixes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetContentTypeHeader(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+		wantOk bool
+	}{
+		{"Content-Type: application/json", "application/json", true},
+		{"content-type:text/plain; charset=UTF-8", "text/plain", true},
+		{"Content-Type: Text/HTML", "text/html", true},
+		{"Location: /", "", false},
+		{"Content-Type", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			got, ok := GetContentTypeHeader(tt.header)
+			if ok != tt.wantOk || got != tt.want {
+				t.Errorf("GetContentTypeHeader() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
@@ -31,9 +31,11 @@ type Func struct {
 	CallableOp Op
 	OpGeneral
 
-	FuncHasTaint bool
-	Sources      []Op
-	Calls        []Op
+	FuncHasTaint      bool
+	Sources           []Op
+	Calls             []Op
+	EntrySuperGlobals map[string]*OpExprAssign // Definition of each superglobal at function entry
+	DocComment        string                   // Doc comment before the declaration
 }
 
 func NewFunc(name string, flags FuncModifFlag, returnType OpType, entryBlock *Block, position *position.Position) (*Func, error) {
@@ -49,6 +51,8 @@ func NewFunc(name string, flags FuncModifFlag, returnType OpType, entryBlock *Bl
 		CFGBlock:      entryBlock,
 		OpGeneral:     NewOpGeneral(position),
 		FuncHasTaint:  false,
+
+		EntrySuperGlobals: make(map[string]*OpExprAssign),
 	}, nil
 }
 func NewClassFunc(name string, flags FuncModifFlag, returnType OpType, entryBlock *Block, fclass OperandString, position *position.Position) (*Func, error) {
@@ -64,6 +68,8 @@ func NewClassFunc(name string, flags FuncModifFlag, returnType OpType, entryBloc
 		CFGBlock:      entryBlock,
 		OpGeneral:     NewOpGeneral(position),
 		FuncHasTaint:  false,
+
+		EntrySuperGlobals: make(map[string]*OpExprAssign),
 	}, nil
 }
 func (op *Func) GetScopedName() string {
@@ -114,6 +120,17 @@ func (op *Func) IsClosure() bool {
 	return op.Flags&FUNC_MODIF_FLAG_CLOSURE != 0
 }
 
+// Get attribute groups of the function or method declaration
+func (op *Func) GetAttrGroups() []*OpAttributeGroup {
+	switch callable := op.CallableOp.(type) {
+	case *OpStmtFunc:
+		return callable.AttrGroups
+	case *OpStmtClassMethod:
+		return callable.AttrGroups
+	}
+	return nil
+}
+
 func (op *Func) GetType() string {
 	return "Func"
 }
@@ -131,5 +148,6 @@ func (op *Func) Clone() Op {
 		CFGBlock:      op.CFGBlock,
 		CallableOp:    op.CallableOp,
 		FuncHasTaint:  op.FuncHasTaint,
+		DocComment:    op.DocComment,
 	}
 }
@@ -1,5 +1,15 @@
 package cfg
 
+import "github.com/VKCOM/php-parser/pkg/position"
+
+// DynamicScope is a source of local variables whose names are unknown at parse time,
+// such as the array given to extract() or a variable variable like $$name = $value
+type DynamicScope struct {
+	Oper     Operand
+	IsArray  bool
+	Position *position.Position
+}
+
 type FunctionContex struct {
 	Labels          map[string]*Block
 	UnresolvedGotos map[string][]*Block
@@ -7,6 +17,12 @@ type FunctionContex struct {
 	IncompletePhis  map[*Block]map[string]*OpPhi
 	CurrConds       []Operand
 	IsComplete      bool
+	DynamicScopes   []DynamicScope      // extract() and variable variable writes seen so far
+	DefinedVars     map[string]int      // Number of dynamic scopes seen when each variable is last written
+	GlobalNames     map[string]struct{} // Variable declared by global statement
+	OutputBuffers   []Operand           // Callback of each ob_start() not yet ended, nil if no callback
+	OutputTail      string              // End of html outputted so far, to find the context of next output
+	ContentTypeSet  bool                // header() has set the response content type
 }
 
 func NewFunctionContex() FunctionContex {
@@ -17,6 +33,10 @@ func NewFunctionContex() FunctionContex {
 		IncompletePhis:  make(map[*Block]map[string]*OpPhi),
 		CurrConds:       make([]Operand, 0),
 		IsComplete:      false, // Flag for complete CFG
+		DynamicScopes:   make([]DynamicScope, 0),
+		DefinedVars:     make(map[string]int),
+		GlobalNames:     make(map[string]struct{}),
+		OutputBuffers:   make([]Operand, 0),
 	}
 }
 
@@ -87,3 +107,35 @@ func (funcctx *FunctionContex) PushCond(cond Operand) {
 func (funcctx *FunctionContex) PopCond() {
 	funcctx.CurrConds = funcctx.CurrConds[:len(funcctx.CurrConds)-1]
 }
+
+// Keep the end of html output, long enough to contain the current tag or script
+const maxOutputTail = 16384
+
+func (funcctx *FunctionContex) AppendOutput(html string) {
+	funcctx.OutputTail += html
+	if len(funcctx.OutputTail) > maxOutputTail {
+		funcctx.OutputTail = funcctx.OutputTail[len(funcctx.OutputTail)-maxOutputTail:]
+	}
+}
+
+// Register a new dynamic scope, variable defined before it may be overwritten
+func (funcctx *FunctionContex) AddDynamicScope(scope DynamicScope) {
+	funcctx.DynamicScopes = append(funcctx.DynamicScopes, scope)
+}
+
+func (funcctx *FunctionContex) SetDefinedVar(name string) {
+	funcctx.DefinedVars[name] = len(funcctx.DynamicScopes)
+}
+
+// Get the dynamic scopes registered since the last write of variable name, each of them may overwrite it
+func (funcctx *FunctionContex) GetDynamicScopes(name string) []DynamicScope {
+	if name == "$this" || name == "$GLOBALS" || IsSuperGlobal(name) {
+		return nil
+	}
+	return funcctx.DynamicScopes[funcctx.DefinedVars[name]:]
+}
+
+// Check if a read of variable name may come from a dynamic scope
+func (funcctx *FunctionContex) IsDynamicVar(name string) bool {
+	return len(funcctx.GetDynamicScopes(name)) > 0
+}
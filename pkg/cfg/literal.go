@@ -0,0 +1,110 @@
+package cfg
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Value of PHP string literal without the quotes, the escape sequences are decoded by the
+// rules of the quote. Value without quotes such as resolved magic constant is returned as is
+func decodeStringLiteral(raw string) string {
+	// binary string prefix
+	if len(raw) > 1 && (raw[0] == 'b' || raw[0] == 'B') && (raw[1] == '\'' || raw[1] == '"') {
+		raw = raw[1:]
+	}
+	if len(raw) < 2 || (raw[0] != '\'' && raw[0] != '"') || raw[len(raw)-1] != raw[0] {
+		return raw
+	}
+	return decodeEscapes(raw[1:len(raw)-1], raw[0])
+}
+
+// Decode the escape sequences of string literal quoted by quote,
+// single quote only escapes itself and backslash, double quote
+// and heredoc (quote 0) escape the special characters
+func decodeEscapes(s string, quote byte) string {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		c := s[i+1]
+		if quote == '\'' {
+			if c == '\'' || c == '\\' {
+				i++
+			}
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch c {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'v':
+			sb.WriteByte('\v')
+		case 'e':
+			sb.WriteByte(0x1b)
+		case 'f':
+			sb.WriteByte('\f')
+		case '\\', '$':
+			sb.WriteByte(c)
+		case '"':
+			if quote != '"' {
+				sb.WriteByte('\\')
+			}
+			sb.WriteByte(c)
+		case 'x':
+			// \x followed by one or two hex digits
+			j := i + 1
+			for j < len(s) && j < i+3 && isHexDigit(s[j]) {
+				j++
+			}
+			if j == i+1 {
+				sb.WriteString(`\x`)
+				continue
+			}
+			n, _ := strconv.ParseUint(s[i+1:j], 16, 8)
+			sb.WriteByte(byte(n))
+			i = j - 1
+		case 'u':
+			// \u{codepoint}
+			end := strings.IndexByte(s[i:], '}')
+			if i+1 >= len(s) || s[i+1] != '{' || end < 0 {
+				sb.WriteString(`\u`)
+				continue
+			}
+			n, err := strconv.ParseUint(s[i+2:i+end], 16, 32)
+			if err != nil {
+				sb.WriteString(`\u`)
+				continue
+			}
+			sb.WriteRune(rune(n))
+			i += end
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			// octal of one to three digits
+			j := i + 1
+			for j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7' {
+				j++
+			}
+			n, _ := strconv.ParseUint(s[i:j], 8, 16)
+			sb.WriteByte(byte(n))
+			i = j - 1
+		default:
+			// unknown escape sequence is kept
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
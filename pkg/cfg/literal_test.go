@@ -0,0 +1,71 @@
+package cfg
+
+import "testing"
+
+func TestDecodeStringLiteral(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{`'it\'s \\ \n \x41'`, `it's \ \n \x41`},
+		{`"<a href=\""`, `<a href="`},
+		{`"\t\r\n\v\e\f\\\$"`, "\t\r\n\v\x1b\f\\$"},
+		{`"\x41\x4a2\101\0\u{263A}"`, "AJ2A\x00\u263a"},
+		{`"\q \x \u{zz} \u41 \'"`, `\q \x \u{zz} \u41 \'`},
+		{`b"\x41"`, "A"},
+		// resolved magic constant isn't quoted
+		{`bar`, `bar`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			if got := decodeStringLiteral(tt.raw); got != tt.want {
+				t.Errorf("decodeStringLiteral() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Value of the echoed literal, heredoc is the concatenation of its parts
+func getLiteral(oper Operand) (string, bool) {
+	if str, ok := GetOperVal(oper).(*OperandString); ok {
+		return str.Val, true
+	}
+	concat, ok := oper.GetWriter().(*OpExprConcatList)
+	if !ok {
+		return "", false
+	}
+	val := ""
+	for _, part := range concat.List {
+		str, ok := GetOperVal(part).(*OperandString)
+		if !ok {
+			return "", false
+		}
+		val += str.Val
+	}
+	return val, true
+}
+
+func TestParseStringLiteral(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"single quoted", `<?php echo 'it\'s "quoted"\n';`, `it's "quoted"\n`},
+		{"double quoted", `<?php echo "<a href=\"";`, `<a href="`},
+		{"double quoted escapes", `<?php echo "a\tb\x41\u{263A}";`, "a\tbA\u263a"},
+		{"heredoc", "<?php echo <<<EOT\n\\\"a\\\" \\x41\nEOT;\n", `\"a\" A`},
+		{"nowdoc", "<?php echo <<<'EOT'\n\\\"a\\\" \\x41\nEOT;\n", `\"a\" \x41`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			echoes := getEchoes(buildTestScript(t, tt.src).Main)
+			if len(echoes) != 1 {
+				t.Fatalf("got %d echo, want 1", len(echoes))
+			}
+			if got, ok := getLiteral(echoes[0].Expr); !ok || got != tt.want {
+				t.Errorf("echo %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -15,6 +15,8 @@ type OpExprParam struct {
 	DefaultBlock *Block
 	DecalreType  OpType
 	Result       Operand
+	// Class of the declared type, empty for builtin type
+	ClassType string
 	OpGeneral
 }
 
@@ -41,6 +43,7 @@ func NewOpExprParam(name Operand,
 
 	AddUseRefs(op, name, defaultVar)
 	AddWriteRef(op, name)
+	AddWriteRef(op, op.Result)
 
 	return op
 }
@@ -81,6 +84,7 @@ func (op *OpExprParam) Clone() Op {
 		DefaultBlock: op.DefaultBlock,
 		DecalreType:  op.DecalreType,
 		Result:       op.Result,
+		ClassType:    op.ClassType,
 	}
 }
 
@@ -350,6 +354,67 @@ func (op *OpExprArrayDimFetch) String() string {
 	return ""
 }
 
+// Write to an array entry, $arr['key'] = expr
+// Result is the new version of the array
+type OpExprArrayDimAssign struct {
+	Var    Operand
+	Dim    Operand
+	Expr   Operand
+	Result Operand
+	OpGeneral
+}
+
+func NewOpExprArrayDimAssign(vr, dim, expr, result Operand, pos *position.Position) *OpExprArrayDimAssign {
+	op := &OpExprArrayDimAssign{
+		Var:       vr,
+		Dim:       dim,
+		Expr:      expr,
+		Result:    result,
+		OpGeneral: NewOpGeneral(pos),
+	}
+
+	AddUseRefs(op, vr, dim, expr)
+	AddWriteRef(op, op.Result)
+
+	return op
+}
+
+func (op *OpExprArrayDimAssign) GetType() string {
+	return "ExprArrayDimAssign"
+}
+
+func (op *OpExprArrayDimAssign) GetOpVars() map[string]Operand {
+	return map[string]Operand{
+		"Var":    op.Var,
+		"Dim":    op.Dim,
+		"Expr":   op.Expr,
+		"Result": op.Result,
+	}
+}
+
+func (op *OpExprArrayDimAssign) ChangeOpVar(vrName string, vr Operand) {
+	switch vrName {
+	case "Var":
+		op.Var = vr
+	case "Dim":
+		op.Dim = vr
+	case "Expr":
+		op.Expr = vr
+	case "Result":
+		op.Result = vr
+	}
+}
+
+func (op *OpExprArrayDimAssign) Clone() Op {
+	return &OpExprArrayDimAssign{
+		OpGeneral: op.OpGeneral,
+		Var:       op.Var,
+		Dim:       op.Dim,
+		Expr:      op.Expr,
+		Result:    op.Result,
+	}
+}
+
 // Binary Op
 
 type OpExprBinaryConcat struct {
@@ -2604,6 +2669,10 @@ type OpExprFunctionCall struct {
 	CalledFunc *Func
 	NamePos    *position.Position
 	ArgsPos    []*position.Position
+	// End of html outputted before the call, for function echoing its argument
+	HTMLPrefix string
+	// Echo appending the output of the call to the active output buffer, nil outside output buffer
+	OutputBuffer *OpEcho
 }
 
 func NewOpExprFunctionCall(name Operand, args []Operand, namePos *position.Position, argsPos []*position.Position, pos *position.Position) *OpExprFunctionCall {
@@ -2682,11 +2751,13 @@ func (op *OpExprFunctionCall) Clone() Op {
 	args := make([]Operand, len(op.Args))
 	copy(args, op.Args)
 	return &OpExprFunctionCall{
-		OpGeneral:  op.OpGeneral,
-		Name:       op.Name,
-		Args:       args,
-		CalledFunc: op.CalledFunc,
-		Result:     op.Result,
+		OpGeneral:    op.OpGeneral,
+		Name:         op.Name,
+		Args:         args,
+		CalledFunc:   op.CalledFunc,
+		Result:       op.Result,
+		HTMLPrefix:   op.HTMLPrefix,
+		OutputBuffer: op.OutputBuffer,
 	}
 }
 
@@ -2887,6 +2958,9 @@ type OpExprMethodCall struct {
 	VarPos     *position.Position
 	NamePos    *position.Position
 	ArgsPos    []*position.Position
+
+	// Class::property of the object when it is a property, such as Controller::renderer of $this->renderer
+	ObjectProperty string
 }
 
 func NewOpExprMethodCall(vr, name Operand, args []Operand, varPos, namePos *position.Position, argsPos []*position.Position, pos *position.Position) *OpExprMethodCall {
@@ -3189,6 +3263,10 @@ type OpExprPrint struct {
 	OpGeneral
 	Expr   Operand
 	Result Operand
+	// Html outputted before the print
+	HTMLPrefix string
+	// Response content type set by header() before the print, nil if never set
+	ContentType Operand
 }
 
 func NewOpExprPrint(expr Operand, pos *position.Position) *OpExprPrint {
@@ -3211,10 +3289,14 @@ func (op *OpExprPrint) GetType() string {
 }
 
 func (op *OpExprPrint) GetOpVars() map[string]Operand {
-	return map[string]Operand{
+	vars := map[string]Operand{
 		"Expr":   op.Expr,
 		"Result": op.Result,
 	}
+	if op.ContentType != nil {
+		vars["ContentType"] = op.ContentType
+	}
+	return vars
 }
 
 func (op *OpExprPrint) ChangeOpVar(vrName string, vr Operand) {
@@ -3223,14 +3305,18 @@ func (op *OpExprPrint) ChangeOpVar(vrName string, vr Operand) {
 		op.Expr = vr
 	case "Result":
 		op.Result = vr
+	case "ContentType":
+		op.ContentType = vr
 	}
 }
 
 func (op *OpExprPrint) Clone() Op {
 	return &OpExprPrint{
-		OpGeneral: op.OpGeneral,
-		Expr:      op.Expr,
-		Result:    op.Result,
+		OpGeneral:   op.OpGeneral,
+		Expr:        op.Expr,
+		Result:      op.Result,
+		HTMLPrefix:  op.HTMLPrefix,
+		ContentType: op.ContentType,
 	}
 }
 
@@ -742,6 +742,13 @@ func (op *OpConst) Clone() Op {
 type OpEcho struct {
 	OpGeneral
 	Expr Operand
+	// Html outputted before the echo
+	HTMLPrefix string
+	// Set when echo is inside output buffer, content of the buffer before and after the echo
+	Buffer Operand
+	Result Operand
+	// Response content type set by header() before the echo, nil if never set
+	ContentType Operand
 }
 
 func NewOpEcho(expr Operand, pos *position.Position) *OpEcho {
@@ -757,33 +764,71 @@ func NewOpEcho(expr Operand, pos *position.Position) *OpEcho {
 	return Op
 }
 
+// Echo that append expr to the output buffer instead of outputting it
+func NewOpEchoBuffered(expr, buffer Operand, pos *position.Position) *OpEcho {
+	Op := NewOpEcho(expr, pos)
+	Op.Buffer = buffer
+	Op.Result = NewTemporaryOperand(nil)
+
+	AddUseRef(Op, buffer)
+	AddWriteRef(Op, Op.Result)
+
+	return Op
+}
+
+func (op *OpEcho) IsBuffered() bool {
+	return op.Result != nil
+}
+
 func (op *OpEcho) GetType() string {
 	return "Echo"
 }
 
 func (op *OpEcho) GetOpVars() map[string]Operand {
-	return map[string]Operand{
+	vars := map[string]Operand{
 		"Expr": op.Expr,
 	}
+	if op.Buffer != nil {
+		vars["Buffer"] = op.Buffer
+	}
+	if op.Result != nil {
+		vars["Result"] = op.Result
+	}
+	if op.ContentType != nil {
+		vars["ContentType"] = op.ContentType
+	}
+	return vars
 }
 
 func (op *OpEcho) ChangeOpVar(vrName string, vr Operand) {
 	switch vrName {
 	case "Expr":
 		op.Expr = vr
+	case "Buffer":
+		op.Buffer = vr
+	case "Result":
+		op.Result = vr
+	case "ContentType":
+		op.ContentType = vr
 	}
 }
 
 func (op *OpEcho) Clone() Op {
 	return &OpEcho{
-		OpGeneral: op.OpGeneral,
-		Expr:      op.Expr,
+		OpGeneral:   op.OpGeneral,
+		Expr:        op.Expr,
+		HTMLPrefix:  op.HTMLPrefix,
+		Buffer:      op.Buffer,
+		Result:      op.Result,
+		ContentType: op.ContentType,
 	}
 }
 
 type OpExit struct {
 	OpGeneral
 	Expr Operand
+	// Response content type set by header() before the exit, nil if never set
+	ContentType Operand
 }
 
 func NewOpExit(expr Operand, pos *position.Position) *OpExit {
@@ -804,22 +849,29 @@ func (op *OpExit) GetType() string {
 }
 
 func (op *OpExit) GetOpVars() map[string]Operand {
-	return map[string]Operand{
+	vars := map[string]Operand{
 		"Expr": op.Expr,
 	}
+	if op.ContentType != nil {
+		vars["ContentType"] = op.ContentType
+	}
+	return vars
 }
 
 func (op *OpExit) ChangeOpVar(vrName string, vr Operand) {
 	switch vrName {
 	case "Expr":
 		op.Expr = vr
+	case "ContentType":
+		op.ContentType = vr
 	}
 }
 
 func (op *OpExit) Clone() Op {
 	return &OpExit{
-		OpGeneral: op.OpGeneral,
-		Expr:      op.Expr,
+		OpGeneral:   op.OpGeneral,
+		Expr:        op.Expr,
+		ContentType: op.ContentType,
 	}
 }
 
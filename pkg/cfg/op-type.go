@@ -97,6 +97,14 @@ type OpTypeReference struct {
 	OpGeneral
 }
 
+func NewOpTypeReference(declaration Operand, isnullable bool, pos *position.Position) *OpTypeReference {
+	return &OpTypeReference{
+		Declaration: declaration,
+		IsNullable:  isnullable,
+		OpGeneral:   NewOpGeneral(pos),
+	}
+}
+
 func (otr *OpTypeReference) GetKind() OP_TYPE {
 	return TYPE_REFERENCE
 }
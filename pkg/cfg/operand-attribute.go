@@ -67,6 +67,10 @@ func (oa *OperandAttributes) AddAssertion(oper Operand, assert Assertion, mode A
 			return
 		}
 	}
+	// only named variable can be redefined in the conditional block
+	if GetOperNamed(oper) != nil {
+		oa.AssertionsList = append(oa.AssertionsList, VarAssert{Var: oper, Assert: assert})
+	}
 }
 
 func (oa *OperandAttributes) GetAssertions() []VarAssert {
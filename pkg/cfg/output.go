@@ -0,0 +1,77 @@
+package cfg
+
+import (
+	"strings"
+
+	"github.com/VKCOM/php-parser/pkg/position"
+)
+
+// Functions writing to the response body, keyed by lowercased function name,
+// name ending with * matches every function with the prefix
+type OutputFuncs map[string]struct{}
+
+// Create output functions with the builtin functions writing to the response body
+func NewOutputFuncs() OutputFuncs {
+	return OutputFuncs{
+		"printf":            {},
+		"vprintf":           {},
+		"fprintf":           {},
+		"vfprintf":          {},
+		"fwrite":            {},
+		"fputs":             {},
+		"file_put_contents": {},
+		"trigger_error":     {},
+		"user_error":        {},
+	}
+}
+
+// Add function writing to the response body, inside output buffer its output go to the buffer
+func (funcs OutputFuncs) Add(name string) {
+	funcs[strings.ToLower(name)] = struct{}{}
+}
+
+func (funcs OutputFuncs) has(name string) bool {
+	name = withoutNamespace(strings.ToLower(name))
+	if _, ok := funcs[name]; ok {
+		return true
+	}
+	for pattern := range funcs {
+		if strings.HasSuffix(pattern, "*") && strings.HasPrefix(name, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Output of function call inside output buffer go to the buffer, linked to the outputted
+// argument by path generator since the function decide which argument is outputted
+func (cb *CFGBuilder) bufferCallOutput(callOp *OpExprFunctionCall, name string, pos *position.Position) {
+	level := len(cb.FuncContex.OutputBuffers)
+	if level == 0 || !cb.outputFuncs.has(name) {
+		return
+	}
+	callOp.OutputBuffer = cb.addOutput(NewTemporaryOperand(nil), level, pos)
+}
+
+// Get pattern of non constant include path, * replaces the unknown parts,
+// such as views/*.php for 'views/' . $name . '.php'
+func GetIncludePattern(oper Operand) string {
+	if str, ok := GetConstString(oper); ok {
+		return str
+	}
+	pattern := ""
+	switch writer := oper.GetWriter().(type) {
+	case *OpExprBinaryConcat:
+		pattern = GetIncludePattern(writer.Left) + GetIncludePattern(writer.Right)
+	case *OpExprConcatList:
+		for _, part := range writer.List {
+			pattern += GetIncludePattern(part)
+		}
+	default:
+		return "*"
+	}
+	for strings.Contains(pattern, "**") {
+		pattern = strings.ReplaceAll(pattern, "**", "*")
+	}
+	return pattern
+}
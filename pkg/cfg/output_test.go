@@ -0,0 +1,98 @@
+package cfg
+
+import (
+	"testing"
+
+	"github.com/VKCOM/php-parser/pkg/position"
+)
+
+func concat(parts ...Operand) Operand {
+	return NewOpExprConcatList(parts, make([]*position.Position, len(parts)), nil).Result
+}
+
+func TestGetIncludePattern(t *testing.T) {
+	name := NewTemporaryOperand(nil)
+	tests := []struct {
+		name    string
+		include Operand
+		want    string
+	}{
+		{"constant path", NewOperandString("views/home.php"), "views/home.php"},
+		{"unknown path", name, "*"},
+		{"unknown view name", concat(NewOperandString("views/"), name, NewOperandString(".php")), "views/*.php"},
+		{
+			"binary concat",
+			NewOpExprBinaryConcat(NewOperandString("views/"), concat(name, NewOperandString(".php")), nil, nil, nil).Result,
+			"views/*.php",
+		},
+		{"adjacent unknown parts", concat(name, NewOperandString("/"), name, name, NewOperandString(".tpl")), "*/*.tpl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetIncludePattern(tt.include); got != tt.want {
+				t.Errorf("GetIncludePattern() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutputFuncs(t *testing.T) {
+	outputFuncs := NewOutputFuncs()
+	outputFuncs.Add("the_*")
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"printf", true},
+		{"\\VPrintf", true},
+		{"the_title", true},
+		{"sprintf", false},
+		{"them", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := outputFuncs.has(tt.name); got != tt.want {
+				t.Errorf("has() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if NewOutputFuncs().has("the_title") {
+		t.Errorf("added function is shared with other output functions")
+	}
+}
+
+func TestBufferCallOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		function string
+		buffered bool
+		wantEcho bool
+	}{
+		{"output function inside buffer", "printf", true, true},
+		{"output function outside buffer", "printf", false, false},
+		{"other function inside buffer", "sprintf", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := newTestBuilder(t)
+			if tt.buffered {
+				builder.startOutputBuffer(nil)
+			}
+			callOp := NewOpExprFunctionCall(NewOperandString(tt.function), []Operand{NewTemporaryOperand(nil)}, nil, []*position.Position{nil}, nil)
+			builder.bufferCallOutput(callOp, tt.function, nil)
+			if !tt.wantEcho {
+				if callOp.OutputBuffer != nil {
+					t.Errorf("output of %s is buffered", tt.function)
+				}
+				return
+			}
+			if callOp.OutputBuffer == nil || !callOp.OutputBuffer.IsBuffered() {
+				t.Fatalf("output of %s is not buffered", tt.function)
+			}
+			content := builder.readVariableName(outputBufferName(1), builder.currentBlock)
+			if content != callOp.OutputBuffer.Result {
+				t.Errorf("buffer content is not the buffered output of %s", tt.function)
+			}
+		})
+	}
+}
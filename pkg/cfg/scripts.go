@@ -5,13 +5,30 @@ type Script struct {
 	Filepath     string
 	FuncsMap     map[string]*Func
 	IncludeFiles []string
+	GlobalVars   map[string]*OperandBoundVariable // Global variable accessed from function scope
+
+	ExitSuperGlobals map[string]Operand   // Value of each superglobal at the end of main
+	BufferedIncludes map[string][]*OpEcho // Echo of the output of script included inside output buffer
+	// Echo of the output of non constant include inside output buffer, keyed by the include pattern
+	BufferedIncludePatterns map[string][]*OpEcho
+	ClassParents            map[string]string   // Parent class of each class declared in the script
+	ClassInterfaces         map[string][]string // Interfaces implemented by each class declared in the script
+	PropertyTypes           map[string]string   // Class of the typed property, keyed by Class::property
 }
 
 func NewScript(main *Func, filepath string) *Script {
 	return &Script{
-		Main:     main,
-		Filepath: filepath,
-		FuncsMap: make(map[string]*Func),
+		Main:       main,
+		Filepath:   filepath,
+		FuncsMap:   make(map[string]*Func),
+		GlobalVars: make(map[string]*OperandBoundVariable),
+
+		ExitSuperGlobals:        make(map[string]Operand),
+		BufferedIncludes:        make(map[string][]*OpEcho),
+		BufferedIncludePatterns: make(map[string][]*OpEcho),
+		ClassParents:            make(map[string]string),
+		ClassInterfaces:         make(map[string][]string),
+		PropertyTypes:           make(map[string]string),
 	}
 }
 func (s *Script) AddFunc(funct *Func) {
@@ -19,3 +36,13 @@ func (s *Script) AddFunc(funct *Func) {
 	s.FuncsMap[name] = funct
 
 }
+
+// Get global variable shared by all function in this script
+func (s *Script) GetGlobalVar(name string) *OperandBoundVariable {
+	if gv, ok := s.GlobalVars[name]; ok {
+		return gv
+	}
+	gv := NewOperandBoundVariable(NewOperandString(name), nil, BOUND_VAR_SCOPE_GLOBAL, false, nil)
+	s.GlobalVars[name] = gv
+	return gv
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
 	"reflect"
+	"sort"
+	"strings"
 )
 
 // Part Utils of Local Value Numbering Read Variable Name in current block
@@ -17,18 +19,28 @@ func (builder *CFGBuilder) readVariable(vr Operand) (Operand, error) {
 	case *OperandVariable:
 		switch varName := v.VariableName.(type) {
 		case *OperandString:
-			return builder.readVariableName(varName.Val, builder.currentBlock), nil
-		case *OperandVariable:
-			_, err := builder.readVariable(varName)
-			if err != nil {
-				return nil, err
+			if builder.FuncContex.IsDynamicVar(varName.Val) {
+				return builder.readDynamicVariable(varName.Val), nil
 			}
-			return vr, nil
-		case *TemporaryOperand:
-			_, err := builder.readVariable(varName)
+			if IsSuperGlobal(varName.Val) {
+				builder.currentFunc.FuncHasTaint = true
+				builder.currentBlock.HasTainted = true
+			}
+			val := builder.readVariableName(varName.Val, builder.currentBlock)
+			if builder.currentFunc == builder.Script.Main {
+				builder.mainReads[varName.Val] = append(builder.mainReads[varName.Val], val)
+			}
+			return val, nil
+		case *OperandVariable, *TemporaryOperand:
+			// Variable variable such as $$name
+			nameOper, err := builder.readVariable(varName)
 			if err != nil {
 				return nil, err
 			}
+			// Name can be resolved to a constant, read the named variable
+			if name, ok := GetOperVal(nameOper).(*OperandString); ok {
+				return builder.readVariable(NewOperandVariable(NewOperandString("$"+name.Val), nil))
+			}
 			return vr, nil
 		default:
 			log.Fatalf("readVariable:Error type '%v'", reflect.TypeOf(varName))
@@ -55,17 +67,46 @@ func (builder *CFGBuilder) readVariableName(name string, block *Block) Operand {
 	if ok {
 		return val
 	}
-	// if name is superglobal,
-	// create symbolic operand
-	switch name {
-	case "$_GET", "$_POST", "$_REQUEST", "$_FILES", "$_COOKIE", "$_SERVERS":
-		return builder.createGlobalSymbolic(name)
+	if block == builder.currentFunc.CFGBlock && IsSuperGlobal(name) {
+		return builder.defineSuperGlobal(name)
 	}
-
 	// Else search recursively from predecessors
 	return builder.readVariableRecursive(name, block)
 }
 
+// Read variable which isn't defined since an extract() or variable variable write,
+// its value come from any of the dynamic scopes since its last definition or is still
+// that definition, because extract() may not have the key and doesn't overwrite with EXTR_SKIP
+func (builder *CFGBuilder) readDynamicVariable(name string) Operand {
+	scopes := builder.FuncContex.GetDynamicScopes(name)
+	prev := builder.readVariableName(name, builder.currentBlock)
+
+	// merge the dynamic writes with the previous definition
+	result := NewTemporaryOperand(NewOperandVariable(NewOperandString(name), nil))
+	phi := NewOpPhi(result, builder.currentBlock, scopes[len(scopes)-1].Position)
+	phi.AddOperandtoPhi(prev)
+	for _, scope := range scopes {
+		val := scope.Oper
+		if scope.IsArray {
+			// extract($arr) define $name as $arr['name']
+			dim := NewOperandString(strings.TrimPrefix(name, "$"))
+			fetch := NewOpExprArrayDimFetch(scope.Oper, dim, scope.Position)
+			builder.currentBlock.AddInstructions(fetch)
+			val = fetch.Result
+		}
+
+		vr := NewTemporaryOperand(NewOperandVariable(NewOperandString(name), nil))
+		assign := NewOpExprAssign(vr, val, nil, nil, scope.Position)
+		builder.currentBlock.AddInstructions(assign)
+		phi.AddOperandtoPhi(vr)
+	}
+	builder.currentBlock.AddPhi(phi)
+	builder.writeVariableName(name, result, builder.currentBlock)
+	builder.FuncContex.SetDefinedVar(name)
+
+	return result
+}
+
 // Search Definition of Variable on all block
 func (builder *CFGBuilder) readVariableRecursive(name string, block *Block) Operand {
 
@@ -118,23 +159,46 @@ func (builder *CFGBuilder) readVariableRecursive(name string, block *Block) Oper
 }
 
 func (builder *CFGBuilder) createGlobalSymbolic(name string) Operand {
-	specialVars := map[string]string{
-		"$_GET":     "globalgets",
-		"$_POST":    "globalposts",
-		"$_REQUEST": "globalrequest",
-		"$_FILES":   "globalfiles",
-		"$_COOKIE":  "globalcookies",
-		"$_SERVERS": "globalservers",
-	}
-	name, ok := specialVars[name]
+	symbolicName, ok := SuperGlobals[name]
 	if ok {
-		builder.currentFunc.FuncHasTaint = true
-		builder.currentBlock.HasTainted = true
-		return NewOperandSymbolic(name, true)
+		return NewOperandSymbolic(symbolicName, true)
 	}
 	return nil
 }
 
+// Superglobal read by the function is defined at its entry as tainted symbolic value,
+// so later write to it create a new definition like any other variable
+func (builder *CFGBuilder) defineSuperGlobal(name string) Operand {
+	fn := builder.currentFunc
+	symbolic := builder.createGlobalSymbolic(name)
+	vr := NewTemporaryOperand(NewOperandVariable(NewOperandString(name), symbolic))
+	assign := NewOpExprAssign(vr, symbolic, nil, nil, nil)
+	fn.CFGBlock.Instructions = append([]Op{assign}, fn.CFGBlock.Instructions...)
+	builder.writeVariableName(name, vr, fn.CFGBlock)
+	fn.EntrySuperGlobals[name] = assign
+	return vr
+}
+
+// Save value of superglobals read or written by main at its end, it will be the value
+// seen by function and script including this script
+func (builder *CFGBuilder) saveExitSuperGlobals(endBlock *Block) {
+	names := make([]string, 0, len(SuperGlobals))
+	for name := range SuperGlobals {
+		_, isRead := builder.currentFunc.EntrySuperGlobals[name]
+		_, isWritten := builder.FuncContex.DefinedVars[name]
+		if isRead || isWritten {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		vr := NewTemporaryOperand(NewOperandVariable(NewOperandString(name), nil))
+		assign := NewOpExprAssign(vr, builder.readVariableName(name, endBlock), nil, nil, nil)
+		endBlock.AddInstructions(assign)
+		builder.Script.ExitSuperGlobals[name] = vr
+	}
+}
+
 // Add a new variable definition to current block scope
 func (builder *CFGBuilder) writeVariable(vr Operand) Operand {
 	// Get original Variable
@@ -148,12 +212,22 @@ func (builder *CFGBuilder) writeVariable(vr Operand) Operand {
 	// Write variable name
 	if vrVar, ok := vr.(*OperandVariable); ok {
 		switch name := vrVar.VariableName.(type) {
-		case *OperandVariable:
-			builder.readVariable(name)
+		case *OperandVariable, *TemporaryOperand:
+			// Variable variable with constant name, write the named variable
+			nameOper, _ := builder.readVariable(name)
+			if nameStr, ok := GetOperVal(nameOper).(*OperandString); ok {
+				return builder.writeVariable(NewOperandVariable(NewOperandString("$"+nameStr.Val), nil))
+			}
 		case *OperandString:
 			nameString := name.Val
 			vr = NewTemporaryOperand(vr)
 			builder.writeVariableName(nameString, vr, builder.currentBlock)
+			builder.FuncContex.SetDefinedVar(nameString)
+			if builder.currentFunc == builder.Script.Main {
+				builder.mainDefs[nameString] = append(builder.mainDefs[nameString], vr)
+			} else if _, ok := builder.FuncContex.GlobalNames[nameString]; ok {
+				builder.globalDefs[nameString] = append(builder.globalDefs[nameString], vr)
+			}
 		}
 	}
 	return vr
@@ -212,6 +286,8 @@ func (cb *CFGBuilder) readAssertion(assert Assertion) Assertion {
 			vrs = append(vrs, cb.readAssertion(assertChild))
 		}
 		return NewCompositeAssertion(vrs, a.Mode, a.IsNegated)
+	case *PrefixAssertion:
+		return a
 	}
 	log.Fatal("Error: Wrong assertion type")
 	return nil
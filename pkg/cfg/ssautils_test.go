@@ -0,0 +1,169 @@
+package cfg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newTestBuilder(t *testing.T) *CFGBuilder {
+	t.Helper()
+	entryBlock := NewBlock(0)
+	mainFunction, err := NewFunc("Main", FUNC_MODIF_FLAG_PUBLIC, NewOpTypeVoid(nil), entryBlock, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder := &CFGBuilder{
+		VariableNames: make(map[string]struct{}),
+		mainDefs:      make(map[string][]Operand),
+		mainReads:     make(map[string][]Operand),
+		globalDefs:    make(map[string][]Operand),
+		ConstsDef:     make(map[string]Operand),
+		FuncContex:    NewFunctionContex(),
+		currentBlock:  entryBlock,
+		currentFunc:   mainFunction,
+		outputFuncs:   NewOutputFuncs(),
+		byRefArgs:     make(ByRefArgs),
+	}
+	builder.FuncContex.IsComplete = true
+	builder.Script = NewScript(mainFunction, "test.php")
+	return builder
+}
+
+func TestReadDynamicVariable(t *testing.T) {
+	tests := []struct {
+		name    string
+		isArray bool
+		// key fetched from the extract() array, empty for variable variable write
+		wantKey string
+	}{
+		{name: "extract", isArray: true, wantKey: "x"},
+		{name: "variable variable", isArray: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := newTestBuilder(t)
+			before := builder.writeVariable(NewOperandVariable(NewOperandString("$x"), nil))
+			scopeOper := NewTemporaryOperand(nil)
+			builder.FuncContex.AddDynamicScope(DynamicScope{Oper: scopeOper, IsArray: tt.isArray})
+
+			read, err := builder.readVariable(NewOperandVariable(NewOperandString("$x"), nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			phi, ok := read.GetWriter().(*OpPhi)
+			if !ok {
+				t.Fatalf("read of $x is written by %T, want *OpPhi", read.GetWriter())
+			}
+			if !phi.HasOperand(before) {
+				t.Errorf("phi doesn't keep the definition before the dynamic scope")
+			}
+			if len(phi.Vars) != 2 {
+				t.Fatalf("phi has %d operands, want 2", len(phi.Vars))
+			}
+
+			var dynamic Operand
+			for _, oper := range phi.GetPhiOperands() {
+				if oper != before {
+					dynamic = oper
+				}
+			}
+			assign, ok := dynamic.GetWriter().(*OpExprAssign)
+			if !ok {
+				t.Fatalf("dynamic value is written by %T, want *OpExprAssign", dynamic.GetWriter())
+			}
+			if tt.wantKey == "" {
+				if assign.Expr != scopeOper {
+					t.Errorf("dynamic value is not the variable variable write")
+				}
+				return
+			}
+			fetch, ok := assign.Expr.GetWriter().(*OpExprArrayDimFetch)
+			if !ok {
+				t.Fatalf("dynamic value is written by %T, want *OpExprArrayDimFetch", assign.Expr.GetWriter())
+			}
+			if key, ok := GetOperVal(fetch.Dim).(*OperandString); !ok || key.Val != tt.wantKey {
+				t.Errorf("extract() key = %v, want %q", fetch.Dim, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestReadDynamicVariableOnce(t *testing.T) {
+	builder := newTestBuilder(t)
+	builder.FuncContex.AddDynamicScope(DynamicScope{Oper: NewTemporaryOperand(nil), IsArray: true})
+
+	first, _ := builder.readVariable(NewOperandVariable(NewOperandString("$x"), nil))
+	second, _ := builder.readVariable(NewOperandVariable(NewOperandString("$x"), nil))
+	if first != second {
+		t.Errorf("second read of $x create a new definition")
+	}
+}
+
+func TestReadDynamicVariableScopes(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		// number of dynamic scopes merged into the read of $name
+		wantScopes int
+	}{
+		{"extract then variable variable", `<?php extract($_POST); $$k = 1; echo $name;`, 2},
+		{"defined between", `<?php extract($_POST); $name = 1; $$k = 1; echo $name;`, 1},
+		{"defined after", `<?php extract($_POST); $$k = 1; $name = 1; echo $name;`, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			echoes := getEchoes(buildTestScript(t, tt.src).Main)
+			if len(echoes) != 1 {
+				t.Fatalf("got %d echo, want 1", len(echoes))
+			}
+			phi, ok := echoes[0].Expr.GetWriter().(*OpPhi)
+			if !ok {
+				if tt.wantScopes != 0 {
+					t.Fatalf("echo is written by %T, want *OpPhi", echoes[0].Expr.GetWriter())
+				}
+				return
+			}
+			// the definition before the dynamic scopes is merged too
+			if got := len(phi.Vars) - 1; got != tt.wantScopes {
+				t.Errorf("read merges %d dynamic scopes, want %d", got, tt.wantScopes)
+			}
+		})
+	}
+}
+
+func TestDefineSuperGlobal(t *testing.T) {
+	script := buildTestScript(t, `<?php
+function show() { echo $_GET['a']; }
+echo $_POST['b'];
+$_COOKIE = [];
+echo $_GET['c'];`)
+
+	mainEntries := make(map[string]bool)
+	for name := range script.Main.EntrySuperGlobals {
+		mainEntries[name] = true
+	}
+	if want := map[string]bool{"$_GET": true, "$_POST": true}; !reflect.DeepEqual(mainEntries, want) {
+		t.Errorf("main defines %v, want %v", mainEntries, want)
+	}
+	fnEntries := make(map[string]bool)
+	for name := range script.FuncsMap["show"].EntrySuperGlobals {
+		fnEntries[name] = true
+	}
+	if want := map[string]bool{"$_GET": true}; !reflect.DeepEqual(fnEntries, want) {
+		t.Errorf("function defines %v, want %v", fnEntries, want)
+	}
+	exits := make(map[string]bool)
+	for name := range script.ExitSuperGlobals {
+		exits[name] = true
+	}
+	if want := map[string]bool{"$_GET": true, "$_POST": true, "$_COOKIE": true}; !reflect.DeepEqual(exits, want) {
+		t.Errorf("main saves %v at exit, want %v", exits, want)
+	}
+	// superglobals are defined before the first statement
+	for i, name := range []string{"$_GET", "$_POST"} {
+		assign, ok := script.Main.CFGBlock.Instructions[i].(*OpExprAssign)
+		if !ok || assign != script.Main.EntrySuperGlobals[name] {
+			t.Errorf("instruction %d of main isn't the definition of %s", i, name)
+		}
+	}
+}
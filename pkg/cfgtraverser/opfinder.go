@@ -0,0 +1,34 @@
+package cfgtraverser
+
+import "github.com/rxhunter00/XSS-Taint/pkg/cfg"
+
+// Get ops of the function matching the filter
+func FindOps(fn *cfg.Func, match func(cfg.Op) bool) []cfg.Op {
+	collector := &opCollector{match: match}
+	traverser := NewTraverser()
+	traverser.AddBlockTraverser(collector)
+	traverser.TraverseFunc(fn)
+	return collector.ops
+}
+
+type opCollector struct {
+	NullTraverser
+
+	match func(cfg.Op) bool
+	ops   []cfg.Op
+}
+
+func (c *opCollector) EnterOp(op cfg.Op, block *cfg.Block) {
+	if c.match(op) {
+		c.ops = append(c.ops, op)
+	}
+}
+
+// Get main and declared functions of the script
+func GetFuncs(script *cfg.Script) []*cfg.Func {
+	fns := []*cfg.Func{script.Main}
+	for _, fn := range script.FuncsMap {
+		fns = append(fns, fn)
+	}
+	return fns
+}
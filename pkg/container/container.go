@@ -0,0 +1,259 @@
+package container
+
+import (
+	"strings"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/cfgtraverser"
+)
+
+// Methods of Laravel container binding the abstract, first argument, to the concrete
+var bindMethods = map[string]struct{}{
+	"bind":        {},
+	"bindif":      {},
+	"singleton":   {},
+	"singletonif": {},
+	"scoped":      {},
+	"scopedif":    {},
+	"instance":    {},
+}
+
+// Services of the dependency injection containers, read from the service providers
+// and the YAML or XML service definitions
+type Container struct {
+	// class of the service, the id of class service is the class name
+	classes map[string]string
+	// service the id is bound to, such as the concrete class of an interface
+	aliases map[string]string
+}
+
+func NewContainer() *Container {
+	return &Container{
+		classes: make(map[string]string),
+		aliases: make(map[string]string),
+	}
+}
+
+// Read $this->app->bind(Abstract::class, Concrete::class) of the service providers, app()->bind()
+// and App::bind() and the other binding calls, the concrete can be a closure returning new Concrete.
+// Abstract bound to different concretes is ambiguous and is not bound
+func (c *Container) ReadProviders(scripts map[string]*cfg.Script) {
+	parents := make(map[string]string)
+	for _, script := range scripts {
+		for class, parent := range script.ClassParents {
+			parents[normalizeID(class)] = normalizeID(parent)
+		}
+	}
+
+	bindings := make(map[string]string)
+	conflicts := make(map[string]struct{})
+	for _, script := range scripts {
+		for _, fn := range cfgtraverser.GetFuncs(script) {
+			for _, call := range cfgtraverser.FindOps(fn, isMethodCall) {
+				var methodName cfg.Operand
+				var args []cfg.Operand
+				switch callOp := call.(type) {
+				case *cfg.OpExprMethodCall:
+					if !isContainer(callOp.Var, fn, parents) {
+						continue
+					}
+					methodName, args = callOp.Name, callOp.Args
+				case *cfg.OpExprStaticCall:
+					if class, err := cfg.GetOperandName(callOp.Class); err != nil || !strings.EqualFold(getShortName(class), "App") {
+						continue
+					}
+					methodName, args = callOp.Name, callOp.Args
+				}
+				name, err := cfg.GetOperandName(methodName)
+				if err != nil || len(args) < 2 {
+					continue
+				}
+				if _, ok := bindMethods[strings.ToLower(name)]; !ok {
+					continue
+				}
+				abstract, ok := getClassName(args[0])
+				if !ok {
+					continue
+				}
+				concrete, ok := getConcreteClass(args[1])
+				if !ok {
+					continue
+				}
+				abstract, concrete = normalizeID(abstract), normalizeID(concrete)
+				if bound, ok := bindings[abstract]; ok && bound != concrete {
+					conflicts[abstract] = struct{}{}
+				}
+				bindings[abstract] = concrete
+			}
+		}
+	}
+	for abstract, concrete := range bindings {
+		if _, ok := conflicts[abstract]; !ok {
+			c.addAlias(abstract, concrete)
+		}
+	}
+}
+
+// Check if the receiver is the Laravel container, $this->app of service provider or app()
+func isContainer(receiver cfg.Operand, fn *cfg.Func, parents map[string]string) bool {
+	if call, ok := receiver.GetWriter().(*cfg.OpExprFunctionCall); ok {
+		name, err := cfg.GetOperandName(call.Name)
+		return err == nil && len(call.Args) == 0 && strings.EqualFold(getShortName(name), "app")
+	}
+	if name, err := cfg.GetOperandName(receiver); err != nil || name != "<propfetch>this->app" {
+		return false
+	}
+	if fn.FunctionClass == nil {
+		return false
+	}
+	class := normalizeID(fn.FunctionClass.Val)
+	for i := 0; class != "" && i < 32; i++ {
+		if strings.EqualFold(getShortName(class), "ServiceProvider") {
+			return true
+		}
+		class = parents[class]
+	}
+	return false
+}
+
+func getShortName(name string) string {
+	if i := strings.LastIndex(name, "\\"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// Get the concrete class bound to each interface or class, following the aliases. Interface
+// implemented by a single scanned class is autowired to it if it is not bound
+func (c *Container) GetBindings(scripts map[string]*cfg.Script) map[string]string {
+	bindings := make(map[string]string)
+	for id := range c.aliases {
+		c.addBinding(bindings, id)
+	}
+	for id := range c.classes {
+		c.addBinding(bindings, id)
+	}
+
+	implementations := make(map[string][]string)
+	for _, script := range scripts {
+		for class, interfaces := range script.ClassInterfaces {
+			for _, iface := range interfaces {
+				iface = normalizeID(iface)
+				implementations[iface] = append(implementations[iface], class)
+			}
+		}
+	}
+	for iface, classes := range implementations {
+		if _, ok := bindings[iface]; !ok && len(classes) == 1 {
+			bindings[iface] = normalizeID(classes[0])
+		}
+	}
+	return bindings
+}
+
+func (c *Container) addBinding(bindings map[string]string, id string) {
+	if !isClassName(id) {
+		return
+	}
+	if concrete := c.resolve(id); concrete != id && isClassName(concrete) {
+		bindings[id] = concrete
+	}
+}
+
+// Get class of the service, the id itself if it is not known
+func (c *Container) resolve(id string) string {
+	for i := 0; i < 32; i++ {
+		target, ok := c.aliases[id]
+		if !ok {
+			break
+		}
+		id = target
+	}
+	if class, ok := c.classes[id]; ok {
+		return class
+	}
+	return id
+}
+
+func (c *Container) addAlias(id string, target string) {
+	if id, target = normalizeID(id), normalizeID(target); id != "" && target != "" && id != target {
+		c.aliases[id] = target
+	}
+}
+
+func (c *Container) addClass(id string, class string) {
+	if id, class = normalizeID(id), normalizeID(class); id != "" && class != "" {
+		c.classes[id] = class
+	}
+}
+
+func normalizeID(id string) string {
+	return strings.TrimPrefix(strings.TrimSpace(id), "\\")
+}
+
+// Check if the service id is a class name such as App\Renderer\RendererInterface
+func isClassName(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, part := range strings.Split(id, "\\") {
+		if part == "" {
+			return false
+		}
+		for i, ch := range part {
+			if ch != '_' && !(ch >= 'a' && ch <= 'z') && !(ch >= 'A' && ch <= 'Z') && ch < 0x80 && !(i > 0 && ch >= '0' && ch <= '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Get class name of Class::class or constant string
+func getClassName(oper cfg.Operand) (string, bool) {
+	if name, ok := cfg.GetConstString(oper); ok {
+		return name, true
+	}
+	if fetch, ok := oper.GetWriter().(*cfg.OpExprClassConstFetch); ok {
+		name, err := cfg.GetOperandName(fetch.Class)
+		return name, err == nil
+	}
+	return "", false
+}
+
+// Get class of the concrete given as class name, new Concrete or closure returning new Concrete
+func getConcreteClass(oper cfg.Operand) (string, bool) {
+	if name, ok := getClassName(oper); ok {
+		return name, true
+	}
+	switch writer := oper.GetWriter().(type) {
+	case *cfg.OpExprNew:
+		name, err := cfg.GetOperandName(writer.Class)
+		return name, err == nil
+	case *cfg.OpExprClosure:
+		for _, op := range cfgtraverser.FindOps(writer.Func, isReturn) {
+			ret := op.(*cfg.OpReturn)
+			if ret.Expr == nil {
+				continue
+			}
+			if newOp, ok := ret.Expr.GetWriter().(*cfg.OpExprNew); ok {
+				name, err := cfg.GetOperandName(newOp.Class)
+				return name, err == nil
+			}
+		}
+	}
+	return "", false
+}
+
+func isMethodCall(op cfg.Op) bool {
+	switch op.(type) {
+	case *cfg.OpExprMethodCall, *cfg.OpExprStaticCall:
+		return true
+	}
+	return false
+}
+
+func isReturn(op cfg.Op) bool {
+	_, ok := op.(*cfg.OpReturn)
+	return ok
+}
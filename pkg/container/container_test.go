@@ -0,0 +1,239 @@
+package container
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+)
+
+func newFunc(t *testing.T, name string) *cfg.Func {
+	t.Helper()
+	fn, err := cfg.NewFunc(name, cfg.FUNC_MODIF_FLAG_PUBLIC, cfg.NewOpTypeVoid(nil), cfg.NewBlock(0), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fn
+}
+
+func classConst(class string) cfg.Operand {
+	return cfg.NewOpExprClassConstFetch(cfg.NewOperandString(class), cfg.NewOperandString("class"), nil).Result
+}
+
+func TestIsDefinitionFile(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/app/config/services.yaml", true},
+		{"/app/config/services_dev.yml", true},
+		{"/app/config/services.xml", true},
+		{"/app/modules/hello/hello.services.yml", true},
+		{"/app/config/Services.YAML", true},
+		{"/app/config/routes.yaml", false},
+		{"/app/config/services.php", false},
+		{"/app/modules/hello/hello.routing.yml", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := IsDefinitionFile(tt.path); got != tt.want {
+				t.Errorf("IsDefinitionFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadDefinitions(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		src  string
+		want map[string]string
+	}{
+		{
+			name: "yaml alias shortcut",
+			path: "services.yaml",
+			src: "services:\n" +
+				"    App\\RendererInterface: '@App\\TwigRenderer'\n",
+			want: map[string]string{"App\\RendererInterface": "App\\TwigRenderer"},
+		},
+		{
+			name: "yaml alias option",
+			path: "services.yaml",
+			src: "services:\n" +
+				"    App\\RendererInterface:\n" +
+				"        alias: App\\TwigRenderer # the default renderer\n",
+			want: map[string]string{"App\\RendererInterface": "App\\TwigRenderer"},
+		},
+		{
+			name: "yaml alias to service id",
+			path: "hello.services.yml",
+			src: "services:\n" +
+				"  hello.renderer:\n" +
+				"    class: Drupal\\hello\\TwigRenderer\n" +
+				"    arguments: ['@twig']\n" +
+				"  Drupal\\hello\\RendererInterface: '@hello.renderer'\n",
+			want: map[string]string{"Drupal\\hello\\RendererInterface": "Drupal\\hello\\TwigRenderer"},
+		},
+		{
+			name: "yaml quoted keys",
+			path: "services.yaml",
+			src: "services:\n" +
+				"    \"App\\\\RendererInterface\":\n" +
+				"        alias: '\\App\\TwigRenderer'\n",
+			want: map[string]string{"App\\RendererInterface": "App\\TwigRenderer"},
+		},
+		{
+			name: "yaml nested class option",
+			path: "services.yaml",
+			src: "services:\n" +
+				"    App\\RendererInterface:\n" +
+				"        factory:\n" +
+				"            class: App\\TwigRenderer\n",
+			want: map[string]string{},
+		},
+		{
+			name: "yaml outside services",
+			path: "services.yaml",
+			src: "parameters:\n" +
+				"    App\\RendererInterface: '@App\\TwigRenderer'\n",
+			want: map[string]string{},
+		},
+		{
+			name: "xml",
+			path: "services.xml",
+			src: "<container><services>\n" +
+				"<service id=\"app.renderer\" class=\"App\\TwigRenderer\"/>\n" +
+				"<service id=\"App\\RendererInterface\" alias=\"app.renderer\"/>\n" +
+				"</services></container>",
+			want: map[string]string{"App\\RendererInterface": "App\\TwigRenderer"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewContainer()
+			if err := c.ReadDefinitions(tt.path, []byte(tt.src)); err != nil {
+				t.Fatal(err)
+			}
+			if got := c.GetBindings(nil); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetBindings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if err := NewContainer().ReadDefinitions("services.xml", []byte("<services><service")); err == nil {
+		t.Errorf("ReadDefinitions() of invalid XML doesn't return error")
+	}
+}
+
+func TestReadProviders(t *testing.T) {
+	app := cfg.NewOperandVariable(cfg.NewOperandString("<propfetch>this->app"), nil)
+	bindOn := func(receiver cfg.Operand, method string, abstract, concrete cfg.Operand) cfg.Op {
+		return cfg.NewOpExprMethodCall(receiver, cfg.NewOperandString(method), []cfg.Operand{abstract, concrete}, nil, nil, nil, nil)
+	}
+	bind := func(method string, abstract, concrete cfg.Operand) cfg.Op {
+		return bindOn(app, method, abstract, concrete)
+	}
+	newClosure := func(class string) cfg.Operand {
+		fn := newFunc(t, "{closure}")
+		fn.CFGBlock.AddInstructions(cfg.NewOpReturn(cfg.NewOpExprNew(cfg.NewOperandString(class), nil, nil).Result, nil))
+		return cfg.NewOpExprClosure(fn, nil, nil).Result
+	}
+	staticBind := func(class string) cfg.Op {
+		return cfg.NewOpExprStaticCall(cfg.NewOperandString(class), cfg.NewOperandString("scoped"), []cfg.Operand{classConst("App\\Payment"), classConst("App\\StripePayment")}, nil, nil, nil, nil)
+	}
+	appCall := cfg.NewOpExprFunctionCall(cfg.NewOperandString("app"), nil, nil, nil, nil).Result
+	tests := []struct {
+		name string
+		// class of the function calling the ops
+		class string
+		ops   []cfg.Op
+		want  map[string]string
+	}{
+		{"bind class names", "App\\Providers\\AppServiceProvider", []cfg.Op{bind("bind", classConst("App\\Payment"), classConst("App\\StripePayment"))}, map[string]string{"App\\Payment": "App\\StripePayment"}},
+		{"singleton string names", "App\\Providers\\AppServiceProvider", []cfg.Op{bind("singleton", cfg.NewOperandString("\\App\\Payment"), cfg.NewOperandString("App\\StripePayment"))}, map[string]string{"App\\Payment": "App\\StripePayment"}},
+		{"instance", "App\\Providers\\AppServiceProvider", []cfg.Op{bind("instance", classConst("App\\Payment"), cfg.NewOpExprNew(cfg.NewOperandString("App\\StripePayment"), nil, nil).Result)}, map[string]string{"App\\Payment": "App\\StripePayment"}},
+		{"closure", "App\\Providers\\AppServiceProvider", []cfg.Op{bind("bindIf", classConst("App\\Payment"), newClosure("App\\StripePayment"))}, map[string]string{"App\\Payment": "App\\StripePayment"}},
+		{"subclass of provider", "App\\Providers\\PaymentServiceProvider", []cfg.Op{bind("bind", classConst("App\\Payment"), classConst("App\\StripePayment"))}, map[string]string{"App\\Payment": "App\\StripePayment"}},
+		{"app of other class", "App\\Http\\Kernel", []cfg.Op{bind("bind", classConst("App\\Payment"), classConst("App\\StripePayment"))}, map[string]string{}},
+		{"app helper", "", []cfg.Op{bindOn(appCall, "bind", classConst("App\\Payment"), classConst("App\\StripePayment"))}, map[string]string{"App\\Payment": "App\\StripePayment"}},
+		{"other receiver", "App\\Providers\\AppServiceProvider", []cfg.Op{bindOn(cfg.NewOperandVariable(cfg.NewOperandString("<propfetch>this->cache"), nil), "bind", classConst("App\\Payment"), classConst("App\\StripePayment"))}, map[string]string{}},
+		{"static bind", "", []cfg.Op{staticBind("App")}, map[string]string{"App\\Payment": "App\\StripePayment"}},
+		{"static bind of other class", "", []cfg.Op{staticBind("Cache")}, map[string]string{}},
+		{"other method", "App\\Providers\\AppServiceProvider", []cfg.Op{bind("make", classConst("App\\Payment"), classConst("App\\StripePayment"))}, map[string]string{}},
+		{"service id", "App\\Providers\\AppServiceProvider", []cfg.Op{bind("bind", cfg.NewOperandString("app.payment"), classConst("App\\StripePayment"))}, map[string]string{}},
+		{"unknown concrete", "App\\Providers\\AppServiceProvider", []cfg.Op{bind("bind", classConst("App\\Payment"), cfg.NewTemporaryOperand(nil))}, map[string]string{}},
+		{"same binding twice", "App\\Providers\\AppServiceProvider", []cfg.Op{
+			bind("bind", classConst("App\\Payment"), classConst("App\\StripePayment")),
+			bind("singleton", classConst("App\\Payment"), classConst("App\\StripePayment")),
+		}, map[string]string{"App\\Payment": "App\\StripePayment"}},
+		{"conflicting bindings", "App\\Providers\\AppServiceProvider", []cfg.Op{
+			bind("bind", classConst("App\\Payment"), classConst("App\\StripePayment")),
+			bind("bind", classConst("App\\Payment"), classConst("App\\PaypalPayment")),
+		}, map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newFunc(t, "register")
+			if tt.class != "" {
+				provider.FunctionClass = cfg.NewOperandString(tt.class)
+			}
+			for _, op := range tt.ops {
+				provider.CFGBlock.AddInstructions(op)
+			}
+			script := cfg.NewScript(newFunc(t, "{main}"), "/app/Providers/AppServiceProvider.php")
+			script.ClassParents["App\\Providers\\AppServiceProvider"] = "Illuminate\\Support\\ServiceProvider"
+			script.ClassParents["App\\Providers\\PaymentServiceProvider"] = "App\\Providers\\AppServiceProvider"
+			script.AddFunc(provider)
+
+			c := NewContainer()
+			c.ReadProviders(map[string]*cfg.Script{script.Filepath: script})
+			if got := c.GetBindings(nil); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetBindings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetBindingsAutowiring(t *testing.T) {
+	script := cfg.NewScript(newFunc(t, "{main}"), "/app/src/Renderer.php")
+	script.ClassInterfaces["App\\TwigRenderer"] = []string{"\\App\\RendererInterface", "App\\Countable"}
+	script.ClassInterfaces["App\\PhpRenderer"] = []string{"App\\RendererInterface"}
+	script.ClassInterfaces["App\\StripePayment"] = []string{"App\\Payment"}
+	script.ClassInterfaces["App\\PaypalPayment"] = []string{"App\\Payment"}
+	scripts := map[string]*cfg.Script{script.Filepath: script}
+
+	c := NewContainer()
+	c.addAlias("App\\RendererInterface", "App\\PhpRenderer")
+	want := map[string]string{
+		// bound, not autowired to the other implementation
+		"App\\RendererInterface": "App\\PhpRenderer",
+		// single implementation
+		"App\\Countable": "App\\TwigRenderer",
+	}
+	if got := c.GetBindings(scripts); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetBindings() = %v, want %v", got, want)
+	}
+}
+
+func TestIsClassName(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"App\\Renderer\\RendererInterface", true},
+		{"Twig_Environment", true},
+		{"App\\V2\\Renderer", true},
+		{"app.renderer", false},
+		{"App\\2Renderer", false},
+		{"App\\\\Renderer", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			if got := isClassName(tt.id); got != tt.want {
+				t.Errorf("isClassName(%q) = %v, want %v", tt.id, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package container
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Check if the file is a YAML or XML service definition, such as config/services.yaml
+// or mymodule.services.yml of Drupal
+func IsDefinitionFile(filePath string) bool {
+	name := strings.ToLower(filepath.Base(filePath))
+	switch filepath.Ext(name) {
+	case ".yaml", ".yml", ".xml":
+		return strings.HasPrefix(name, "services") || strings.Contains(name, ".services.")
+	}
+	return false
+}
+
+// Read the services of YAML or XML definition file
+func (c *Container) ReadDefinitions(filePath string, src []byte) error {
+	if strings.EqualFold(filepath.Ext(filePath), ".xml") {
+		return c.readXML(src)
+	}
+	c.readYAML(src)
+	return nil
+}
+
+// Read <service id="..." class="..." alias="..."/> elements
+func (c *Container) readXML(src []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(src))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		element, ok := token.(xml.StartElement)
+		if !ok || element.Name.Local != "service" {
+			continue
+		}
+		var id, class, alias string
+		for _, attr := range element.Attr {
+			switch attr.Name.Local {
+			case "id":
+				id = attr.Value
+			case "class":
+				class = attr.Value
+			case "alias":
+				alias = attr.Value
+			}
+		}
+		if alias != "" {
+			c.addAlias(id, alias)
+		} else if class != "" {
+			c.addClass(id, class)
+		}
+	}
+}
+
+// Read the entries of the services section, only the forms giving the class or the alias are read:
+//
+//	App\RendererInterface: '@App\TwigRenderer'
+//	App\RendererInterface:
+//	    alias: App\TwigRenderer
+//	app.renderer:
+//	    class: App\TwigRenderer
+func (c *Container) readYAML(src []byte) {
+	inServices := false
+	entryIndent, childIndent := -1, -1
+	id := ""
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		content := strings.TrimLeft(line, " ")
+		if content == "" || strings.HasPrefix(content, "#") {
+			continue
+		}
+		indent := len(line) - len(content)
+		if indent == 0 {
+			inServices = strings.HasPrefix(content, "services:")
+			entryIndent = -1
+			continue
+		}
+		if !inServices {
+			continue
+		}
+		if entryIndent < 0 {
+			entryIndent = indent
+		}
+		key, value, found := splitYAMLEntry(content)
+		if !found {
+			continue
+		}
+		if indent == entryIndent {
+			id = key
+			childIndent = -1
+			if strings.HasPrefix(value, "@") {
+				c.addAlias(id, strings.TrimLeft(value, "@?"))
+			}
+			continue
+		}
+		if childIndent < 0 {
+			childIndent = indent
+		}
+		// only the options of the service, not the nested arguments
+		if id == "" || value == "" || indent != childIndent {
+			continue
+		}
+		switch key {
+		case "alias":
+			c.addAlias(id, value)
+		case "class":
+			c.addClass(id, value)
+		}
+	}
+}
+
+// Split key: value of YAML mapping, the quotes and the comment are removed
+func splitYAMLEntry(content string) (string, string, bool) {
+	// key can be quoted class name containing backslashes but no colon followed by space
+	i := strings.Index(content, ": ")
+	if i < 0 {
+		if !strings.HasSuffix(content, ":") {
+			return "", "", false
+		}
+		i = len(content) - 1
+	}
+	key := unquoteYAML(content[:i])
+	value := strings.TrimSpace(content[i+1:])
+	if j := strings.Index(value, " #"); j >= 0 {
+		value = strings.TrimSpace(value[:j])
+	}
+	return key, unquoteYAML(value), true
+}
+
+func unquoteYAML(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 || (value[0] != '\'' && value[0] != '"') || value[len(value)-1] != value[0] {
+		return value
+	}
+	if value[0] == '"' {
+		// backslash is escaped in double quoted string
+		return strings.ReplaceAll(value[1:len(value)-1], "\\\\", "\\")
+	}
+	return value[1 : len(value)-1]
+}
@@ -0,0 +1,215 @@
+package linker
+
+import (
+	"strings"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/cfgtraverser"
+)
+
+// Position of the first dispatched argument of the WordPress hook functions
+var hookDispatchers = map[string]int{
+	"do_action":               1,
+	"apply_filters":           1,
+	"do_action_ref_array":     1,
+	"apply_filters_ref_array": 1,
+}
+
+// LinkHooks make the arguments of do_action and apply_filters flow into the parameters of
+// the callbacks registered with add_action and add_filter for the same constant hook name,
+// and the callback returns flow out of apply_filters. Parameters of shortcode callbacks
+// registered with add_shortcode are sources since their attributes come from post content.
+// Must be called after simplifier and before source finder
+func LinkHooks(scripts map[string]*cfg.Script) {
+	resolver := newCallbackResolver(scripts)
+	callbacks := make(map[string][]*cfg.Func)
+	dispatches := make([]*cfg.OpExprFunctionCall, 0)
+	for _, script := range scripts {
+		for _, fn := range cfgtraverser.GetFuncs(script) {
+			for _, call := range fn.Calls {
+				callOp, ok := call.(*cfg.OpExprFunctionCall)
+				if !ok || len(callOp.Args) < 2 {
+					continue
+				}
+				funcName, err := cfg.GetOperandName(callOp.Name)
+				if err != nil {
+					continue
+				}
+				hook, ok := cfg.GetConstString(callOp.Args[0])
+				if !ok {
+					continue
+				}
+				switch funcName = strings.ToLower(strings.TrimPrefix(funcName, "\\")); funcName {
+				case "add_action", "add_filter":
+					if callback, ok := resolver.resolve(callOp.Args[1], fn); ok {
+						callbacks[hook] = append(callbacks[hook], callback)
+					}
+				case "add_shortcode":
+					if callback, ok := resolver.resolve(callOp.Args[1], fn); ok {
+						for i := 0; i < len(callback.Params) && i < 2; i++ {
+							callback.Sources = append(callback.Sources, callback.Params[i])
+						}
+					}
+				default:
+					if _, ok := hookDispatchers[funcName]; ok {
+						dispatches = append(dispatches, callOp)
+					}
+				}
+			}
+		}
+	}
+
+	for _, callOp := range dispatches {
+		hook, _ := cfg.GetConstString(callOp.Args[0])
+		funcName, _ := cfg.GetOperandName(callOp.Name)
+		funcName = strings.ToLower(strings.TrimPrefix(funcName, "\\"))
+		args := getDispatchedArgs(callOp, funcName)
+		for _, callback := range callbacks[hook] {
+			for i, arg := range args {
+				if i < len(callback.Params) {
+					arg.AddUser(callback.Params[i])
+				}
+			}
+			if strings.HasPrefix(funcName, "apply_filters") {
+				for _, ret := range findReturns(callback) {
+					ret.Expr.AddUser(callOp)
+				}
+			}
+		}
+	}
+}
+
+// Get arguments passed to the callbacks, _ref_array functions pass the elements of array literal
+func getDispatchedArgs(callOp *cfg.OpExprFunctionCall, funcName string) []cfg.Operand {
+	args := callOp.Args[hookDispatchers[funcName]:]
+	if !strings.HasSuffix(funcName, "_ref_array") {
+		return args
+	}
+	if arrayOp, ok := args[0].GetWriter().(*cfg.OpExprArray); ok {
+		return arrayOp.Vals
+	}
+	return nil
+}
+
+// Find callbacks given as function name, Class::method, [$this, 'method'],
+// [new Class, 'method'], [Class::class, 'method'] or closure
+type callbackResolver struct {
+	funcs   map[string]*cfg.Func
+	methods map[string][]*cfg.Func
+	// parent of each class, lowercased without namespace
+	parents map[string]string
+}
+
+func newCallbackResolver(scripts map[string]*cfg.Script) *callbackResolver {
+	r := &callbackResolver{
+		funcs:   make(map[string]*cfg.Func),
+		methods: make(map[string][]*cfg.Func),
+		parents: make(map[string]string),
+	}
+	for _, script := range scripts {
+		for class, parent := range script.ClassParents {
+			r.parents[getClassKey(class)] = getClassKey(parent)
+		}
+		for _, fn := range script.FuncsMap {
+			if fn.FunctionClass != nil {
+				methodName := strings.ToLower(fn.Name)
+				r.methods[methodName] = append(r.methods[methodName], fn)
+			} else if !fn.IsClosure() {
+				r.funcs[getClassKey(fn.Name)] = fn
+			}
+		}
+	}
+	return r
+}
+
+func getClassKey(name string) string {
+	name = strings.ToLower(name)
+	if i := strings.LastIndex(name, "\\"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+func (r *callbackResolver) resolve(callback cfg.Operand, fn *cfg.Func) (*cfg.Func, bool) {
+	if name, ok := cfg.GetConstString(callback); ok {
+		if className, methodName, isMethod := strings.Cut(name, "::"); isMethod {
+			return r.resolveMethod(className, methodName)
+		}
+		callee, ok := r.funcs[getClassKey(name)]
+		return callee, ok
+	}
+	for i := 0; callback != nil && i < 32; i++ {
+		switch writer := callback.GetWriter().(type) {
+		case *cfg.OpExprClosure:
+			return writer.Func, true
+		case *cfg.OpExprArray:
+			if len(writer.Vals) != 2 {
+				return nil, false
+			}
+			methodName, ok := cfg.GetConstString(writer.Vals[1])
+			if !ok {
+				return nil, false
+			}
+			return r.resolveMethod(getCallbackClass(writer.Vals[0], fn), methodName)
+		case *cfg.OpExprAssign:
+			callback = writer.Expr
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// Get class of the callback object or class name, empty if unknown
+func getCallbackClass(oper cfg.Operand, fn *cfg.Func) string {
+	if className, ok := cfg.GetConstString(oper); ok {
+		return className
+	}
+	if name, err := cfg.GetOperandName(oper); err == nil && strings.TrimPrefix(name, "$") == "this" && fn.FunctionClass != nil {
+		return fn.FunctionClass.Val
+	}
+	switch writer := oper.GetWriter().(type) {
+	case *cfg.OpExprNew:
+		className, _ := cfg.GetOperandName(writer.Class)
+		return className
+	case *cfg.OpExprClassConstFetch:
+		// Class::class
+		className, _ := cfg.GetOperandName(writer.Class)
+		return className
+	}
+	return ""
+}
+
+// Find method declared by the class or its parents, method of unknown class is resolved
+// only if a single class declares it
+func (r *callbackResolver) resolveMethod(className string, methodName string) (*cfg.Func, bool) {
+	methods := r.methods[strings.ToLower(methodName)]
+	if className == "" {
+		if len(methods) == 1 {
+			return methods[0], true
+		}
+		return nil, false
+	}
+	class := getClassKey(className)
+	for i := 0; class != "" && i < 32; i++ {
+		for _, method := range methods {
+			if getClassKey(method.FunctionClass.Val) == class {
+				return method, true
+			}
+		}
+		class = r.parents[class]
+	}
+	return nil, false
+}
+
+// Get returns with value of the function
+func findReturns(fn *cfg.Func) []*cfg.OpReturn {
+	returns := make([]*cfg.OpReturn, 0)
+	for _, op := range cfgtraverser.FindOps(fn, func(op cfg.Op) bool {
+		ret, ok := op.(*cfg.OpReturn)
+		return ok && ret.Expr != nil
+	}) {
+		returns = append(returns, op.(*cfg.OpReturn))
+	}
+	return returns
+}
@@ -0,0 +1,134 @@
+package linker
+
+import (
+	"testing"
+
+	"github.com/VKCOM/php-parser/pkg/position"
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+)
+
+func newCall(name string, args ...cfg.Operand) *cfg.OpExprFunctionCall {
+	return cfg.NewOpExprFunctionCall(cfg.NewOperandString(name), args, nil, make([]*position.Position, len(args)), nil)
+}
+
+func newTestMethod(t *testing.T, class string, name string) *cfg.Func {
+	t.Helper()
+	fn, err := cfg.NewClassFunc(name, cfg.FUNC_MODIF_FLAG_PUBLIC, cfg.NewOpTypeVoid(nil), cfg.NewBlock(0), *cfg.NewOperandString(class), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fn
+}
+
+// Add parameter $value to the callback which returns it
+func addReturnedParam(fn *cfg.Func) *cfg.OpExprParam {
+	param := cfg.NewOpExprParam(cfg.NewOperandString("$value"), false, false, nil, nil, nil, nil, nil)
+	fn.Params = append(fn.Params, param)
+	fn.CFGBlock.AddInstructions(param)
+	fn.CFGBlock.AddInstructions(cfg.NewOpReturn(param.Result, nil))
+	return param
+}
+
+func TestLinkHooks(t *testing.T) {
+	className := func(name string) cfg.Operand {
+		return cfg.NewOpExprClassConstFetch(cfg.NewOperandString(name), cfg.NewOperandString("class"), nil).Result
+	}
+	callbackArray := func(object cfg.Operand) cfg.Operand {
+		return cfg.NewOpExprArray([]cfg.Operand{nil, nil}, []cfg.Operand{object, cfg.NewOperandString("render")}, make([]bool, 2), nil).Result
+	}
+	tests := []struct {
+		name       string
+		register   string
+		hook       string
+		dispatcher string
+		// callback is the method Widget::render, registered inside Widget::init
+		isMethod bool
+		callback func(cb *cfg.Func) cfg.Operand
+		want     bool
+	}{
+		{"function name", "add_filter", "the_title", "apply_filters", false, func(cb *cfg.Func) cfg.Operand {
+			return cfg.NewOperandString("render")
+		}, true},
+		{"action", "add_action", "the_title", "do_action", false, func(cb *cfg.Func) cfg.Operand {
+			return cfg.NewOperandString("\\Render")
+		}, true},
+		{"other hook", "add_filter", "the_content", "apply_filters", false, func(cb *cfg.Func) cfg.Operand {
+			return cfg.NewOperandString("render")
+		}, false},
+		{"closure", "add_filter", "the_title", "apply_filters", false, func(cb *cfg.Func) cfg.Operand {
+			cb.AddModifier(cfg.FUNC_MODIF_FLAG_CLOSURE)
+			return cfg.NewOpExprClosure(cb, nil, nil).Result
+		}, true},
+		{"static method name", "add_filter", "the_title", "apply_filters", true, func(cb *cfg.Func) cfg.Operand {
+			return cfg.NewOperandString("Widget::render")
+		}, true},
+		{"this method", "add_filter", "the_title", "apply_filters", true, func(cb *cfg.Func) cfg.Operand {
+			return callbackArray(cfg.NewOperandVariable(cfg.NewOperandString("$this"), nil))
+		}, true},
+		{"new object method", "add_filter", "the_title", "apply_filters", true, func(cb *cfg.Func) cfg.Operand {
+			return callbackArray(cfg.NewOpExprNew(cfg.NewOperandString("App\\Widget"), nil, nil).Result)
+		}, true},
+		{"class name method", "add_filter", "the_title", "apply_filters", true, func(cb *cfg.Func) cfg.Operand {
+			return callbackArray(className("Widget"))
+		}, true},
+		{"other class method", "add_filter", "the_title", "apply_filters", true, func(cb *cfg.Func) cfg.Operand {
+			return callbackArray(className("Menu"))
+		}, false},
+		{"ref array", "add_filter", "the_title", "apply_filters_ref_array", false, func(cb *cfg.Func) cfg.Operand {
+			return cfg.NewOperandString("render")
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			main := newTestFunc(t, "Main")
+			script := cfg.NewScript(main, "/app/plugin.php")
+			owner := main
+			var callback *cfg.Func
+			if tt.isMethod {
+				callback = newTestMethod(t, "App\\Widget", "render")
+				owner = newTestMethod(t, "App\\Widget", "init")
+				script.AddFunc(owner)
+			} else {
+				callback = newTestFunc(t, "render")
+			}
+			param := addReturnedParam(callback)
+			script.AddFunc(callback)
+
+			value := cfg.NewTemporaryOperand(nil)
+			arg := cfg.Operand(value)
+			if tt.dispatcher == "apply_filters_ref_array" {
+				arg = cfg.NewOpExprArray([]cfg.Operand{nil}, []cfg.Operand{value}, make([]bool, 1), nil).Result
+			}
+			dispatch := newCall(tt.dispatcher, cfg.NewOperandString("the_title"), arg)
+			owner.Calls = append(owner.Calls, newCall(tt.register, cfg.NewOperandString(tt.hook), tt.callback(callback)))
+			main.Calls = append(main.Calls, dispatch)
+
+			LinkHooks(map[string]*cfg.Script{"/app/plugin.php": script})
+
+			if got := hasUser(value, param); got != tt.want {
+				t.Errorf("dispatched value flows into the callback = %v, want %v", got, tt.want)
+			}
+			wantReturn := tt.want && tt.dispatcher != "do_action"
+			if got := hasUser(param.Result, dispatch); got != wantReturn {
+				t.Errorf("callback return flows out of %s = %v, want %v", tt.dispatcher, got, wantReturn)
+			}
+		})
+	}
+}
+
+func TestLinkHooksShortcode(t *testing.T) {
+	main := newTestFunc(t, "Main")
+	callback := newTestFunc(t, "gallery_shortcode")
+	for _, name := range []string{"$atts", "$content", "$tag"} {
+		callback.Params = append(callback.Params, cfg.NewOpExprParam(cfg.NewOperandString(name), false, false, nil, nil, nil, nil, nil))
+	}
+	main.Calls = append(main.Calls, newCall("add_shortcode", cfg.NewOperandString("gallery"), cfg.NewOperandString("gallery_shortcode")))
+	script := cfg.NewScript(main, "/app/plugin.php")
+	script.AddFunc(callback)
+
+	LinkHooks(map[string]*cfg.Script{"/app/plugin.php": script})
+
+	if len(callback.Sources) != 2 || callback.Sources[0] != callback.Params[0] || callback.Sources[1] != callback.Params[1] {
+		t.Errorf("Sources = %v, want the attributes and content parameters", callback.Sources)
+	}
+}
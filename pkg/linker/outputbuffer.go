@@ -0,0 +1,195 @@
+package linker
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/cfgtraverser"
+)
+
+// LinkOutputBuffers make the output of script included inside output buffer,
+// such as ob_start(); include $view; $html = ob_get_clean(); flow into the buffer.
+// Echo of script only included inside output buffer is no longer a direct output.
+// Non constant include, such as include $view, may include every script matching its
+// pattern, their output also flow into the buffer but stay direct output. Must be called after simplifier and before path generator
+func LinkOutputBuffers(scripts map[string]*cfg.Script) {
+	// include sites outside output buffer
+	directIncluders := make(map[*cfg.Script][]*cfg.Script)
+	// echo of the output of script at buffered include sites
+	bufferEchos := make(map[*cfg.Script][]*cfg.OpEcho)
+	for filePath, script := range scripts {
+		for _, includePath := range script.IncludeFiles {
+			included := ResolveInclude(scripts, filePath, includePath)
+			if included == nil || included == script {
+				continue
+			}
+			if _, ok := script.BufferedIncludes[includePath]; !ok {
+				directIncluders[included] = append(directIncluders[included], script)
+			}
+		}
+		for includePath, echos := range script.BufferedIncludes {
+			included := ResolveInclude(scripts, filePath, includePath)
+			if included != nil && included != script {
+				bufferEchos[included] = append(bufferEchos[included], echos...)
+			}
+		}
+	}
+
+	// output of script included by buffered script go to the same buffer
+	targets := make(map[*cfg.Script][]*cfg.OpEcho)
+	for script, echos := range bufferEchos {
+		addBufferTargets(targets, directIncluders, script, echos)
+	}
+
+	// script which every include site end up in a buffer
+	buffered := make(map[*cfg.Script]struct{})
+	for script := range targets {
+		buffered[script] = struct{}{}
+	}
+	for changed := true; changed; {
+		changed = false
+		for script := range buffered {
+			for _, includer := range directIncluders[script] {
+				if _, ok := buffered[includer]; !ok {
+					delete(buffered, script)
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	for script, echos := range targets {
+		_, isBuffered := buffered[script]
+		for _, output := range findMainOutputs(script) {
+			redirectOutput(output, echos, isBuffered)
+		}
+	}
+
+	candidateTargets := make(map[*cfg.Script][]*cfg.OpEcho)
+	for _, script := range scripts {
+		for pattern, echos := range script.BufferedIncludePatterns {
+			for _, included := range resolveIncludePattern(scripts, pattern) {
+				if included != script {
+					addBufferTargets(candidateTargets, directIncluders, included, echos)
+				}
+			}
+		}
+	}
+	for script, echos := range candidateTargets {
+		for _, output := range findMainOutputs(script) {
+			redirectOutput(output, echos, false)
+		}
+	}
+}
+
+// Get scripts which path matches the pattern of non constant include, * matches any part of the path
+func resolveIncludePattern(scripts map[string]*cfg.Script, pattern string) []*cfg.Script {
+	parts := strings.Split(filepath.ToSlash(pattern), "*")
+	// relative path is resolved from the including script or include_path
+	parts[0] = strings.TrimLeft(parts[0], "./")
+	matched := make([]*cfg.Script, 0)
+	for filePath, script := range scripts {
+		if matchIncludePattern(filepath.ToSlash(filePath), parts) {
+			matched = append(matched, script)
+		}
+	}
+	return matched
+}
+
+func matchIncludePattern(filePath string, parts []string) bool {
+	last := parts[len(parts)-1]
+	if !strings.HasSuffix(filePath, last) {
+		return false
+	}
+	rest := filePath[:len(filePath)-len(last)]
+	for _, part := range parts[:len(parts)-1] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	return true
+}
+
+func addBufferTargets(targets map[*cfg.Script][]*cfg.OpEcho, directIncluders map[*cfg.Script][]*cfg.Script, script *cfg.Script, echos []*cfg.OpEcho) {
+	newEchos := make([]*cfg.OpEcho, 0)
+	for _, echo := range echos {
+		if !containsEcho(targets[script], echo) {
+			newEchos = append(newEchos, echo)
+		}
+	}
+	if len(newEchos) == 0 {
+		return
+	}
+	targets[script] = append(targets[script], newEchos...)
+
+	// scripts directly included by this script
+	for included, includers := range directIncluders {
+		for _, includer := range includers {
+			if includer == script {
+				addBufferTargets(targets, directIncluders, included, newEchos)
+				break
+			}
+		}
+	}
+}
+
+func containsEcho(echos []*cfg.OpEcho, target *cfg.OpEcho) bool {
+	for _, echo := range echos {
+		if echo == target {
+			return true
+		}
+	}
+	return false
+}
+
+// Make output flow into the buffer echos, buffered output is no longer a sink
+func redirectOutput(output cfg.Op, echos []*cfg.OpEcho, isBuffered bool) {
+	switch outputT := output.(type) {
+	case *cfg.OpEcho:
+		if isBuffered {
+			outputT.Result = cfg.NewTemporaryOperand(nil)
+			cfg.AddWriteRef(outputT, outputT.Result)
+			for _, echo := range echos {
+				outputT.Result.AddUser(echo)
+			}
+		} else {
+			for _, echo := range echos {
+				outputT.Expr.AddUser(echo)
+			}
+		}
+	case *cfg.OpExprPrint:
+		for _, echo := range echos {
+			outputT.Expr.AddUser(echo)
+		}
+	}
+}
+
+// Get unbuffered echo and print in the main of script
+func findMainOutputs(script *cfg.Script) []cfg.Op {
+	collector := &outputCollector{}
+	traverser := cfgtraverser.NewTraverser()
+	traverser.AddBlockTraverser(collector)
+	traverser.TraverseFunc(script.Main)
+	return collector.outputs
+}
+
+type outputCollector struct {
+	cfgtraverser.NullTraverser
+
+	outputs []cfg.Op
+}
+
+func (c *outputCollector) EnterOp(op cfg.Op, block *cfg.Block) {
+	switch opT := op.(type) {
+	case *cfg.OpEcho:
+		if !opT.IsBuffered() {
+			c.outputs = append(c.outputs, op)
+		}
+	case *cfg.OpExprPrint:
+		c.outputs = append(c.outputs, op)
+	}
+}
@@ -0,0 +1,82 @@
+package linker
+
+import (
+	"testing"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+)
+
+// Script which main echoes a value
+func newOutputScript(t *testing.T, filePath string) (*cfg.Script, *cfg.OpEcho) {
+	t.Helper()
+	main := newTestFunc(t, "Main")
+	echo := cfg.NewOpEcho(cfg.NewTemporaryOperand(nil), nil)
+	main.CFGBlock.AddInstructions(echo)
+	return cfg.NewScript(main, filePath), echo
+}
+
+func hasUser(oper cfg.Operand, op cfg.Op) bool {
+	for _, user := range oper.GetUsers() {
+		if user == op {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLinkOutputBuffersPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		want    map[string]bool
+	}{
+		{
+			name:    "unknown view name",
+			pattern: "views/*.php",
+			want:    map[string]bool{"/app/views/home.php": true, "/app/views/admin/users.php": true, "/app/lib/db.php": false},
+		},
+		{
+			name:    "relative unknown view name",
+			pattern: "../views/*.php",
+			want:    map[string]bool{"/app/views/home.php": true, "/app/views/admin/users.php": true, "/app/lib/db.php": false},
+		},
+		{
+			name:    "unknown path",
+			pattern: "*",
+			want:    map[string]bool{"/app/views/home.php": true, "/app/views/admin/users.php": true, "/app/lib/db.php": true},
+		},
+		{
+			name:    "unknown directory",
+			pattern: "*/users.php",
+			want:    map[string]bool{"/app/views/home.php": false, "/app/views/admin/users.php": true, "/app/lib/db.php": false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scripts := make(map[string]*cfg.Script)
+			echos := make(map[string]*cfg.OpEcho)
+			for filePath := range tt.want {
+				scripts[filePath], echos[filePath] = newOutputScript(t, filePath)
+			}
+			controller, controllerEcho := newOutputScript(t, "/app/controllers/home.php")
+			scripts[controller.Filepath] = controller
+			bufferEcho := cfg.NewOpEchoBuffered(cfg.NewTemporaryOperand(nil), cfg.NewOperandString(""), nil)
+			controller.BufferedIncludePatterns[tt.pattern] = []*cfg.OpEcho{bufferEcho}
+
+			LinkOutputBuffers(scripts)
+
+			for filePath, want := range tt.want {
+				echo := echos[filePath]
+				if got := hasUser(echo.Expr, bufferEcho); got != want {
+					t.Errorf("output of %s flows into the buffer = %v, want %v", filePath, got, want)
+				}
+				if echo.IsBuffered() {
+					t.Errorf("output of %s is no longer a direct output", filePath)
+				}
+			}
+			if hasUser(controllerEcho.Expr, bufferEcho) {
+				t.Errorf("output of the including script flows into its own buffer")
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package linker
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/cfgtraverser"
+)
+
+// Position of the URI of the Laravel route methods, the action is after it
+var routeMethods = map[string]int{
+	"get":     0,
+	"post":    0,
+	"put":     0,
+	"patch":   0,
+	"delete":  0,
+	"options": 0,
+	"any":     0,
+	"match":   1,
+}
+
+// Route parameter such as {id}, optional {id?} or with requirement {id<\d+>}
+var routeParamPattern = regexp.MustCompile(`\{(\w+)[^}]*\}`)
+
+// Path of @Route annotation in doc comment
+var routeAnnotationPattern = regexp.MustCompile(`@Route\(\s*(?:path\s*=\s*)?"([^"]*)"`)
+
+// Attributes of Symfony controller arguments mapped from the request
+var requestAttrs = map[string]struct{}{
+	"mapqueryparameter": {},
+	"mapquerystring":    {},
+	"maprequestpayload": {},
+}
+
+// LinkRoutes make the parameters of route actions named after the route parameters of the
+// URI sources, such as $id of Route::get('/users/{id}', [UserController::class, 'show']) of
+// Laravel and of the method with #[Route('/users/{id}')] of Symfony. Parameters with class type
+// are bound to models and are not sources. Must be called after simplifier and before source finder
+func LinkRoutes(scripts map[string]*cfg.Script) {
+	resolver := newCallbackResolver(scripts)
+	for _, script := range scripts {
+		for _, fn := range script.FuncsMap {
+			linkRouteAttrs(fn)
+		}
+		for _, fn := range cfgtraverser.GetFuncs(script) {
+			for _, op := range cfgtraverser.FindOps(fn, func(op cfg.Op) bool {
+				_, ok := op.(*cfg.OpExprStaticCall)
+				return ok
+			}) {
+				callOp := op.(*cfg.OpExprStaticCall)
+				className, _ := cfg.GetOperandName(callOp.Class)
+				methodName, _ := cfg.GetOperandName(callOp.Name)
+				uriPos, ok := routeMethods[strings.ToLower(methodName)]
+				if getClassKey(className) != "route" || !ok || len(callOp.Args) <= uriPos+1 {
+					continue
+				}
+				uri, ok := cfg.GetConstString(callOp.Args[uriPos])
+				if !ok {
+					continue
+				}
+				action, ok := resolver.resolveAction(callOp.Args[uriPos+1], fn)
+				if !ok {
+					continue
+				}
+				for _, match := range routeParamPattern.FindAllStringSubmatch(uri, -1) {
+					addRouteParamSource(action, match[1])
+				}
+			}
+		}
+	}
+}
+
+// Make the parameters of Symfony controller sources by the route attribute or annotation
+// of the method, and the parameters mapped from the request by attribute
+func linkRouteAttrs(fn *cfg.Func) {
+	if fn.FunctionClass == nil {
+		return
+	}
+	paths := make([]string, 0)
+	for _, attrGroup := range fn.GetAttrGroups() {
+		for _, attr := range attrGroup.Attrs {
+			if name, _ := cfg.GetOperandName(attr.Name); getClassKey(name) != "route" || len(attr.Args) == 0 {
+				continue
+			}
+			if path, ok := cfg.GetConstString(attr.Args[0]); ok {
+				paths = append(paths, path)
+			}
+		}
+	}
+	for _, match := range routeAnnotationPattern.FindAllStringSubmatch(fn.DocComment, -1) {
+		paths = append(paths, match[1])
+	}
+	for _, path := range paths {
+		for _, match := range routeParamPattern.FindAllStringSubmatch(path, -1) {
+			addRouteParamSource(fn, match[1])
+		}
+	}
+	for _, param := range fn.Params {
+		for _, attrGroup := range param.AttrGroups {
+			for _, attr := range attrGroup.Attrs {
+				name, _ := cfg.GetOperandName(attr.Name)
+				if _, ok := requestAttrs[getClassKey(name)]; ok && !hasSource(fn, param) {
+					fn.Sources = append(fn.Sources, param)
+				}
+			}
+		}
+	}
+}
+
+// Resolve the action, also given as Controller@method
+func (r *callbackResolver) resolveAction(action cfg.Operand, fn *cfg.Func) (*cfg.Func, bool) {
+	if name, ok := cfg.GetConstString(action); ok {
+		if className, methodName, found := strings.Cut(name, "@"); found {
+			return r.resolveMethod(className, methodName)
+		}
+	}
+	return r.resolve(action, fn)
+}
+
+func addRouteParamSource(action *cfg.Func, name string) {
+	for _, param := range action.Params {
+		paramName, _ := cfg.GetOperandName(param.Name)
+		if strings.TrimPrefix(paramName, "$") != name || param.ClassType != "" {
+			continue
+		}
+		if !hasSource(action, param) {
+			action.Sources = append(action.Sources, param)
+		}
+		return
+	}
+}
+
+func hasSource(fn *cfg.Func, op cfg.Op) bool {
+	for _, source := range fn.Sources {
+		if source == op {
+			return true
+		}
+	}
+	return false
+}
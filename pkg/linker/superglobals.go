@@ -0,0 +1,146 @@
+package linker
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+)
+
+// LinkSuperGlobals make the superglobals seen at function entry also hold the value left by
+// the main script, and the superglobals seen by a script also hold the value left by the
+// last included bootstrap script that change them.
+// Must be called after simplifier and before source finder
+func LinkSuperGlobals(scripts map[string]*cfg.Script) {
+	includes := make(map[*cfg.Script][]*cfg.Script)
+	for filePath, script := range scripts {
+		for _, includePath := range script.IncludeFiles {
+			included := ResolveInclude(scripts, filePath, includePath)
+			if included != nil && included != script {
+				includes[script] = append(includes[script], included)
+			}
+		}
+	}
+
+	for _, script := range scripts {
+		for name, entry := range script.Main.EntrySuperGlobals {
+			// the last included script win
+			for i := len(includes[script]) - 1; i >= 0; i-- {
+				included := includes[script][i]
+				// Prevent include cycle remove all the sources
+				if isIncluded(includes, included, script, make(map[*cfg.Script]struct{})) {
+					continue
+				}
+				if !isSuperGlobalChanged(includes, included, name, make(map[*cfg.Script]struct{})) {
+					continue
+				}
+				mergeEntryValue(script.Main, name, entry, included.ExitSuperGlobals[name])
+				break
+			}
+		}
+	}
+
+	for _, script := range scripts {
+		for name, exit := range script.ExitSuperGlobals {
+			if !isSuperGlobalChanged(includes, script, name, make(map[*cfg.Script]struct{})) {
+				continue
+			}
+			for _, fn := range script.FuncsMap {
+				if entry, ok := fn.EntrySuperGlobals[name]; ok {
+					mergeEntryValue(fn, name, entry, exit)
+				}
+			}
+		}
+	}
+}
+
+// Find script of an include path, relative path is resolved from the including script
+func ResolveInclude(scripts map[string]*cfg.Script, fromPath string, includePath string) *cfg.Script {
+	candidate := includePath
+	if !filepath.IsAbs(includePath) {
+		candidate = filepath.Join(filepath.Dir(fromPath), includePath)
+	}
+	if script, ok := scripts[filepath.Clean(candidate)]; ok {
+		return script
+	}
+
+	// path may be relative to include_path, match the end of the path
+	suffix := "/" + strings.TrimPrefix(filepath.ToSlash(filepath.Clean(includePath)), "./")
+	for filePath, script := range scripts {
+		if strings.HasSuffix(filepath.ToSlash(filePath), suffix) {
+			return script
+		}
+	}
+	return nil
+}
+
+// Check if target is included by script directly or indirectly
+func isIncluded(includes map[*cfg.Script][]*cfg.Script, script *cfg.Script, target *cfg.Script, visited map[*cfg.Script]struct{}) bool {
+	if _, ok := visited[script]; ok {
+		return false
+	}
+	visited[script] = struct{}{}
+	for _, included := range includes[script] {
+		if included == target || isIncluded(includes, included, target, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check if the value of superglobal at the end of main is not its value at the entry,
+// either written by the script itself or by one of its included script
+func isSuperGlobalChanged(includes map[*cfg.Script][]*cfg.Script, script *cfg.Script, name string, visited map[*cfg.Script]struct{}) bool {
+	if _, ok := visited[script]; ok {
+		return false
+	}
+	visited[script] = struct{}{}
+
+	exit, ok := script.ExitSuperGlobals[name]
+	if !ok {
+		return false
+	}
+	entry, ok := script.Main.EntrySuperGlobals[name]
+	if !ok {
+		// main overwrite the superglobal without reading it
+		return true
+	}
+	// value at the end is the entry value if the exit op is still its user
+	for _, user := range entry.Var.GetUsers() {
+		if user == exit.GetWriter() {
+			for _, included := range includes[script] {
+				if isSuperGlobalChanged(includes, included, name, visited) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return true
+}
+
+// Merge the superglobal defined at function entry with val. The request value is kept
+// because the function can be called, or the script included, before val is written
+func mergeEntryValue(fn *cfg.Func, name string, entry *cfg.OpExprAssign, val cfg.Operand) {
+	block := fn.CFGBlock
+	request := cfg.NewTemporaryOperand(cfg.NewOperandVariable(cfg.NewOperandString(name), nil))
+	entry.Expr.RemoveUser(entry)
+	requestAssign := cfg.NewOpExprAssign(request, entry.Expr, nil, nil, nil)
+
+	result := cfg.NewTemporaryOperand(cfg.NewOperandVariable(cfg.NewOperandString(name), nil))
+	phi := cfg.NewOpPhi(result, block, nil)
+	phi.AddOperandtoPhi(request)
+	phi.AddOperandtoPhi(val)
+	block.AddPhi(phi)
+	entry.Expr = result
+	result.AddUser(entry)
+
+	// the request value is still the source defined at entry
+	for i, op := range block.Instructions {
+		if op == entry {
+			block.Instructions = append(block.Instructions[:i], append([]cfg.Op{requestAssign}, block.Instructions[i:]...)...)
+			return
+		}
+	}
+	block.Instructions = append([]cfg.Op{requestAssign}, block.Instructions...)
+}
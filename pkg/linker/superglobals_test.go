@@ -0,0 +1,88 @@
+package linker
+
+import (
+	"testing"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+)
+
+// Define $_GET at entry of fn as the builder does
+func defineEntry(t *testing.T, fn *cfg.Func) *cfg.OpExprAssign {
+	t.Helper()
+	symbolic := cfg.NewOperandSymbolic(cfg.SuperGlobals["$_GET"], true)
+	vr := cfg.NewTemporaryOperand(cfg.NewOperandVariable(cfg.NewOperandString("$_GET"), symbolic))
+	assign := cfg.NewOpExprAssign(vr, symbolic, nil, nil, nil)
+	fn.CFGBlock.AddInstructions(assign)
+	fn.EntrySuperGlobals["$_GET"] = assign
+	return assign
+}
+
+func newTestFunc(t *testing.T, name string) *cfg.Func {
+	t.Helper()
+	fn, err := cfg.NewFunc(name, cfg.FUNC_MODIF_FLAG_PUBLIC, cfg.NewOpTypeVoid(nil), cfg.NewBlock(0), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fn
+}
+
+func TestLinkSuperGlobals(t *testing.T) {
+	tests := []struct {
+		name       string
+		mainWrites bool
+		wantExit   bool
+	}{
+		{name: "main writes superglobal", mainWrites: true, wantExit: true},
+		{name: "main doesn't write superglobal", mainWrites: false, wantExit: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			main := newTestFunc(t, "Main")
+			mainEntry := defineEntry(t, main)
+			script := cfg.NewScript(main, "/app/index.php")
+
+			// value of $_GET at the end of main
+			val := mainEntry.Var
+			if tt.mainWrites {
+				val = cfg.NewOperandString("page=1")
+			}
+			exit := cfg.NewTemporaryOperand(cfg.NewOperandVariable(cfg.NewOperandString("$_GET"), nil))
+			main.CFGBlock.AddInstructions(cfg.NewOpExprAssign(exit, val, nil, nil, nil))
+			script.ExitSuperGlobals["$_GET"] = exit
+
+			fn := newTestFunc(t, "show")
+			fnEntry := defineEntry(t, fn)
+			script.AddFunc(fn)
+
+			LinkSuperGlobals(map[string]*cfg.Script{"/app/index.php": script})
+
+			if !tt.wantExit {
+				if _, ok := fnEntry.Expr.(*cfg.OperandSymbolic); !ok {
+					t.Errorf("entry of function is %T, want the request source", fnEntry.Expr)
+				}
+				return
+			}
+			phi, ok := fnEntry.Expr.GetWriter().(*cfg.OpPhi)
+			if !ok {
+				t.Fatalf("entry of function is written by %T, want *cfg.OpPhi", fnEntry.Expr.GetWriter())
+			}
+			if !phi.HasOperand(exit) {
+				t.Errorf("phi doesn't have the exit value of main")
+			}
+			hasRequest := false
+			for _, oper := range phi.GetPhiOperands() {
+				if assign, ok := oper.GetWriter().(*cfg.OpExprAssign); ok {
+					if _, ok := assign.Expr.(*cfg.OperandSymbolic); ok {
+						hasRequest = true
+						if fn.CFGBlock.Instructions[0] != assign {
+							t.Errorf("request source is not defined before the entry")
+						}
+					}
+				}
+			}
+			if !hasRequest {
+				t.Errorf("phi doesn't keep the request value")
+			}
+		})
+	}
+}
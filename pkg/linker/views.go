@@ -0,0 +1,290 @@
+package linker
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/cfgtraverser"
+	"github.com/rxhunter00/XSS-Taint/pkg/templating"
+	"github.com/rxhunter00/XSS-Taint/pkg/twig"
+)
+
+const (
+	BLADE_EXTENSION = ".blade.php"
+	TWIG_EXTENSION  = ".twig"
+)
+
+// Variables of the compiled Blade or Twig template, read from the view data
+type template struct {
+	// fetch of the view data key by the variable name
+	vars map[string][]*cfg.OpExprArrayDimFetch
+	// templates included with the context of this template
+	includes []*template
+}
+
+// LinkViews make the data passed to view('name', [...]), View::make, ->with() and
+// ->render('name.html.twig', [...]) flow into the variables of the compiled Blade or Twig
+// template, each key into the variable of the same name. Templates included with the
+// context also receive the data. Must be called after simplifier and before path generator
+func LinkViews(scripts map[string]*cfg.Script) {
+	templates := findTemplates(scripts)
+	if len(templates) == 0 {
+		return
+	}
+	for filePath, script := range scripts {
+		tpl, ok := templates[getViewName(filePath)]
+		if !ok || !isTemplate(filePath) {
+			continue
+		}
+		for _, op := range cfgtraverser.FindOps(script.Main, isCallOp) {
+			// twig_include('name', [...], $__data)
+			if name, args, ok := getViewCall(op); ok && len(args) > 2 && isViewData(args[2]) {
+				if included, ok := getTemplate(templates, name); ok {
+					tpl.includes = append(tpl.includes, included)
+				}
+			}
+		}
+	}
+
+	for _, script := range scripts {
+		for _, fn := range cfgtraverser.GetFuncs(script) {
+			for _, op := range cfgtraverser.FindOps(fn, isCallOp) {
+				if name, args, ok := getViewCall(op); ok {
+					if tpl, ok := getTemplate(templates, name); ok && len(args) > 1 {
+						tpl.linkData(args[1])
+					}
+					continue
+				}
+				methodCall, ok := op.(*cfg.OpExprMethodCall)
+				if !ok || !isMethodNamed(methodCall, "with") || len(methodCall.Args) == 0 {
+					continue
+				}
+				tpl, ok := getTemplate(templates, getWithView(methodCall))
+				if !ok {
+					continue
+				}
+				if len(methodCall.Args) > 1 {
+					// ->with('key', $value)
+					if key, ok := cfg.GetConstString(methodCall.Args[0]); ok {
+						tpl.linkVar(key, methodCall.Args[1])
+					}
+				} else {
+					tpl.linkData(methodCall.Args[0])
+				}
+			}
+		}
+	}
+}
+
+func isCallOp(op cfg.Op) bool {
+	switch op.(type) {
+	case *cfg.OpExprFunctionCall, *cfg.OpExprMethodCall, *cfg.OpExprStaticCall:
+		return true
+	}
+	return false
+}
+
+func isMethodNamed(op *cfg.OpExprMethodCall, name string) bool {
+	methodName, err := cfg.GetOperandName(op.Name)
+	return err == nil && strings.EqualFold(methodName, name)
+}
+
+// Get view name and arguments of view(), View::make(), ->view(), ->render(), ->renderView()
+// or twig_include() call, the data is the second argument
+func getViewCall(op cfg.Op) (string, []cfg.Operand, bool) {
+	var args []cfg.Operand
+	switch opT := op.(type) {
+	case *cfg.OpExprFunctionCall:
+		funcName, err := cfg.GetOperandName(opT.Name)
+		if err != nil {
+			return "", nil, false
+		}
+		if funcName = getClassKey(funcName); funcName != "view" && funcName != twig.INCLUDE_FUNC {
+			return "", nil, false
+		}
+		args = opT.Args
+	case *cfg.OpExprStaticCall:
+		className, _ := cfg.GetOperandName(opT.Class)
+		methodName, _ := cfg.GetOperandName(opT.Name)
+		if getClassKey(className) != "view" || strings.ToLower(methodName) != "make" {
+			return "", nil, false
+		}
+		args = opT.Args
+	case *cfg.OpExprMethodCall:
+		if !isMethodNamed(opT, "view") && !isMethodNamed(opT, "render") && !isMethodNamed(opT, "renderView") {
+			return "", nil, false
+		}
+		args = opT.Args
+	default:
+		return "", nil, false
+	}
+	if len(args) == 0 {
+		return "", nil, false
+	}
+	name, ok := cfg.GetConstString(args[0])
+	if !ok {
+		return "", nil, false
+	}
+	return name, args, true
+}
+
+// Get view name of view('name')->with(...)->with(...) chain, empty if unknown
+func getWithView(op *cfg.OpExprMethodCall) string {
+	oper := op.Var
+	for i := 0; oper != nil && i < 32; i++ {
+		switch writer := oper.GetWriter().(type) {
+		case *cfg.OpExprMethodCall:
+			if !isMethodNamed(writer, "with") {
+				name, _, _ := getViewCall(writer)
+				return name
+			}
+			oper = writer.Var
+		case *cfg.OpExprAssign:
+			oper = writer.Expr
+		default:
+			name, _, _ := getViewCall(writer)
+			return name
+		}
+	}
+	return ""
+}
+
+func isTemplate(filePath string) bool {
+	return strings.HasSuffix(filePath, BLADE_EXTENSION) || strings.HasSuffix(filePath, TWIG_EXTENSION)
+}
+
+// Find compiled templates by view name, such as users.index for resources/views/users/index.blade.php
+// and blog.show.html.twig for templates/blog/show.html.twig
+func findTemplates(scripts map[string]*cfg.Script) map[string]*template {
+	templates := make(map[string]*template)
+	for filePath, script := range scripts {
+		if !isTemplate(filePath) {
+			continue
+		}
+		data, ok := findViewData(script.Main)
+		if !ok {
+			continue
+		}
+		tpl := &template{vars: make(map[string][]*cfg.OpExprArrayDimFetch)}
+		for _, op := range cfgtraverser.FindOps(script.Main, func(op cfg.Op) bool {
+			fetch, ok := op.(*cfg.OpExprArrayDimFetch)
+			return ok && fetch.Var == data
+		}) {
+			fetch := op.(*cfg.OpExprArrayDimFetch)
+			if name, ok := cfg.GetConstString(fetch.Dim); ok {
+				tpl.vars[name] = append(tpl.vars[name], fetch)
+			}
+		}
+		templates[getViewName(filePath)] = tpl
+	}
+	return templates
+}
+
+// Get the view data operand extracted at the start of the template
+func findViewData(main *cfg.Func) (cfg.Operand, bool) {
+	for _, call := range main.Calls {
+		callOp, ok := call.(*cfg.OpExprFunctionCall)
+		if !ok || len(callOp.Args) == 0 {
+			continue
+		}
+		funcName, _ := cfg.GetOperandName(callOp.Name)
+		if strings.ToLower(funcName) == "extract" && isViewData(callOp.Args[0]) {
+			return callOp.Args[0], true
+		}
+	}
+	return nil, false
+}
+
+func isViewData(oper cfg.Operand) bool {
+	name, err := cfg.GetOperandName(oper)
+	return err == nil && strings.TrimPrefix(name, "$") == templating.DATA_VAR
+}
+
+// Get view name from the path after the views or templates directory
+func getViewName(filePath string) string {
+	parts := strings.Split(filepath.ToSlash(strings.TrimSuffix(filePath, BLADE_EXTENSION)), "/")
+	for i := len(parts) - 1; i >= 0; i-- {
+		if parts[i] == "views" || parts[i] == "templates" {
+			return strings.Join(parts[i+1:], ".")
+		}
+	}
+	return parts[len(parts)-1]
+}
+
+func getTemplate(templates map[string]*template, name string) (*template, bool) {
+	if _, viewName, found := strings.Cut(name, "::"); found {
+		// view of package namespace
+		name = viewName
+	} else if strings.HasPrefix(name, "@") {
+		// Twig template of namespace such as @Admin/index.html.twig
+		if _, viewName, found := strings.Cut(name, "/"); found {
+			name = viewName
+		}
+	}
+	tpl, ok := templates[strings.ReplaceAll(name, "/", ".")]
+	return tpl, ok
+}
+
+// Link each key of the data array literal to the variable, the whole data to every
+// variable if it is not a literal
+func (tpl *template) linkData(data cfg.Operand) {
+	if arrayOp, ok := getArrayLiteral(data); ok {
+		for i, key := range arrayOp.Keys {
+			if key == nil || i >= len(arrayOp.Vals) {
+				continue
+			}
+			if name, ok := cfg.GetConstString(key); ok {
+				tpl.linkVar(name, arrayOp.Vals[i])
+			}
+		}
+		return
+	}
+	tpl.walk(func(t *template) {
+		for _, fetches := range t.vars {
+			for _, fetch := range fetches {
+				data.AddUser(fetch)
+			}
+		}
+	})
+}
+
+// Get array literal of the operand, following assignments
+func getArrayLiteral(oper cfg.Operand) (*cfg.OpExprArray, bool) {
+	for i := 0; oper != nil && i < 32; i++ {
+		switch writer := oper.GetWriter().(type) {
+		case *cfg.OpExprArray:
+			return writer, true
+		case *cfg.OpExprAssign:
+			oper = writer.Expr
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+func (tpl *template) linkVar(name string, val cfg.Operand) {
+	tpl.walk(func(t *template) {
+		for _, fetch := range t.vars[name] {
+			val.AddUser(fetch)
+		}
+	})
+}
+
+// Visit the template and the templates included with its context
+func (tpl *template) walk(visit func(*template)) {
+	visited := map[*template]struct{}{tpl: {}}
+	stack := []*template{tpl}
+	for len(stack) > 0 {
+		t := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		visit(t)
+		for _, included := range t.includes {
+			if _, ok := visited[included]; !ok {
+				visited[included] = struct{}{}
+				stack = append(stack, included)
+			}
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package pathgenerator
+
+import (
+	"strings"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/cfgtraverser"
+	"github.com/rxhunter00/XSS-Taint/pkg/taint"
+)
+
+// Symfony Mailer message classes which html() set the html body
+var emailClasses = map[string]struct{}{
+	"email": {}, "templatedemail": {}, "notificationemail": {},
+}
+
+// Get the html email sink of op, false if op doesn't put the tainted value in html email body
+func (pg *PathGenerator) getEmailSink(op cfg.Op, taintedVar cfg.Operand, state taintState) (Sink, bool) {
+	target := ""
+	switch opT := op.(type) {
+	case *cfg.OpExprFunctionCall:
+		funcNameStr, _ := cfg.GetOperandName(opT.Name)
+		// mail($to, $subject, $message, "Content-Type: text/html")
+		if strings.EqualFold(strings.TrimPrefix(funcNameStr, "\\"), "mail") && isArg(opT.Args, 2, taintedVar) &&
+			len(opT.Args) > 3 && isHTMLMailHeaders(opT.Args[3]) {
+			target = "mail() message"
+		}
+	case *cfg.OpExprAssign:
+		// PHPMailer $mail->Body after $mail->isHTML(true)
+		if opT.Expr != taintedVar {
+			break
+		}
+		if name, err := cfg.GetOperandName(opT.Var); err == nil {
+			if _, ok := pg.htmlMailBodies[name]; ok {
+				target = "PHPMailer Body"
+			}
+		}
+	case *cfg.OpExprMethodCall:
+		methodNameStr, err := cfg.GetOperandName(opT.Name)
+		if err != nil || !isArg(opT.Args, 0, taintedVar) {
+			break
+		}
+		switch strings.ToLower(methodNameStr) {
+		case "msghtml":
+			target = "PHPMailer msgHTML()"
+		case "html":
+			if isNewOf(opT.Var, emailClasses) {
+				target = "Email html()"
+			}
+		case "setbody", "addpart":
+			// Swift_Message with text/html content type
+			if len(opT.Args) > 1 {
+				if contentType, ok := cfg.GetConstString(opT.Args[1]); ok && strings.EqualFold(strings.TrimSpace(contentType), "text/html") {
+					target = "Swift_Message " + methodNameStr + "()"
+				}
+			}
+		}
+	case *cfg.OpExprStaticCall:
+		classNameStr, err := cfg.GetOperandName(opT.Class)
+		if err != nil || !isArg(opT.Args, 0, taintedVar) {
+			break
+		}
+		methodNameStr, _ := cfg.GetOperandName(opT.Name)
+		// Mail::html($html, $callback)
+		if strings.EqualFold(getShortName(classNameStr), "mail") && strings.EqualFold(methodNameStr, "html") {
+			target = "Mail::html()"
+		}
+	}
+	if target == "" {
+		return Sink{}, false
+	}
+	return Sink{Rule: taint.RULE_EMAIL_XSS, Context: taint.GetContext(state.prefix), Target: target}, true
+}
+
+// Check if mail() additional headers set text/html content type
+func isHTMLMailHeaders(oper cfg.Operand) bool {
+	contentType, ok := getHeadersContentType(oper)
+	return ok && contentType == "text/html"
+}
+
+// Get media type of Content-Type in headers, as header lines
+// or array of header name and value, false if not set
+func getHeadersContentType(oper cfg.Operand) (string, bool) {
+	if headers, ok := cfg.GetConstString(oper); ok {
+		for _, line := range strings.Split(headers, "\n") {
+			if mediaType, ok := cfg.GetContentTypeHeader(strings.TrimSuffix(line, "\r")); ok {
+				return mediaType, true
+			}
+		}
+		return "", false
+	}
+	arr, ok := cfg.GetArrayLiteral(oper)
+	if !ok {
+		return "", false
+	}
+	for i, val := range arr.Vals {
+		value, ok := cfg.GetConstString(val)
+		if !ok {
+			continue
+		}
+		header := value
+		if i < len(arr.Keys) && arr.Keys[i] != nil {
+			if key, ok := cfg.GetConstString(arr.Keys[i]); ok && strings.EqualFold(key, "content-type") {
+				header = key + ": " + value
+			}
+		}
+		if mediaType, ok := cfg.GetContentTypeHeader(header); ok {
+			return mediaType, true
+		}
+	}
+	return "", false
+}
+
+// Check if oper is an object created by new of the classes, following fluent method calls
+// such as (new Email())->from($from)
+func isNewOf(oper cfg.Operand, classes map[string]struct{}) bool {
+	for i := 0; oper != nil && i < 32; i++ {
+		switch writer := oper.GetWriter().(type) {
+		case *cfg.OpExprNew:
+			classNameStr, err := cfg.GetOperandName(writer.Class)
+			if err != nil {
+				return false
+			}
+			_, ok := classes[strings.ToLower(getShortName(classNameStr))]
+			return ok
+		case *cfg.OpExprAssign:
+			oper = writer.Expr
+		case *cfg.OpExprMethodCall:
+			oper = writer.Var
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// Get property fetch names of PHPMailer Body which isHTML() is turned on,
+// such as <propfetch>mail->Body for $mail->isHTML(true)
+func findHTMLMailBodies(scripts map[string]*cfg.Script) map[string]struct{} {
+	bodies := make(map[string]struct{})
+	for _, script := range scripts {
+		for _, fn := range cfgtraverser.GetFuncs(script) {
+			for _, call := range fn.Calls {
+				callOp, ok := call.(*cfg.OpExprMethodCall)
+				if !ok {
+					continue
+				}
+				methodNameStr, err := cfg.GetOperandName(callOp.Name)
+				if err != nil || !strings.EqualFold(methodNameStr, "ishtml") {
+					continue
+				}
+				// isHTML() default to true
+				if len(callOp.Args) > 0 && !isTrueArg(callOp.Args, 0) {
+					continue
+				}
+				varName, err := cfg.GetOperandName(callOp.Var)
+				if err != nil || varName == "" {
+					continue
+				}
+				bodies["<propfetch>"+varName[1:]+"->Body"] = struct{}{}
+			}
+		}
+	}
+	return bodies
+}
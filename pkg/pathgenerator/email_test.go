@@ -0,0 +1,99 @@
+package pathgenerator
+
+import (
+	"testing"
+
+	"github.com/VKCOM/php-parser/pkg/position"
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/taint"
+)
+
+func newMethodCall(vr cfg.Operand, name string, args ...cfg.Operand) *cfg.OpExprMethodCall {
+	return cfg.NewOpExprMethodCall(vr, cfg.NewOperandString(name), args, nil, nil, make([]*position.Position, len(args)), nil)
+}
+
+func newStaticCall(class, name string, args ...cfg.Operand) *cfg.OpExprStaticCall {
+	return cfg.NewOpExprStaticCall(cfg.NewOperandString(class), cfg.NewOperandString(name), args, nil, nil, make([]*position.Position, len(args)), nil)
+}
+
+func TestGetHeadersContentType(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers cfg.Operand
+		want    string
+	}{
+		{"header lines", cfg.NewOperandString("From: a@example.com\r\nContent-Type: text/html; charset=UTF-8"), "text/html"},
+		{"plain text", cfg.NewOperandString("Content-type: text/plain"), "text/plain"},
+		{"no content type", cfg.NewOperandString("From: a@example.com"), ""},
+		{"header array", cfg.NewOpExprArray(
+			[]cfg.Operand{cfg.NewOperandString("From"), cfg.NewOperandString("Content-Type")},
+			[]cfg.Operand{cfg.NewOperandString("a@example.com"), cfg.NewOperandString("text/html")},
+			make([]bool, 2), nil).Result, "text/html"},
+		{"header line list", cfg.NewOpExprArray(
+			[]cfg.Operand{nil},
+			[]cfg.Operand{cfg.NewOperandString("Content-Type: text/html")},
+			make([]bool, 1), nil).Result, "text/html"},
+		{"unknown headers", cfg.NewTemporaryOperand(nil), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := getHeadersContentType(tt.headers)
+			if ok != (tt.want != "") || got != tt.want {
+				t.Errorf("getHeadersContentType() = %q, %v, want %q", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetEmailSink(t *testing.T) {
+	tainted := cfg.NewTemporaryOperand(nil)
+	htmlHeaders := cfg.NewOperandString("Content-Type: text/html")
+	newEmail := func(class string) cfg.Operand {
+		return cfg.NewOpExprNew(cfg.NewOperandString(class), nil, nil).Result
+	}
+	mailer := cfg.NewOperandVariable(cfg.NewOperandString("$mail"), nil)
+	htmlBody := cfg.NewOperandVariable(cfg.NewOperandString("<propfetch>mail->Body"), nil)
+	textBody := cfg.NewOperandVariable(cfg.NewOperandString("<propfetch>text->Body"), nil)
+	tests := []struct {
+		name       string
+		op         cfg.Op
+		wantTarget string
+	}{
+		{"html mail", newCall("mail", cfg.NewOperandString("a@example.com"), cfg.NewOperandString("Hi"), tainted, htmlHeaders), "mail() message"},
+		{"plain mail", newCall("mail", cfg.NewOperandString("a@example.com"), cfg.NewOperandString("Hi"), tainted), ""},
+		{"html mail subject", newCall("mail", cfg.NewOperandString("a@example.com"), tainted, cfg.NewOperandString("Hi"), htmlHeaders), ""},
+		{"PHPMailer html body", cfg.NewOpExprAssign(htmlBody, tainted, nil, nil, nil), "PHPMailer Body"},
+		{"PHPMailer text body", cfg.NewOpExprAssign(textBody, tainted, nil, nil, nil), ""},
+		{"msgHTML", newMethodCall(mailer, "msgHTML", tainted), "PHPMailer msgHTML()"},
+		{"Email html", newMethodCall(newEmail("Email"), "html", tainted), "Email html()"},
+		{"fluent Email html", newMethodCall(newMethodCall(newEmail("Symfony\\Component\\Mime\\Email"), "from", cfg.NewOperandString("a@example.com")).Result, "html", tainted), "Email html()"},
+		{"other html", newMethodCall(newEmail("Builder"), "html", tainted), ""},
+		{"Swift_Message html body", newMethodCall(mailer, "setBody", tainted, cfg.NewOperandString("text/html")), "Swift_Message setBody()"},
+		{"Swift_Message text body", newMethodCall(mailer, "setBody", tainted, cfg.NewOperandString("text/plain")), ""},
+		{"Mail html", newStaticCall("Illuminate\\Support\\Facades\\Mail", "html", tainted), "Mail::html()"},
+		{"Mail raw", newStaticCall("Mail", "raw", tainted), ""},
+	}
+	// $mail->isHTML(true) and $text->isHTML(false)
+	main, err := cfg.NewFunc("{main}", cfg.FUNC_MODIF_FLAG_PUBLIC, cfg.NewOpTypeVoid(nil), cfg.NewBlock(0), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	main.Calls = append(main.Calls,
+		newMethodCall(mailer, "isHTML", cfg.NewOperandBool(true)),
+		newMethodCall(cfg.NewOperandVariable(cfg.NewOperandString("$text"), nil), "isHTML", cfg.NewOperandBool(false)))
+	script := cfg.NewScript(main, "/app/mail.php")
+
+	pg := NewPathGenerator()
+	pg.htmlMailBodies = findHTMLMailBodies(map[string]*cfg.Script{"/app/mail.php": script})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink, ok := pg.getEmailSink(tt.op, tainted, taintState{prefix: "<p>"})
+			if ok != (tt.wantTarget != "") || sink.Target != tt.wantTarget {
+				t.Fatalf("getEmailSink() = %q, %v, want %q", sink.Target, ok, tt.wantTarget)
+			}
+			if ok && (sink.Rule != taint.RULE_EMAIL_XSS || sink.Context.Kind != taint.CONTEXT_HTML_TEXT) {
+				t.Errorf("sink is %s in %+v, want %s in html text", sink.Rule, sink.Context, taint.RULE_EMAIL_XSS)
+			}
+		})
+	}
+}
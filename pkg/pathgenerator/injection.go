@@ -0,0 +1,72 @@
+package pathgenerator
+
+import (
+	"strings"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/taint"
+)
+
+// Get SQL, code injection or file inclusion sink of op which is not a plain call of the rule files
+func (pg *PathGenerator) getInjectionSink(op cfg.Op, taintedVar cfg.Operand, state taintState) (Sink, bool) {
+	switch opT := op.(type) {
+	case *cfg.OpExprEval:
+		if opT.Expr == taintedVar {
+			return Sink{Rule: taint.RULE_CODE_INJECTION, Prefix: state.prefix, Target: "eval()"}, true
+		}
+	case *cfg.OpExprInclude:
+		if opT.Expr == taintedVar {
+			return Sink{Rule: taint.RULE_FILE_INCLUSION, Prefix: state.prefix, Target: getIncludeName(opT.Type)}, true
+		}
+	case *cfg.OpExprFunctionCall:
+		funcNameStr, _ := cfg.GetOperandName(opT.Name)
+		funcNameStr = strings.ToLower(strings.TrimPrefix(funcNameStr, "\\"))
+		// replacement of preg_replace with /e modifier is evaluated
+		if funcNameStr == "preg_replace" && isArg(opT.Args, 1, taintedVar) && hasEvalModifier(opT.Args[0]) {
+			return Sink{Rule: taint.RULE_CODE_INJECTION, Prefix: state.prefix, Target: "preg_replace() /e replacement"}, true
+		}
+	case *cfg.OpExprMethodCall:
+		methodNameStr, err := cfg.GetOperandName(opT.Name)
+		if err != nil {
+			break
+		}
+		// query builder raw expression such as whereRaw and orderByRaw
+		if len(methodNameStr) > 3 && strings.HasSuffix(methodNameStr, "Raw") && isArg(opT.Args, 0, taintedVar) {
+			return Sink{Rule: taint.RULE_SQL_INJECTION, Prefix: state.prefix, Target: methodNameStr + "()"}, true
+		}
+	}
+	return Sink{}, false
+}
+
+// Check if preg_replace pattern has the deprecated /e modifier
+func hasEvalModifier(patternOper cfg.Operand) bool {
+	pattern, ok := cfg.GetConstString(patternOper)
+	if !ok || len(pattern) < 2 {
+		return false
+	}
+	delimiter := pattern[0]
+	switch delimiter {
+	case '(':
+		delimiter = ')'
+	case '{':
+		delimiter = '}'
+	case '[':
+		delimiter = ']'
+	case '<':
+		delimiter = '>'
+	}
+	end := strings.LastIndexByte(pattern, delimiter)
+	return end > 0 && strings.ContainsRune(pattern[end+1:], 'e')
+}
+
+func getIncludeName(includeType cfg.INCLUDE_TYPE) string {
+	switch includeType {
+	case cfg.TYPE_INCLUDE_ONCE:
+		return "include_once"
+	case cfg.TYPE_REQUIRE:
+		return "require"
+	case cfg.TYPE_REQUIRE_ONCE:
+		return "require_once"
+	}
+	return "include"
+}
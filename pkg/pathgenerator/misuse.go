@@ -0,0 +1,147 @@
+package pathgenerator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/taint"
+)
+
+type MisuseKind string
+
+const (
+	// sanitizer doesn't fit the sink context, such as html escaping in href
+	MISUSE_WRONG_CONTEXT MisuseKind = "wrong-context"
+	// html escaping flags leave the attribute quote unencoded
+	MISUSE_QUOTES_NOT_ENCODED MisuseKind = "quotes-not-encoded"
+	// default flags only encode single quote since PHP 8.1
+	MISUSE_VERSION_DEFAULT_FLAGS MisuseKind = "version-dependent-default-flags"
+	// strip_tags or addslashes used as html escaping
+	MISUSE_NOT_HTML_ESCAPING MisuseKind = "not-html-escaping"
+	// urlencode on a whole URL in URL attribute
+	MISUSE_ENCODED_FULL_URL MisuseKind = "encoded-full-url"
+	// json_encode without the JSON_HEX_* flags the context need
+	MISUSE_JSON_MISSING_HEX_FLAGS MisuseKind = "json-missing-hex-flags"
+)
+
+// Sanitizer used in the path which is unsafe or buggy for the sink context
+type Misuse struct {
+	Kind      MisuseKind
+	Sanitizer cfg.Op
+	Flags     []string
+	// Flags the sanitizer need to be safe in the context
+	MissingFlags []string
+	Message      string
+}
+
+// Find misused sanitizer in the path, nil if there is none
+func (pg *PathGenerator) findMisuse(ops []cfg.Op, labels taint.Labels, context taint.Context) *Misuse {
+	accepted := context.Accepts(labels)
+	// missing scheme validation is not a sanitizer misuse
+	if context.IsJavaScriptURL(labels) {
+		return nil
+	}
+
+	// the sanitizer closest to the sink decide the encoding
+	var callOp *cfg.OpExprFunctionCall
+	for _, op := range ops {
+		if sanitizerCall := pg.getSanitizerCall(op, make(map[*Sanitizer]struct{})); sanitizerCall != nil {
+			callOp = sanitizerCall
+		}
+	}
+	if callOp == nil {
+		return nil
+	}
+
+	funcNameStr, _ := cfg.GetOperandName(callOp.Name)
+	funcNameStr = strings.ToLower(funcNameStr)
+	var flagsOper cfg.Operand
+	if len(callOp.Args) > 1 {
+		flagsOper = callOp.Args[1]
+	}
+	var flags []string
+	if flagsOper != nil {
+		flags, _ = cfg.GetConstNames(flagsOper)
+	}
+	misuse := &Misuse{Sanitizer: callOp, Flags: flags}
+
+	switch funcNameStr {
+	case "htmlspecialchars", "htmlentities":
+		if context.Kind != taint.CONTEXT_ATTR && context.Kind != taint.CONTEXT_URL_ATTR && context.Kind != taint.CONTEXT_SCRIPT_STRING {
+			break
+		}
+		switch label := taint.GetHTMLEscapeLabel(flagsOper); {
+		case label == taint.LABEL_HTML_ESCAPED_NOQUOTES && context.Quote != 0,
+			label == taint.LABEL_HTML_ESCAPED_DQUOTES && context.Quote == '\'':
+			misuse.Kind = MISUSE_QUOTES_NOT_ENCODED
+			misuse.Message = fmt.Sprintf("%s with flags '%s' doesn't encode the quote of %s", funcNameStr, strings.Join(flags, "|"), context)
+			return misuse
+		case flagsOper == nil && context.Quote == '\'':
+			misuse.Kind = MISUSE_VERSION_DEFAULT_FLAGS
+			misuse.Message = fmt.Sprintf("%s without flags doesn't encode single quote before PHP 8.1, value is used in %s", funcNameStr, context)
+			return misuse
+		}
+	case "strip_tags", "addslashes":
+		if !accepted {
+			misuse.Kind = MISUSE_NOT_HTML_ESCAPING
+			misuse.Message = fmt.Sprintf("%s is not html escaping, value is used in %s", funcNameStr, context)
+			return misuse
+		}
+	case "urlencode", "rawurlencode":
+		if context.Kind == taint.CONTEXT_URL_ATTR {
+			misuse.Kind = MISUSE_ENCODED_FULL_URL
+			misuse.Message = fmt.Sprintf("%s encode the whole URL in %s, only query parameters should be encoded", funcNameStr, context)
+			return misuse
+		}
+	case "json_encode":
+		if accepted {
+			break
+		}
+		if missing, ok := context.MissingJSONFlags(labels); ok && len(missing) > 0 {
+			misuse.Kind = MISUSE_JSON_MISSING_HEX_FLAGS
+			misuse.MissingFlags = missing
+			misuse.Message = fmt.Sprintf("json_encode without %s can break out of %s", strings.Join(missing, "|"), context)
+			return misuse
+		}
+	}
+
+	if !accepted && labels != taint.LABELS_RAW {
+		misuse.Kind = MISUSE_WRONG_CONTEXT
+		misuse.Message = fmt.Sprintf("%s value is not safe in %s", labels, context)
+		return misuse
+	}
+	return nil
+}
+
+// Get builtin sanitizer call of op, following user defined wrapper
+func (pg *PathGenerator) getSanitizerCall(op cfg.Op, visited map[*Sanitizer]struct{}) *cfg.OpExprFunctionCall {
+	var sanitizer *Sanitizer
+	switch opT := op.(type) {
+	case *cfg.OpExprFunctionCall:
+		funcNameStr, _ := cfg.GetOperandName(opT.Name)
+		if labels, ok := pg.applyBuiltinCall(funcNameStr, opT.Args, taint.LABELS_RAW); ok && labels != taint.LABELS_RAW {
+			return opT
+		}
+		sanitizer, _ = pg.sanitizers.lookupFunc(funcNameStr)
+	case *cfg.OpExprStaticCall:
+		sanitizer, _ = pg.getStaticCallSanitizer(opT)
+	case *cfg.OpExprMethodCall:
+		if methodNameStr, err := cfg.GetOperandName(opT.Name); err == nil {
+			sanitizer, _ = pg.sanitizers.lookupMethod(methodNameStr)
+		}
+	}
+	if sanitizer == nil {
+		return nil
+	}
+	if _, ok := visited[sanitizer]; ok {
+		return nil
+	}
+	visited[sanitizer] = struct{}{}
+	for _, wrap := range sanitizer.Wraps {
+		if callOp := pg.getSanitizerCall(wrap, visited); callOp != nil {
+			return callOp
+		}
+	}
+	return nil
+}
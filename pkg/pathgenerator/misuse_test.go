@@ -0,0 +1,94 @@
+package pathgenerator
+
+import (
+	"testing"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/rules"
+	"github.com/rxhunter00/XSS-Taint/pkg/taint"
+)
+
+func constFetch(name string) cfg.Operand {
+	return cfg.NewOpExprConstFetch(cfg.NewOperandString(name), nil).Result
+}
+
+func TestFindMisuse(t *testing.T) {
+	tainted := cfg.NewTemporaryOperand(nil)
+	tests := []struct {
+		name   string
+		call   *cfg.OpExprFunctionCall
+		prefix string
+		want   MisuseKind
+		// flags reported missing for json_encode
+		wantMissing []string
+	}{
+		{name: "no quotes in attribute", call: newCall("htmlspecialchars", tainted, constFetch("ENT_NOQUOTES")), prefix: `<a title="`, want: MISUSE_QUOTES_NOT_ENCODED},
+		{name: "double quotes only in single quoted attribute", call: newCall("htmlspecialchars", tainted, constFetch("ENT_COMPAT")), prefix: `<a title='`, want: MISUSE_QUOTES_NOT_ENCODED},
+		{name: "default flags in single quoted attribute", call: newCall("htmlentities", tainted), prefix: `<a title='`, want: MISUSE_VERSION_DEFAULT_FLAGS},
+		{name: "strip_tags in attribute", call: newCall("strip_tags", tainted), prefix: `<a title="`, want: MISUSE_NOT_HTML_ESCAPING},
+		{name: "addslashes in html text", call: newCall("addslashes", tainted), prefix: `<p>`, want: MISUSE_NOT_HTML_ESCAPING},
+		{name: "urlencode on full URL", call: newCall("urlencode", tainted), prefix: `<a href="`, want: MISUSE_ENCODED_FULL_URL},
+		{
+			name:        "json_encode in script",
+			call:        newCall("json_encode", tainted),
+			prefix:      `<script>var data = `,
+			want:        MISUSE_JSON_MISSING_HEX_FLAGS,
+			wantMissing: []string{"JSON_HEX_TAG"},
+		},
+		{name: "html escaping in script", call: newCall("htmlspecialchars", tainted), prefix: `<script>var data = `, want: MISUSE_WRONG_CONTEXT},
+		{name: "html escaping in html text", call: newCall("htmlspecialchars", tainted), prefix: `<p>`},
+		{name: "json_encode with hex tag in script", call: newCall("json_encode", tainted, constFetch("JSON_HEX_TAG")), prefix: `<script>var data = `},
+		{name: "not a sanitizer", call: newCall("trim", tainted), prefix: `<a title="`},
+	}
+	pg := NewPathGenerator()
+	pg.ruleSet = rules.Default()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels, ok := pg.transformLabels(tt.call, tainted, taint.LABELS_RAW)
+			if !ok {
+				t.Fatalf("value is no longer tainted")
+			}
+			misuse := pg.findMisuse([]cfg.Op{tt.call}, labels, taint.GetContext(tt.prefix))
+			if tt.want == "" {
+				if misuse != nil {
+					t.Errorf("findMisuse() = %s, want nil", misuse.Kind)
+				}
+				return
+			}
+			if misuse == nil {
+				t.Fatalf("findMisuse() = nil, want %s", tt.want)
+			}
+			if misuse.Kind != tt.want {
+				t.Errorf("Kind = %s, want %s", misuse.Kind, tt.want)
+			}
+			if misuse.Sanitizer != tt.call {
+				t.Errorf("Sanitizer is not the call")
+			}
+			if len(misuse.MissingFlags) != len(tt.wantMissing) {
+				t.Fatalf("MissingFlags = %v, want %v", misuse.MissingFlags, tt.wantMissing)
+			}
+			for i, flag := range tt.wantMissing {
+				if misuse.MissingFlags[i] != flag {
+					t.Errorf("MissingFlags[%d] = %s, want %s", i, misuse.MissingFlags[i], flag)
+				}
+			}
+		})
+	}
+}
+
+func TestFindMisuseFlags(t *testing.T) {
+	tainted := cfg.NewTemporaryOperand(nil)
+	flags := cfg.NewOpExprBinaryBitwiseOr(constFetch("ENT_NOQUOTES"), constFetch("ENT_HTML5"), nil).Result
+	call := newCall("htmlspecialchars", tainted, flags)
+
+	pg := NewPathGenerator()
+	pg.ruleSet = rules.Default()
+	labels, _ := pg.transformLabels(call, tainted, taint.LABELS_RAW)
+	misuse := pg.findMisuse([]cfg.Op{call}, labels, taint.GetContext(`<input value="`))
+	if misuse == nil {
+		t.Fatalf("findMisuse() = nil, want %s", MISUSE_QUOTES_NOT_ENCODED)
+	}
+	if len(misuse.Flags) != 2 || misuse.Flags[0] != "ENT_NOQUOTES" || misuse.Flags[1] != "ENT_HTML5" {
+		t.Errorf("Flags = %v, want [ENT_NOQUOTES ENT_HTML5]", misuse.Flags)
+	}
+}
@@ -0,0 +1,159 @@
+package pathgenerator
+
+import (
+	"testing"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/rules"
+)
+
+func TestHasStringConversion(t *testing.T) {
+	tests := []struct {
+		format string
+		want   bool
+	}{
+		{"", false},
+		{"Hello %s", true},
+		{"%d items", false},
+		{"100%% done", false},
+		{"%%s", false},
+		{"%1$s", true},
+		{"%'*10.5s", true},
+		{"%-10s|", true},
+		{"%05.2f", false},
+		{"%x %o %b", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if got := hasStringConversion(tt.format); got != tt.want {
+				t.Errorf("hasStringConversion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsOutputStream(t *testing.T) {
+	fopen := func(url string) cfg.Operand {
+		return newCall("fopen", cfg.NewOperandString(url), cfg.NewOperandString("w")).Result
+	}
+	assigned := cfg.NewTemporaryOperand(nil)
+	cfg.NewOpExprAssign(assigned, fopen("php://output"), nil, nil, nil)
+	tests := []struct {
+		name   string
+		stream cfg.Operand
+		want   bool
+	}{
+		{"STDOUT", constFetch("STDOUT"), false},
+		{"STDERR", constFetch("STDERR"), false},
+		{"output url", cfg.NewOperandString("php://output"), true},
+		{"file name", cfg.NewOperandString("/tmp/log.txt"), false},
+		{"fopen output", fopen("php://output"), true},
+		{"fopen output in upper case", fopen(" PHP://OUTPUT"), true},
+		{"fopen stdout", fopen("php://stdout"), false},
+		{"fopen file", fopen("/tmp/log.txt"), false},
+		{"assigned stream", assigned, true},
+		{"unknown stream", cfg.NewTemporaryOperand(nil), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isOutputStream(tt.stream); got != tt.want {
+				t.Errorf("isOutputStream() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsOutputSink(t *testing.T) {
+	tainted := cfg.NewTemporaryOperand(nil)
+	number := cfg.NewOperandNumber(1)
+	values := cfg.NewTemporaryOperand(nil)
+	tests := []struct {
+		name          string
+		op            cfg.Op
+		taintedVar    cfg.Operand
+		displayErrors bool
+		want          bool
+	}{
+		{"exit message", cfg.NewOpExit(tainted, nil), tainted, false, true},
+		{"exit status", cfg.NewOpExit(number, nil), number, false, false},
+		{"printf format", newCall("printf", tainted), tainted, false, true},
+		{"printf string", newCall("printf", cfg.NewOperandString("<b>%s</b>"), tainted), tainted, false, true},
+		{"printf number", newCall("printf", cfg.NewOperandString("%d items"), tainted), tainted, false, false},
+		{"printf unknown format", newCall("printf", cfg.NewTemporaryOperand(nil), tainted), tainted, false, true},
+		{"printf later argument", newCall("printf", cfg.NewOperandString("%d: %s"), number, tainted), tainted, false, true},
+		{"vprintf values", newCall("vprintf", cfg.NewOperandString("%s"), values), values, false, true},
+		{"fprintf output", newCall("fprintf", newCall("fopen", cfg.NewOperandString("php://output"), cfg.NewOperandString("w")).Result, cfg.NewOperandString("%s"), tainted), tainted, false, true},
+		{"fprintf stdout", newCall("fprintf", constFetch("STDOUT"), cfg.NewOperandString("%s"), tainted), tainted, false, false},
+		{"fprintf file", newCall("fprintf", cfg.NewTemporaryOperand(nil), cfg.NewOperandString("%s"), tainted), tainted, false, false},
+		{"fwrite output", newCall("fwrite", cfg.NewOperandString("php://output"), tainted), tainted, false, true},
+		{"fwrite stream argument", newCall("fwrite", tainted, cfg.NewOperandString("x")), tainted, false, false},
+		{"file_put_contents output", newCall("file_put_contents", cfg.NewOperandString("php://output"), tainted), tainted, false, true},
+		{"trigger_error with display errors", newCall("trigger_error", tainted), tainted, true, true},
+		{"trigger_error", newCall("trigger_error", tainted), tainted, false, false},
+		{"not an output function", newCall("strlen", tainted), tainted, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pg := NewPathGenerator()
+			pg.displayErrors = tt.displayErrors
+			if got := pg.isOutputSink(tt.op, tt.taintedVar); got != tt.want {
+				t.Errorf("isOutputSink() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReturnedOutputSink(t *testing.T) {
+	tainted := cfg.NewTemporaryOperand(nil)
+	tests := []struct {
+		name string
+		call *cfg.OpExprFunctionCall
+		want bool
+	}{
+		{"print_r", newCall("print_r", tainted), true},
+		{"print_r return", newCall("print_r", tainted, cfg.NewOperandBool(true)), false},
+		{"print_r false", newCall("print_r", tainted, cfg.NewOperandBool(false)), true},
+		{"var_export return", newCall("var_export", tainted, cfg.NewOperandBool(true)), false},
+		{"var_dump", newCall("var_dump", tainted), true},
+	}
+	ruleSet := rules.Default()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call, ok := rules.GetCall(tt.call)
+			if !ok {
+				t.Fatalf("GetCall() failed")
+			}
+			sink, ok := ruleSet.GetSink(call, tainted)
+			if got := ok && sink.Output; got != tt.want {
+				t.Errorf("output sink = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDisplayErrorsOn(t *testing.T) {
+	tests := []struct {
+		name  string
+		value cfg.Operand
+		want  bool
+	}{
+		{"on", cfg.NewOperandString("On"), true},
+		{"stdout", cfg.NewOperandString("stdout"), true},
+		{"one", cfg.NewOperandNumber(1), true},
+		{"off", cfg.NewOperandString("0"), false},
+		{"stderr", cfg.NewOperandString("stderr"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			main, err := cfg.NewFunc("{main}", cfg.FUNC_MODIF_FLAG_PUBLIC, cfg.NewOpTypeVoid(nil), cfg.NewBlock(0), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			main.Calls = append(main.Calls, newCall("ini_set", cfg.NewOperandString("display_errors"), tt.value))
+			script := cfg.NewScript(main, "/app/index.php")
+			if got := isDisplayErrorsOn(map[string]*cfg.Script{"/app/index.php": script}); got != tt.want {
+				t.Errorf("isDisplayErrorsOn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,29 +4,187 @@ import (
 	"fmt"
 	"log"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/rules"
+	"github.com/rxhunter00/XSS-Taint/pkg/taint"
 )
 
 type PathGenerator struct {
-	detectedPaths [][]cfg.Op
+	detectedPaths []TaintPath
 	currPath      []cfg.Op
-	visited       map[cfg.Op]map[cfg.Operand]struct{}
+	visited       map[cfg.Op]map[cfg.Operand]map[string]struct{}
+	sanitizers    *sanitizerSet
+	// ini_set('display_errors', 1) is called, trigger_error output the message
+	displayErrors bool
+	// PHPMailer Body property fetch names which isHTML() is turned on
+	htmlMailBodies map[string]struct{}
+	response       *ResponseConfig
+	// rules to check, every rule if empty
+	rules map[taint.RuleID]struct{}
+	// returns of entry points mapped to the handler name
+	entryReturns map[*cfg.OpReturn]string
+	// render array ops of the render array builders mapped to the builder name
+	renderOps map[cfg.Op]string
+	// function where the traced source is
+	currFuncName string
+	// sources, sinks and sanitizers of the rule files
+	ruleSet *rules.RuleSet
+}
+
+// Flow of tainted value from source to a sink that doesn't accept its labels
+type TaintPath struct {
+	Ops    []cfg.Op
+	Labels taint.Labels
+	Sink   Sink
+	// Html context of XSS sink
+	Context taint.Context
+	// Set when the sanitizer in the path is misused
+	Misuse *Misuse
+	// Response content types the sink can output with, empty if never set
+	ContentTypes []string
+}
+
+// State of the taint along the current path
+type taintState struct {
+	// Array keys overwritten after the array become tainted,
+	// such as $_GET['page'] = (int) $_GET['page']
+	overwrittenKeys []string
+	// Encodings applied to the tainted value
+	labels taint.Labels
+	// Html concatenated before the tainted value
+	prefix string
+	// Tainted keys of the superglobal, nil if every key is tainted
+	sourceKeys []string
+}
+
+// Length of the start and the end of the prefix kept in the visited key
+const prefixKeyLength = 256
+
+func (ts taintState) String() string {
+	return strings.Join(ts.overwrittenKeys, ",") + "|" + ts.labels.String() + "|" + ts.prefixKey() + "|" + strings.Join(ts.sourceKeys, ",")
+}
+
+// Key of the prefix, html concatenated in a loop such as $s = '<li>' . $s makes the prefix
+// grow without end, only its start read by header and include sinks and its end with the
+// html context are kept
+func (ts taintState) prefixKey() string {
+	if len(ts.prefix) <= 2*prefixKeyLength {
+		return ts.prefix
+	}
+	context := taint.GetContext(ts.prefix)
+	return fmt.Sprintf("%s...%s#%d,%d,%s", ts.prefix[:prefixKeyLength], ts.prefix[len(ts.prefix)-prefixKeyLength:], context.Kind, context.Quote, context.Attr)
+}
+
+func (ts taintState) isOverwritten(key string) bool {
+	for _, k := range ts.overwrittenKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Get the state of the taint after it pass op
+func (ts taintState) next(op cfg.Op, taintedVar cfg.Operand) taintState {
+	switch opT := op.(type) {
+	case *cfg.OpExprArrayDimAssign:
+		key, ok := cfg.GetConstString(opT.Dim)
+		if ok && opT.Var == taintedVar && !ts.isOverwritten(key) {
+			keys := make([]string, len(ts.overwrittenKeys), len(ts.overwrittenKeys)+1)
+			copy(keys, ts.overwrittenKeys)
+			return taintState{overwrittenKeys: append(keys, key), labels: ts.labels, sourceKeys: ts.sourceKeys}
+		} else if opT.Var == taintedVar {
+			return ts
+		}
+	case *cfg.OpExprAssign, *cfg.OpExprAssignRef, *cfg.OpPhi:
+		// the array is copied
+		return ts
+	case *cfg.OpExprBinaryConcat:
+		if opT.Left != taintedVar {
+			return taintState{labels: ts.labels, prefix: getOutputString(opT.Left) + ts.prefix}
+		}
+		return taintState{labels: ts.labels, prefix: ts.prefix}
+	case *cfg.OpExprConcatList:
+		prefix := ""
+		for _, part := range opT.List {
+			if part == taintedVar {
+				break
+			}
+			prefix += getOutputString(part)
+		}
+		return taintState{labels: ts.labels, prefix: prefix + ts.prefix}
+	case *cfg.OpEcho:
+		// buffered output is preceded by the html outputted before
+		return taintState{labels: ts.labels, prefix: opT.HTMLPrefix + ts.prefix}
+	}
+	return taintState{labels: ts.labels}
+}
+
+// Non constant part of html is replaced by a plain character
+func getOutputString(oper cfg.Operand) string {
+	if str, ok := cfg.GetConstString(oper); ok {
+		return str
+	}
+	return "x"
 }
 
 func NewPathGenerator() *PathGenerator {
 	return &PathGenerator{
-		detectedPaths: make([][]cfg.Op, 0),
+		detectedPaths: make([]TaintPath, 0),
+		sanitizers:    newSanitizerSet(nil),
+		response:      NewResponseConfig(),
+		rules:         make(map[taint.RuleID]struct{}),
+		ruleSet:       rules.NewRuleSet(),
+	}
+}
+
+func (pg *PathGenerator) isRuleEnabled(rule taint.RuleID) bool {
+	if len(pg.rules) == 0 {
+		return true
+	}
+	_, ok := pg.rules[rule]
+	return ok
+}
+
+// Settings of the path generator given by the user
+type Config struct {
+	Response *ResponseConfig
+	// Rules to check, every rule if empty
+	Rules []taint.RuleID
+	// Sources, sinks and sanitizers of the rule files
+	RuleSet *rules.RuleSet
+}
+
+func NewConfig() *Config {
+	return &Config{
+		Response: NewResponseConfig(),
+		Rules:    make([]taint.RuleID, 0),
+		RuleSet:  rules.Default(),
 	}
 }
 
-func GeneratePath(scripts map[string]*cfg.Script) [][]cfg.Op {
+func GeneratePath(scripts map[string]*cfg.Script, sanitizers []*Sanitizer, config *Config) []TaintPath {
 	pg := NewPathGenerator()
+	pg.response = config.Response
+	pg.ruleSet = config.RuleSet
+	pg.entryReturns = findEntryPointReturns(scripts, config.Response)
+	pg.renderOps = findRenderOps(scripts, config.Response, config.RuleSet)
+	for _, rule := range config.Rules {
+		pg.rules[rule] = struct{}{}
+	}
+	pg.sanitizers = newSanitizerSet(scripts)
+	for _, sanitizer := range sanitizers {
+		pg.sanitizers.add(sanitizer)
+	}
+	pg.displayErrors = isDisplayErrorsOn(scripts)
+	pg.htmlMailBodies = findHTMLMailBodies(scripts)
 
 	for _, script := range scripts {
 
-		pg.visited = make(map[cfg.Op]map[cfg.Operand]struct{})
+		pg.visited = make(map[cfg.Op]map[cfg.Operand]map[string]struct{})
 		pg.traverseScript(script)
 
 	}
@@ -44,18 +202,36 @@ func (pg *PathGenerator) traverseScript(s *cfg.Script) {
 }
 
 func (pg *PathGenerator) traverseFunc(fn cfg.Func) {
+	pg.currFuncName = fn.GetScopedName()
 
 	for _, sourceOp := range fn.Sources {
 
-		pg.currPath = []cfg.Op{sourceOp}
+		// filter_input apply the filter to the source
+		labels, ok := pg.transformLabels(sourceOp, nil, taint.LABELS_RAW)
+		if !ok || labels.Has(taint.LABEL_NUMERIC) {
+			continue
+		}
+		state := taintState{labels: labels, sourceKeys: pg.getSourceKeys(sourceOp)}
+		if fetchOp, ok := sourceOp.(*cfg.OpExprArrayDimFetch); ok && pg.isUntaintedFetch(fetchOp, fetchOp.Var, state) {
+			continue
+		}
+		if _, ok := sourceOp.(*cfg.OpExprAssign); !ok {
+			// only the assigned superglobal is still the array
+			state.sourceKeys = nil
+		}
 		// Get the result of tainted op
 		sourceVar, err := pg.getPropagatedVar(sourceOp)
 		if err != nil {
 			continue
 		}
+		byRefOnly, isByRefWrite := pg.getByRefSource(sourceOp, sourceVar)
 		for _, sourceUser := range sourceVar.GetUsers() {
+			if byRefOnly && !isByRefWrite(sourceUser) {
+				continue
+			}
 			// For each p that use this source
-			err := pg.traceTaintFlow(sourceUser, sourceVar)
+			pg.currPath = []cfg.Op{sourceOp, sourceUser}
+			err := pg.traceTaintFlow(sourceUser, sourceVar, state)
 			if err != nil {
 				log.Fatalf("traverseFunc:File '%s':  %v", fn.Filepath, err)
 			}
@@ -63,22 +239,59 @@ func (pg *PathGenerator) traverseFunc(fn cfg.Func) {
 	}
 }
 
-func (pg *PathGenerator) traceTaintFlow(taintedUser cfg.Op, taintedVar cfg.Operand) error {
+func (pg *PathGenerator) traceTaintFlow(taintedUser cfg.Op, taintedVar cfg.Operand, state taintState) error {
 
-	if pg.isSink(taintedUser, taintedVar) {
+	if echo, escapes, ok := pg.getBufferedOutput(taintedUser, taintedVar); ok {
+		// output inside output buffer is reported where the buffer is output
+		state.labels = state.labels.Add(escapes)
+		temp := pg.currPath
+		newPath := make([]cfg.Op, len(pg.currPath))
+		copy(newPath, pg.currPath)
+		pg.currPath = append(newPath, echo)
+		err := pg.traceTaintFlow(echo, echo.Expr, state)
+		pg.currPath = temp
+		return err
+	}
+	if sink, ok := pg.getSink(taintedUser, taintedVar, state); ok {
+		var misuse *Misuse
+		var contentTypes []string
+		labels := state.labels.Add(sink.Escapes)
+		if sink.Rule.IsHTML() {
+			misuse = pg.findMisuse(pg.currPath, labels, sink.Context)
+		}
+		if sink.Rule == taint.RULE_XSS {
+			// output of json or plain text response is not rendered
+			contentTypes = getSinkContentTypes(taintedUser)
+			if !taint.CanRenderHTML(contentTypes) {
+				return nil
+			}
+		}
+		if misuse == nil && sink.Accepts(labels) {
+			return nil
+		}
 
 		newPath := make([]cfg.Op, len(pg.currPath))
 		copy(newPath, pg.currPath)
 		newPath = append(newPath, taintedUser)
-		pg.detectedPaths = append(pg.detectedPaths, newPath)
+		pg.detectedPaths = append(pg.detectedPaths, TaintPath{Ops: newPath, Labels: labels, Sink: sink, Context: sink.Context, Misuse: misuse, ContentTypes: contentTypes})
 
 		return nil
-	} else if pg.isSanitized(taintedUser, taintedVar) {
+	}
+	labels, ok := pg.transformLabels(taintedUser, taintedVar, state.labels)
+	if !ok || labels.Has(taint.LABEL_NUMERIC) {
+		// numeric value is safe in every context
+		return nil
+	} else if pg.isUntaintedFetch(taintedUser, taintedVar, state) {
 		return nil
-	} else if pg.isAlreadyVisited(taintedUser, taintedVar) {
+	} else if pg.isAlreadyVisited(taintedUser, taintedVar, state) {
 		return nil
 	}
-	pg.markVisited(taintedUser, taintedVar)
+	pg.markVisited(taintedUser, taintedVar, state)
+	if err := pg.traceSpecializedCall(taintedUser, taintedVar, state); err != nil {
+		return err
+	}
+	newState := state.next(taintedUser, taintedVar)
+	newState.labels = labels
 
 	// Get Next Operand that hold taint Value
 	newTaint, err := pg.getPropagatedVar(taintedUser)
@@ -95,7 +308,7 @@ func (pg *PathGenerator) traceTaintFlow(taintedUser cfg.Op, taintedVar cfg.Opera
 		pg.currPath = append(newPath, newTaintUser)
 
 		// Trace tainted for the result
-		err := pg.traceTaintFlow(newTaintUser, newTaint)
+		err := pg.traceTaintFlow(newTaintUser, newTaint, newState)
 		if err != nil {
 			return err
 		}
@@ -106,10 +319,46 @@ func (pg *PathGenerator) traceTaintFlow(taintedUser cfg.Op, taintedVar cfg.Opera
 	return nil
 }
 
-func (pg *PathGenerator) isAlreadyVisited(op cfg.Op, taintedVar cfg.Operand) bool {
+// Trace the tainted argument into the parameter of the specialized function called
+func (pg *PathGenerator) traceSpecializedCall(callOp cfg.Op, taintedVar cfg.Operand, state taintState) error {
+	call, ok := rules.GetCall(callOp)
+	if !ok {
+		return nil
+	}
+	fn, ok := pg.ruleSet.GetSpecialized(call)
+	if !ok || len(fn.Params) == 0 {
+		return nil
+	}
+	for i, arg := range call.Args {
+		if arg != taintedVar {
+			continue
+		}
+		param := fn.Params[len(fn.Params)-1]
+		if i < len(fn.Params) {
+			param = fn.Params[i]
+		} else if !param.IsVariadic {
+			continue
+		}
+		for _, paramUser := range param.Result.GetUsers() {
+			temp := pg.currPath
+			newPath := make([]cfg.Op, len(pg.currPath))
+			copy(newPath, pg.currPath)
+			pg.currPath = append(newPath, param, paramUser)
+
+			err := pg.traceTaintFlow(paramUser, param.Result, state)
+			if err != nil {
+				return err
+			}
+			pg.currPath = temp
+		}
+	}
+	return nil
+}
+
+func (pg *PathGenerator) isAlreadyVisited(op cfg.Op, taintedVar cfg.Operand, state taintState) bool {
 	_, ok := pg.visited[op]
 	if ok {
-		_, v := pg.visited[op][taintedVar]
+		_, v := pg.visited[op][taintedVar][state.String()]
 		if v {
 			return true
 		}
@@ -117,167 +366,334 @@ func (pg *PathGenerator) isAlreadyVisited(op cfg.Op, taintedVar cfg.Operand) boo
 	return false
 
 }
-func (pg *PathGenerator) markVisited(op cfg.Op, taintedVar cfg.Operand) {
+func (pg *PathGenerator) markVisited(op cfg.Op, taintedVar cfg.Operand, state taintState) {
 
 	if _, ok := pg.visited[op]; !ok {
-		pg.visited[op] = make(map[cfg.Operand]struct{})
+		pg.visited[op] = make(map[cfg.Operand]map[string]struct{})
+	}
+	if _, ok := pg.visited[op][taintedVar]; !ok {
+		pg.visited[op][taintedVar] = make(map[string]struct{})
 	}
-	pg.visited[op][taintedVar] = struct{}{}
+	pg.visited[op][taintedVar][state.String()] = struct{}{}
 
 }
 
-// Check if its sanitizer
-func (pg *PathGenerator) isSanitized(op cfg.Op, taintedVar cfg.Operand) bool {
+// Check if op fetch an array entry which have been overwritten or is not a tainted key of the superglobal
+func (pg *PathGenerator) isUntaintedFetch(op cfg.Op, taintedVar cfg.Operand, state taintState) bool {
+	if fetchOp, ok := op.(*cfg.OpExprArrayDimFetch); ok && fetchOp.Var == taintedVar {
+		if key, ok := cfg.GetConstString(fetchOp.Dim); ok {
+			return state.isOverwritten(key) || (state.sourceKeys != nil && !isSourceKey(key, state.sourceKeys))
+		}
+	}
+	return false
+}
 
-	switch opT := op.(type) {
-	case *cfg.OpExprFunctionCall:
-		funcNameStr, _ := cfg.GetOperandName(opT.Name)
-		switch funcNameStr {
-		// URL Context
-		case "rawurlencode":
-			return true
-		case "urlencode":
-			return true
-		//Java Script
-		case "json_encode":
-			return true
-		//Convertible()
-		case "intval":
+func isSourceKey(key string, sourceKeys []string) bool {
+	for _, k := range sourceKeys {
+		if k == key {
 			return true
-		case "floatval":
-			return true
-		case "doubleval":
-			return true
-		case "boolval":
-			return true
-		// filter_var with cosntants
-		case "filter_var":
-			constArg := opT.Args[1].GetWriter()
-			switch filterOp := constArg.(type) {
-			case *cfg.OpExprConstFetch:
-				constName, err := cfg.GetOperandName(filterOp.Name)
-				if err != nil {
-					log.Fatalf("error in isSource: %v", err)
-				}
-				switch constName {
-				case "FILTER_SANITIZE_NUMBER_INT":
-					return true
-				case "FILTER_SANITIZE_NUMBER_FLOAT":
-					return true
-				}
-			}
-		case "htmlentities":
-			if len(opT.Args) > 1 {
-				constArg := opT.Args[1].GetWriter()
-				switch filterOp := constArg.(type) {
-				case *cfg.OpExprConstFetch:
-
-					constName, err := cfg.GetOperandName(filterOp.Name)
-					if err != nil {
-						log.Fatalf("error in IsSource: %v", err)
-					}
-					switch constName {
-					case "ENT_COMPAT":
-						return true
-					case "ENT_QUOTES":
-						return true
-					case "ENT_NOQUOTES":
-						return true
-					}
-				}
-			} else {
-				return true
+		}
+	}
+	return false
+}
+
+// Get tainted keys of the superglobal read by the source op, nil if every key is tainted
+func (pg *PathGenerator) getSourceKeys(sourceOp cfg.Op) []string {
+	for _, vr := range sourceOp.GetOpVars() {
+		if symbolic, ok := vr.(*cfg.OperandSymbolic); ok {
+			if keys, ok := pg.ruleSet.GetSuperglobalKeys(symbolic.Val); ok {
+				return keys
 			}
+		}
+	}
+	return nil
+}
 
-		case "htmlspecialchars":
-			if len(opT.Args) > 1 {
-				constArg := opT.Args[1].GetWriter()
-				switch filterOp := constArg.(type) {
-				case *cfg.OpExprConstFetch:
-					constName, err := cfg.GetOperandName(filterOp.Name)
-					if err != nil {
-						log.Fatalf("error in IsSource: %v", err)
-					}
-					switch constName {
-					case "ENT_COMPAT":
-						return true
-					case "ENT_QUOTES":
-						return true
-					case "ENT_NOQUOTES":
-						return true
-					}
-				}
-			} else {
+// Check if only the arguments passed by reference of the source call are tainted,
+// and return the check of assignment to them
+func (pg *PathGenerator) getByRefSource(sourceOp cfg.Op, sourceVar cfg.Operand) (bool, func(cfg.Op) bool) {
+	call, ok := rules.GetCall(sourceOp)
+	if !ok {
+		return false, nil
+	}
+	source, ok := pg.ruleSet.GetSource(call)
+	if !ok || source.IsResultTainted() {
+		return false, nil
+	}
+	return true, func(op cfg.Op) bool {
+		assignOp, ok := op.(*cfg.OpExprAssign)
+		if !ok || assignOp.Expr != sourceVar {
+			return false
+		}
+		varName, err := cfg.GetOperandName(assignOp.Var)
+		if err != nil {
+			return false
+		}
+		for _, i := range source.ByRef {
+			if i >= len(call.Args) {
+				continue
+			}
+			if argName, err := cfg.GetOperandName(call.Args[i]); err == nil && argName == varName {
 				return true
 			}
+		}
+		return false
+	}
+}
+
+// Get labels of the tainted value after op, false if op result is no longer tainted
+func (pg *PathGenerator) transformLabels(op cfg.Op, taintedVar cfg.Operand, labels taint.Labels) (taint.Labels, bool) {
 
+	switch opT := op.(type) {
+	case *cfg.OpExprFunctionCall:
+		funcNameStr, _ := cfg.GetOperandName(opT.Name)
+		labels, _ = pg.applyCall(funcNameStr, opT.Args, labels)
+	case *cfg.OpExprStaticCall:
+		if sanitizer, ok := pg.getRuleSanitizer(opT); ok {
+			labels = sanitizer.Apply(labels)
+		} else if sanitizer, ok := pg.getStaticCallSanitizer(opT); ok {
+			labels = pg.applySanitizer(sanitizer, labels)
+		}
+	case *cfg.OpExprMethodCall:
+		methodNameStr, err := cfg.GetOperandName(opT.Name)
+		if err != nil {
+			break
+		}
+		if sanitizer, ok := pg.getRuleSanitizer(opT); ok {
+			labels = sanitizer.Apply(labels)
+		} else if sanitizer, ok := pg.sanitizers.lookupMethod(methodNameStr); ok {
+			labels = pg.applySanitizer(sanitizer, labels)
 		}
 
 	case *cfg.OpExprCastBool, *cfg.OpExprCastDouble, *cfg.OpExprCastInt:
-		return true
+		labels = labels.Add(taint.LABEL_NUMERIC)
 	case *cfg.OpExprAssertion:
+		if taint.IsSchemeValidated(opT.Assertion) {
+			labels = labels.Add(taint.LABEL_URL_SCHEME_CHECKED)
+		}
+		if taint.IsRelativeURLValidated(opT.Assertion) {
+			labels = labels.Add(taint.LABEL_RELATIVE_URL_CHECKED)
+		}
 		switch assert := opT.Assertion.(type) {
 		case *cfg.TypeAssertion:
-			if typeVal, ok := assert.AssertionOperand.(*cfg.OperandString); ok {
+			if typeVal, ok := assert.AssertionOperand.(*cfg.OperandString); ok && !assert.IsNegated {
 				switch typeVal.Val {
 				case "int", "float", "bool", "null":
-					return true
+					labels = labels.Add(taint.LABEL_NUMERIC)
 				}
 			}
 		}
 
 	case *cfg.OpExprArrayDimFetch:
 		if opT.Dim == taintedVar {
-			return true
+			return labels, false
+		}
+		if opT.Var == taintedVar {
+			labels = pg.applyFilteredKey(opT, labels)
 		}
+	case *cfg.OpExprValid:
+		// foreach only check if the iterable still valid
+		return labels, false
+	case *cfg.OpExprArray:
+		labels = pg.applyPlaceholder(opT, taintedVar, labels)
 
 	}
-	return false
+	return labels, true
+}
+
+// Apply calling function with the arguments to the labels of tainted argument,
+// return false if the function doesn't change the labels
+func (pg *PathGenerator) applyCall(funcNameStr string, args []cfg.Operand, labels taint.Labels) (taint.Labels, bool) {
+	if newLabels, ok := pg.applyBuiltinCall(funcNameStr, args, labels); ok {
+		return newLabels, true
+	}
+	// user defined wrapper of sanitizer
+	if sanitizer, ok := pg.sanitizers.lookupFunc(funcNameStr); ok {
+		return pg.applySanitizer(sanitizer, labels), true
+	}
+	return labels, false
+}
+
+// Apply builtin function or sanitizer of the rule files, user defined wrapper isn't applied
+func (pg *PathGenerator) applyBuiltinCall(funcNameStr string, args []cfg.Operand, labels taint.Labels) (taint.Labels, bool) {
+	switch strings.ToLower(funcNameStr) {
+	// Callback applied to each entry of the array
+	case "array_map":
+		if len(args) > 1 {
+			if callback, ok := cfg.GetConstString(args[0]); ok {
+				return pg.applyCall(callback, nil, labels)
+			}
+		}
+		return labels, false
+	// filter extension
+	case "filter_var", "filter_input", "filter_var_array", "filter_input_array":
+		filterCall := taint.ResolveFilterCall(strings.ToLower(funcNameStr), args)
+		if filterCall.Keys == nil {
+			return pg.applyFilter(filterCall.Filter, labels), true
+		}
+		// each key is labeled when fetched
+		return labels, false
+	}
+	if sanitizer, ok := pg.ruleSet.GetSanitizer(rules.NewFunctionCall(funcNameStr, args)); ok {
+		return sanitizer.Apply(labels), true
+	}
+	return taint.ApplyCall(funcNameStr, args, labels)
+}
+
+// Sanitizer apply the weakest of its wrapped sanitizer
+func (pg *PathGenerator) applySanitizer(sanitizer *Sanitizer, labels taint.Labels) taint.Labels {
+	result := labels
+	applied := false
+	for _, wrap := range sanitizer.Wraps {
+		wrapLabels, ok := pg.transformLabels(wrap, nil, labels)
+		if !ok {
+			// value of the wrap doesn't hold the argument
+			continue
+		}
+		if !applied {
+			result = wrapLabels
+		} else {
+			result &= wrapLabels
+		}
+		applied = true
+	}
+	return result
+}
+
+// Get sanitizer of the method call in the rule files
+func (pg *PathGenerator) getRuleSanitizer(op cfg.Op) (*rules.Sanitizer, bool) {
+	call, ok := rules.GetCall(op)
+	if !ok {
+		return nil, false
+	}
+	return pg.ruleSet.GetSanitizer(call)
+}
+
+func (pg *PathGenerator) getStaticCallSanitizer(callOp *cfg.OpExprStaticCall) (*Sanitizer, bool) {
+	methodNameStr, err := cfg.GetOperandName(callOp.Name)
+	if err != nil {
+		return nil, false
+	}
+	classNameStr, err := cfg.GetOperandName(callOp.Class)
+	if err == nil {
+		switch strings.ToLower(classNameStr) {
+		case "self", "static", "parent":
+		default:
+			return pg.sanitizers.lookupFunc(classNameStr + "::" + methodNameStr)
+		}
+	}
+	return pg.sanitizers.lookupMethod(methodNameStr)
+}
+
+func (pg *PathGenerator) applyFilter(filter *taint.Filter, labels taint.Labels) taint.Labels {
+	if filter.Effect == taint.FILTER_EFFECT_CALLBACK {
+		if filter.Callback != "" {
+			labels, _ = pg.applyCall(filter.Callback, nil, labels)
+		}
+		return labels
+	}
+	return labels.Add(filter.Labels())
 }
 
-// Check if its sink
-func (pg *PathGenerator) isSink(op cfg.Op, taintedVar cfg.Operand) bool {
+// Apply the filter of fetched key of filter_var_array or filter_input_array result
+func (pg *PathGenerator) applyFilteredKey(fetchOp *cfg.OpExprArrayDimFetch, labels taint.Labels) taint.Labels {
+	arr := fetchOp.Var
+	for {
+		assignOp, ok := arr.GetWriter().(*cfg.OpExprAssign)
+		if !ok {
+			break
+		}
+		arr = assignOp.Expr
+	}
+	callOp, ok := arr.GetWriter().(*cfg.OpExprFunctionCall)
+	if !ok {
+		return labels
+	}
+	funcNameStr, _ := cfg.GetOperandName(callOp.Name)
+	filterCall := taint.ResolveFilterCall(strings.ToLower(funcNameStr), callOp.Args)
+	if filterCall == nil || filterCall.Keys == nil {
+		return labels
+	}
+	key, ok := cfg.GetConstString(fetchOp.Dim)
+	if !ok {
+		return labels
+	}
+	filter, ok := filterCall.Keys[key]
+	if !ok {
+		// key not in definition is not returned
+		return labels.Add(taint.LABEL_NUMERIC)
+	}
+	return pg.applyFilter(filter, labels)
+}
 
+// Get html context of the sink, false if op is not a sink
+func (pg *PathGenerator) getSinkContext(op cfg.Op, taintedVar cfg.Operand, state taintState) (taint.Context, bool) {
 	switch opT := op.(type) {
 	case *cfg.OpEcho:
-		return true
+		// buffered output is emitted later
+		if opT.IsBuffered() {
+			return taint.Context{}, false
+		}
+		return taint.GetContext(opT.HTMLPrefix + state.prefix), true
 	case *cfg.OpExprPrint:
-		return true
-	case *cfg.OpExprFunctionCall:
-		funcNameStr, _ := cfg.GetOperandName(opT.Name)
-		switch funcNameStr {
-		case "printf":
-			t := opT.Args[0]
-			switch s := t.(type) {
-			// Lazy Checking if there is any, should check based on args order and % position
-			case *cfg.OperandString:
-				if strings.Contains(s.Val, "%s") {
-					return true
-				}
-			}
-			return false
-		case "header":
-			if opT.Args[0] == taintedVar {
-				return true
-			}
-			t := opT.Args[0].GetWriter()
-			switch l := t.(type) {
-			case *cfg.OpExprBinaryConcat:
-				s, ok := l.Left.(*cfg.OperandString)
-				if ok {
-					if strings.Contains(s.Val, "Location") {
-						return true
-					}
-				} else if l.Right == taintedVar {
-					return true
-				}
+		return taint.GetContext(opT.HTMLPrefix + state.prefix), true
+	}
+	if pg.isOutputSink(op, taintedVar) {
+		return taint.GetContext(state.prefix), true
+	}
+	return taint.Context{}, false
+}
 
-			}
+// Get response content types when the sink output, empty if header() never set it
+func getSinkContentTypes(op cfg.Op) []string {
+	var contentType cfg.Operand
+	switch opT := op.(type) {
+	case *cfg.OpEcho:
+		contentType = opT.ContentType
+	case *cfg.OpExprPrint:
+		contentType = opT.ContentType
+	case *cfg.OpExit:
+		contentType = opT.ContentType
+	}
+	if contentType == nil {
+		return nil
+	}
+	set := make(map[string]struct{})
+	collectContentTypes(contentType, set, make(map[cfg.Operand]struct{}))
+	contentTypes := make([]string, 0, len(set))
+	for ct := range set {
+		contentTypes = append(contentTypes, ct)
+	}
+	sort.Strings(contentTypes)
+	return contentTypes
+}
 
+func collectContentTypes(oper cfg.Operand, set map[string]struct{}, visited map[cfg.Operand]struct{}) {
+	if _, ok := visited[oper]; ok {
+		return
+	}
+	visited[oper] = struct{}{}
+	if str, ok := oper.(*cfg.OperandString); ok {
+		if str.Val == "" {
+			set[taint.CONTENT_TYPE_UNKNOWN] = struct{}{}
+		} else {
+			set[str.Val] = struct{}{}
 		}
-
+		return
+	}
+	phi, ok := oper.GetWriter().(*cfg.OpPhi)
+	if !ok {
+		set[taint.CONTENT_TYPE_UNKNOWN] = struct{}{}
+		return
+	}
+	phiOpers := phi.GetPhiOperands()
+	if len(phiOpers) == 0 {
+		// not set before the function
+		set[taint.CONTENT_TYPE_DEFAULT] = struct{}{}
+	}
+	for _, phiOper := range phiOpers {
+		collectContentTypes(phiOper, set, visited)
 	}
-	return false
 }
 
 func (pg *PathGenerator) getPropagatedVar(op cfg.Op) (cfg.Operand, error) {
@@ -286,6 +702,8 @@ func (pg *PathGenerator) getPropagatedVar(op cfg.Op) (cfg.Operand, error) {
 			return assignmentOp.Var, nil
 		}
 		return assignmentOp.Result, nil
+	} else if assignRefOp, ok := op.(*cfg.OpExprAssignRef); ok {
+		return assignRefOp.Var, nil
 	} else if result, ok := op.GetOpVars()["Result"]; ok {
 		if result != nil {
 			return result, nil
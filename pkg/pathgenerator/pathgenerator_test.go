@@ -0,0 +1,69 @@
+package pathgenerator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/rules"
+	"github.com/rxhunter00/XSS-Taint/pkg/taint"
+)
+
+func TestTraceLoopConcat(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+	}{
+		{"list item", "<li>"},
+		{"attribute", `<a href="`},
+		{"script", "<script>var s = '"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// while (...) { $s = '<li>' . $s; echo $s; }
+			s := cfg.NewTemporaryOperand(nil)
+			concat := cfg.NewOpExprBinaryConcat(cfg.NewOperandString(tt.html), s, nil, nil, nil)
+			cfg.NewOpExprAssign(s, concat.Result, nil, nil, nil)
+			echo := cfg.NewOpEcho(s, nil)
+
+			pg := NewPathGenerator()
+			pg.ruleSet = rules.Default()
+			pg.visited = make(map[cfg.Op]map[cfg.Operand]map[string]struct{})
+			pg.currPath = []cfg.Op{concat}
+			if err := pg.traceTaintFlow(concat, s, taintState{labels: taint.LABELS_RAW}); err != nil {
+				t.Fatal(err)
+			}
+			if len(pg.detectedPaths) == 0 {
+				t.Fatalf("echo of the concatenated value is not reported")
+			}
+			for _, path := range pg.detectedPaths {
+				if path.Ops[len(path.Ops)-1] != echo {
+					t.Errorf("sink is %T, want the echo", path.Ops[len(path.Ops)-1])
+				}
+			}
+		})
+	}
+}
+
+func TestPrefixKey(t *testing.T) {
+	short := taintState{prefix: "<li>"}
+	if short.prefixKey() != "<li>" {
+		t.Errorf("prefixKey() = %q, want the prefix", short.prefixKey())
+	}
+
+	long := taintState{prefix: strings.Repeat("<li>", 1000)}
+	longer := taintState{prefix: strings.Repeat("<li>", 1001)}
+	if long.String() != longer.String() {
+		t.Errorf("key of longer repeated prefix differs")
+	}
+	if len(long.prefixKey()) > 3*prefixKeyLength {
+		t.Errorf("prefixKey() has %d bytes, want at most %d", len(long.prefixKey()), 3*prefixKeyLength)
+	}
+
+	// same start and end but the attribute is still open
+	open := taintState{prefix: strings.Repeat("<li>", 100) + `<a title="` + strings.Repeat("x", 1000)}
+	closed := taintState{prefix: strings.Repeat("<li>", 100) + `<a title="x">` + strings.Repeat("x", 997)}
+	if open.String() == closed.String() {
+		t.Errorf("key doesn't keep the html context")
+	}
+}
@@ -0,0 +1,146 @@
+package pathgenerator
+
+import (
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/cfgtraverser"
+	"github.com/rxhunter00/XSS-Taint/pkg/rules"
+	"github.com/rxhunter00/XSS-Taint/pkg/taint"
+)
+
+// Get array literals and array key assignments of the render array builders, mapped to the builder name.
+// Render arrays are searched only if the rule files have render keys
+func findRenderOps(scripts map[string]*cfg.Script, config *ResponseConfig, ruleSet *rules.RuleSet) map[cfg.Op]string {
+	ops := make(map[cfg.Op]string)
+	if !ruleSet.HasRenderKeys() {
+		return ops
+	}
+	for _, script := range scripts {
+		for _, fn := range script.FuncsMap {
+			if !config.isRenderBuilder(fn) {
+				continue
+			}
+			collector := &renderCollector{}
+			traverser := cfgtraverser.NewTraverser()
+			traverser.AddBlockTraverser(collector)
+			traverser.TraverseFunc(fn)
+			for _, op := range collector.ops {
+				ops[op] = fn.GetScopedName()
+			}
+		}
+	}
+	return ops
+}
+
+type renderCollector struct {
+	cfgtraverser.NullTraverser
+
+	ops []cfg.Op
+}
+
+func (c *renderCollector) EnterOp(op cfg.Op, block *cfg.Block) {
+	switch op.(type) {
+	case *cfg.OpExprArray, *cfg.OpExprAssign:
+		c.ops = append(c.ops, op)
+	}
+}
+
+// Get sink of render array key such as '#markup' => $html and $build['#markup'] = $html,
+// or of formatter placeholder which value is output as is
+func (pg *PathGenerator) getRenderSink(op cfg.Op, taintedVar cfg.Operand, state taintState) (Sink, bool) {
+	if formatter, call, placeholder, ok := pg.getPlaceholder(op, taintedVar); ok {
+		if labels, ok := formatter.GetPlaceholderLabels(placeholder); ok && labels == taint.LABELS_RAW {
+			sink := Sink{Rule: formatter.Rule, Target: call.String() + " placeholder " + placeholder}
+			if sink.Rule.IsHTML() {
+				sink.Context = taint.GetContext(state.prefix)
+			}
+			return sink, true
+		}
+		return Sink{}, false
+	}
+	handler, ok := pg.renderOps[op]
+	if !ok {
+		return Sink{}, false
+	}
+	key, ok := getRenderKey(op, taintedVar)
+	if !ok {
+		return Sink{}, false
+	}
+	renderKey, ok := pg.ruleSet.GetRenderKey(key)
+	if !ok {
+		return Sink{}, false
+	}
+	sink := Sink{Rule: renderKey.Rule, Target: "render array " + key, Handler: handler, Escapes: renderKey.GetAddedLabels()}
+	if sink.Rule.IsHTML() {
+		sink.Context = taint.GetContext(state.prefix)
+	}
+	return sink, true
+}
+
+// Get constant key of the tainted value in array literal or array key assignment
+func getRenderKey(op cfg.Op, taintedVar cfg.Operand) (string, bool) {
+	switch opT := op.(type) {
+	case *cfg.OpExprArray:
+		return getArrayKey(opT, taintedVar)
+	case *cfg.OpExprAssign:
+		if opT.Expr != taintedVar || opT.Var == nil {
+			return "", false
+		}
+		// the assigned variable is the result of the fetch of the array key
+		for _, writer := range opT.Var.GetWriterOps() {
+			if fetchOp, ok := writer.(*cfg.OpExprArrayDimFetch); ok && fetchOp.Dim != nil {
+				return cfg.GetConstString(fetchOp.Dim)
+			}
+		}
+	}
+	return "", false
+}
+
+func getArrayKey(arrayOp *cfg.OpExprArray, val cfg.Operand) (string, bool) {
+	for i, arrVal := range arrayOp.Vals {
+		if arrVal == val && i < len(arrayOp.Keys) && arrayOp.Keys[i] != nil {
+			return cfg.GetConstString(arrayOp.Keys[i])
+		}
+	}
+	return "", false
+}
+
+// Get formatter call which placeholder values is the array literal of op, and the placeholder of the tainted value
+func (pg *PathGenerator) getPlaceholder(op cfg.Op, taintedVar cfg.Operand) (*rules.Formatter, rules.Call, string, bool) {
+	arrayOp, ok := op.(*cfg.OpExprArray)
+	if !ok || arrayOp.Result == nil {
+		return nil, rules.Call{}, "", false
+	}
+	placeholder, ok := getArrayKey(arrayOp, taintedVar)
+	if !ok {
+		return nil, rules.Call{}, "", false
+	}
+	array := arrayOp.Result
+	users := array.GetUsers()
+	if len(users) == 1 {
+		// $args = ['@name' => $name]; t('Hello @name', $args)
+		if assignOp, ok := users[0].(*cfg.OpExprAssign); ok && assignOp.Expr == array && assignOp.Var != nil {
+			array = assignOp.Var
+			users = array.GetUsers()
+		}
+	}
+	for _, user := range users {
+		call, ok := rules.GetCall(user)
+		if !ok {
+			continue
+		}
+		if formatter, ok := pg.ruleSet.GetFormatter(call, array); ok {
+			return formatter, call, placeholder, true
+		}
+	}
+	return nil, rules.Call{}, "", false
+}
+
+// Add labels of the placeholder to the value replacing it, such as html-escaped for @name of t()
+func (pg *PathGenerator) applyPlaceholder(op cfg.Op, taintedVar cfg.Operand, labels taint.Labels) taint.Labels {
+	formatter, _, placeholder, ok := pg.getPlaceholder(op, taintedVar)
+	if !ok {
+		return labels
+	}
+	placeholderLabels, _ := formatter.GetPlaceholderLabels(placeholder)
+	return labels.Add(placeholderLabels)
+}
@@ -0,0 +1,153 @@
+package pathgenerator
+
+import (
+	"testing"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/rules"
+	"github.com/rxhunter00/XSS-Taint/pkg/taint"
+)
+
+func newDrupalPathGenerator() *PathGenerator {
+	ruleSet := rules.Default()
+	for _, pack := range rules.DetectPacks("/app/modules/hello/hello.module", nil) {
+		ruleSet.AddPack(pack)
+	}
+	pg := NewPathGenerator()
+	pg.ruleSet = ruleSet
+	return pg
+}
+
+func renderArray(key string, val cfg.Operand) *cfg.OpExprArray {
+	return cfg.NewOpExprArray([]cfg.Operand{cfg.NewOperandString(key)}, []cfg.Operand{val}, make([]bool, 1), nil)
+}
+
+// $build[key] = val
+func assignRenderKey(dim cfg.Operand, val cfg.Operand) (*cfg.OpExprArrayDimFetch, *cfg.OpExprAssign) {
+	build := cfg.NewOperandVariable(cfg.NewOperandString("$build"), nil)
+	fetch := cfg.NewOpExprArrayDimFetch(build, dim, nil)
+	return fetch, cfg.NewOpExprAssign(fetch.Result, val, nil, nil, nil)
+}
+
+func TestGetRenderKey(t *testing.T) {
+	tainted := cfg.NewTemporaryOperand(nil)
+	_, keyAssign := assignRenderKey(cfg.NewOperandString("#markup"), tainted)
+	_, varKeyAssign := assignRenderKey(cfg.NewOperandVariable(cfg.NewOperandString("$key"), nil), tainted)
+	tests := []struct {
+		name    string
+		op      cfg.Op
+		wantKey string
+	}{
+		{"array literal", renderArray("#markup", tainted), "#markup"},
+		{"other value of array literal", renderArray("#markup", cfg.NewTemporaryOperand(nil)), ""},
+		{"list without keys", cfg.NewOpExprArray(nil, []cfg.Operand{tainted}, make([]bool, 1), nil), ""},
+		{"key assignment", keyAssign, "#markup"},
+		{"variable key assignment", varKeyAssign, ""},
+		{"variable assignment", cfg.NewOpExprAssign(cfg.NewOperandVariable(cfg.NewOperandString("$markup"), nil), tainted, nil, nil, nil), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, ok := getRenderKey(tt.op, tainted)
+			if ok != (tt.wantKey != "") || key != tt.wantKey {
+				t.Errorf("getRenderKey() = %q, %v, want %q", key, ok, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestGetRenderSink(t *testing.T) {
+	tainted := cfg.NewTemporaryOperand(nil)
+	markup := renderArray("#markup", tainted)
+	children := renderArray("#children", tainted)
+	title := renderArray("#title", tainted)
+	prefixFetch, prefixAssign := assignRenderKey(cfg.NewOperandString("#prefix"), tainted)
+	helperMarkup := renderArray("#markup", tainted)
+
+	build := newMethod(t, "HelloBlock", "build", cfg.FUNC_MODIF_FLAG_PUBLIC)
+	for _, op := range []cfg.Op{markup, children, title, prefixFetch, prefixAssign} {
+		build.CFGBlock.AddInstructions(op)
+	}
+	helper := newMethod(t, "HelloBlock", "helper", cfg.FUNC_MODIF_FLAG_PRIVATE)
+	helper.CFGBlock.AddInstructions(helperMarkup)
+	main, err := cfg.NewFunc("{main}", cfg.FUNC_MODIF_FLAG_PUBLIC, cfg.NewOpTypeVoid(nil), cfg.NewBlock(0), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := cfg.NewScript(main, "/app/modules/hello/src/Plugin/Block/HelloBlock.php")
+	script.AddFunc(build)
+	script.AddFunc(helper)
+
+	// t('Hello !name', ['!name' => $name]), t('Hello @name', ['@name' => $name]) and
+	// $args = ['!name' => $name]; t('Hello !name', $args)
+	rawArgs := renderArray("!name", tainted)
+	newCall("t", cfg.NewOperandString("Hello !name"), rawArgs.Result)
+	escapedArgs := renderArray("@name", tainted)
+	newCall("t", cfg.NewOperandString("Hello @name"), escapedArgs.Result)
+	assignedArgs := renderArray("!name", tainted)
+	args := cfg.NewOperandVariable(cfg.NewOperandString("$args"), nil)
+	cfg.NewOpExprAssign(args, assignedArgs.Result, nil, nil, nil)
+	newCall("t", cfg.NewOperandString("Hello !name"), args)
+
+	tests := []struct {
+		name        string
+		op          cfg.Op
+		wantTarget  string
+		wantEscapes taint.Labels
+	}{
+		{"#markup", markup, "render array #markup", taint.LABEL_HTML_SANITIZED},
+		{"#children", children, "render array #children", taint.LABELS_RAW},
+		{"#title", title, "", taint.LABELS_RAW},
+		{"#prefix assignment", prefixAssign, "render array #prefix", taint.LABEL_HTML_SANITIZED},
+		{"#markup outside render builder", helperMarkup, "", taint.LABELS_RAW},
+		{"raw placeholder", rawArgs, "t() placeholder !name", taint.LABELS_RAW},
+		{"escaped placeholder", escapedArgs, "", taint.LABELS_RAW},
+		{"raw placeholder of assigned array", assignedArgs, "t() placeholder !name", taint.LABELS_RAW},
+	}
+	pg := newDrupalPathGenerator()
+	pg.renderOps = findRenderOps(map[string]*cfg.Script{script.Filepath: script}, pg.response, pg.ruleSet)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink, ok := pg.getRenderSink(tt.op, tainted, taintState{prefix: "<div>"})
+			if ok != (tt.wantTarget != "") || sink.Target != tt.wantTarget {
+				t.Fatalf("getRenderSink() = %q, %v, want %q", sink.Target, ok, tt.wantTarget)
+			}
+			if !ok {
+				return
+			}
+			if sink.Rule != taint.RULE_XSS || sink.Context.Kind != taint.CONTEXT_HTML_TEXT {
+				t.Errorf("sink is %s in %+v, want %s in html text", sink.Rule, sink.Context, taint.RULE_XSS)
+			}
+			if sink.Escapes != tt.wantEscapes {
+				t.Errorf("Escapes = %s, want %s", sink.Escapes, tt.wantEscapes)
+			}
+		})
+	}
+}
+
+func TestApplyPlaceholder(t *testing.T) {
+	tainted := cfg.NewTemporaryOperand(nil)
+	newArgs := func(call string, placeholder string) cfg.Op {
+		args := renderArray(placeholder, tainted)
+		newCall(call, cfg.NewOperandString("Hello "+placeholder), args.Result)
+		return args
+	}
+	tests := []struct {
+		name string
+		op   cfg.Op
+		want taint.Labels
+	}{
+		{"@ placeholder", newArgs("t", "@name"), taint.LABEL_HTML_ESCAPED},
+		{"% placeholder", newArgs("format_string", "%name"), taint.LABEL_HTML_ESCAPED},
+		{": placeholder", newArgs("t", ":url"), taint.LABEL_HTML_ESCAPED | taint.LABEL_URL_SCHEME_CHECKED},
+		{"! placeholder", newArgs("t", "!name"), taint.LABELS_RAW},
+		{"other function", newArgs("strtr", "@name"), taint.LABELS_RAW},
+	}
+	pg := newDrupalPathGenerator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pg.applyPlaceholder(tt.op, tainted, taint.LABELS_RAW); got != tt.want {
+				t.Errorf("applyPlaceholder() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package pathgenerator
+
+import (
+	"path"
+	"strings"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/cfgtraverser"
+	"github.com/rxhunter00/XSS-Taint/pkg/taint"
+)
+
+// Response producing sinks, the value is emitted later by the front controller
+type ResponseConfig struct {
+	// Glob patterns of entry point name such as *Controller::*, class name is without namespace.
+	// Return value of public entry point is the response body
+	EntryPoints []string
+	// Response classes which first constructor argument is the body, mapped to the content type
+	ResponseClasses map[string]string
+	// Methods setting the body of response, such as $response->setContent($html)
+	ContentSetters []string
+	// Glob patterns of functions building render arrays besides the entry points, such as *::buildForm
+	RenderBuilders []string
+}
+
+func NewResponseConfig() *ResponseConfig {
+	return &ResponseConfig{
+		EntryPoints: []string{"*controller::*"},
+		ResponseClasses: map[string]string{
+			"response":     taint.CONTENT_TYPE_DEFAULT,
+			"htmlresponse": taint.CONTENT_TYPE_DEFAULT,
+			"jsonresponse": "application/json",
+			"textresponse": "text/plain",
+		},
+		ContentSetters: []string{"setcontent"},
+		RenderBuilders: []string{"*::buildform", "*::form", "*::build", "*_form"},
+	}
+}
+
+// Add entry point pattern, matched case insensitively
+func (config *ResponseConfig) AddEntryPoint(pattern string) {
+	config.EntryPoints = append(config.EntryPoints, strings.ToLower(pattern))
+}
+
+// Add response class, without namespace
+func (config *ResponseConfig) AddResponseClass(className string, contentType string) {
+	config.ResponseClasses[strings.ToLower(getShortName(className))] = strings.ToLower(contentType)
+}
+
+func (config *ResponseConfig) AddContentSetter(methodName string) {
+	config.ContentSetters = append(config.ContentSetters, strings.ToLower(methodName))
+}
+
+// Add pattern of functions building render arrays, matched case insensitively
+func (config *ResponseConfig) AddRenderBuilder(pattern string) {
+	config.RenderBuilders = append(config.RenderBuilders, strings.ToLower(pattern))
+}
+
+// Check if fn is matched as entry point
+func (config *ResponseConfig) isEntryPoint(fn *cfg.Func) bool {
+	return isPublicFunc(fn) && matchFunc(fn, config.EntryPoints)
+}
+
+// Check if fn is entry point or render array builder
+func (config *ResponseConfig) isRenderBuilder(fn *cfg.Func) bool {
+	return isPublicFunc(fn) && (matchFunc(fn, config.EntryPoints) || matchFunc(fn, config.RenderBuilders))
+}
+
+// Constructor and non public method are not called by the router
+func isPublicFunc(fn *cfg.Func) bool {
+	if fn.FunctionClass == nil {
+		return true
+	}
+	return !strings.EqualFold(fn.Name, "__construct") && fn.GetVisibility()&(cfg.FUNC_MODIF_FLAG_PROTECTED|cfg.FUNC_MODIF_FLAG_PRIVATE) == 0
+}
+
+// Check if the name of fn, as class::method for method, match any of the patterns
+func matchFunc(fn *cfg.Func, patterns []string) bool {
+	name := strings.ToLower(fn.Name)
+	if fn.FunctionClass != nil {
+		name = strings.ToLower(getShortName(fn.FunctionClass.Val)) + "::" + name
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (config *ResponseConfig) isContentSetter(methodName string) bool {
+	methodName = strings.ToLower(methodName)
+	for _, setter := range config.ContentSetters {
+		if setter == methodName {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns of entry points, mapped to the handler name
+func findEntryPointReturns(scripts map[string]*cfg.Script, config *ResponseConfig) map[*cfg.OpReturn]string {
+	returns := make(map[*cfg.OpReturn]string)
+	for _, script := range scripts {
+		for _, fn := range script.FuncsMap {
+			if !config.isEntryPoint(fn) {
+				continue
+			}
+			collector := &returnCollector{}
+			traverser := cfgtraverser.NewTraverser()
+			traverser.AddBlockTraverser(collector)
+			traverser.TraverseFunc(fn)
+			for _, ret := range collector.returns {
+				returns[ret] = fn.GetScopedName()
+			}
+		}
+	}
+	return returns
+}
+
+// Check if oper is object of a response class or a rendered view, the body is checked when
+// the response is created and the view data is checked in the template
+func (pg *PathGenerator) isResponseObject(oper cfg.Operand) bool {
+	if obj, ok := cfg.GetOperVal(oper).(*cfg.OperandObject); ok {
+		_, ok = pg.response.ResponseClasses[strings.ToLower(getShortName(obj.ClassName))]
+		return ok
+	}
+	return isViewCall(oper.GetWriter())
+}
+
+// Check if op is view('name', $data) or View::make() call, followed by its ->with() chain,
+// or ->render('name.html.twig', $data) and ->renderView() of Symfony controller and Twig
+func isViewCall(op cfg.Op) bool {
+	for i := 0; op != nil && i < 32; i++ {
+		switch opT := op.(type) {
+		case *cfg.OpExprAssign:
+			op = opT.Expr.GetWriter()
+		case *cfg.OpExprFunctionCall:
+			funcNameStr, err := cfg.GetOperandName(opT.Name)
+			return err == nil && strings.EqualFold(getShortName(funcNameStr), "view")
+		case *cfg.OpExprStaticCall:
+			classNameStr, err := cfg.GetOperandName(opT.Class)
+			if err != nil || !strings.EqualFold(getShortName(classNameStr), "View") {
+				return false
+			}
+			methodNameStr, err := cfg.GetOperandName(opT.Name)
+			return err == nil && strings.EqualFold(methodNameStr, "make")
+		case *cfg.OpExprMethodCall:
+			methodNameStr, err := cfg.GetOperandName(opT.Name)
+			if err != nil {
+				return false
+			}
+			if strings.EqualFold(methodNameStr, "render") || strings.EqualFold(methodNameStr, "renderView") {
+				return len(opT.Args) > 0
+			}
+			if !strings.EqualFold(methodNameStr, "with") {
+				return false
+			}
+			op = opT.Var.GetWriter()
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// Get the response sink of op, false if op doesn't put the tainted value in response body
+func (pg *PathGenerator) getResponseSink(op cfg.Op, taintedVar cfg.Operand, state taintState) (Sink, bool) {
+	sink := Sink{Rule: taint.RULE_XSS, Handler: pg.currFuncName}
+	switch opT := op.(type) {
+	case *cfg.OpReturn:
+		handler, ok := pg.entryReturns[opT]
+		if !ok || opT.Expr != taintedVar || pg.isResponseObject(taintedVar) {
+			return Sink{}, false
+		}
+		sink.Handler = handler
+		sink.Target = "return value"
+	case *cfg.OpExprNew:
+		classNameStr, err := cfg.GetOperandName(opT.Class)
+		if err != nil || !isArg(opT.Args, 0, taintedVar) {
+			return Sink{}, false
+		}
+		contentType, ok := pg.response.ResponseClasses[strings.ToLower(getShortName(classNameStr))]
+		if !ok {
+			return Sink{}, false
+		}
+		// new Response($body, $status, ['Content-Type' => 'text/plain'])
+		if len(opT.Args) > 2 {
+			if headerType, ok := getHeadersContentType(opT.Args[2]); ok {
+				contentType = headerType
+			}
+		}
+		if !taint.IsHTMLContentType(contentType) {
+			return Sink{}, false
+		}
+		sink.Target = "new " + getShortName(classNameStr)
+	case *cfg.OpExprMethodCall:
+		methodNameStr, err := cfg.GetOperandName(opT.Name)
+		if err != nil || !isArg(opT.Args, 0, taintedVar) || !pg.response.isContentSetter(methodNameStr) {
+			return Sink{}, false
+		}
+		sink.Target = methodNameStr + "()"
+	default:
+		return Sink{}, false
+	}
+	sink.Context = taint.GetContext(state.prefix)
+	return sink, true
+}
@@ -0,0 +1,164 @@
+package pathgenerator
+
+import (
+	"testing"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/taint"
+)
+
+func newMethod(t *testing.T, class, name string, flags cfg.FuncModifFlag) *cfg.Func {
+	t.Helper()
+	fn, err := cfg.NewClassFunc(name, flags, cfg.NewOpTypeVoid(nil), cfg.NewBlock(0), *cfg.NewOperandString(class), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fn
+}
+
+func TestIsEntryPoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		class   string
+		method  string
+		flags   cfg.FuncModifFlag
+		entry   bool
+		builder bool
+	}{
+		{"controller action", "App\\Controller\\UserController", "show", cfg.FUNC_MODIF_FLAG_PUBLIC, true, true},
+		{"controller constructor", "UserController", "__construct", cfg.FUNC_MODIF_FLAG_PUBLIC, false, false},
+		{"private controller method", "UserController", "helper", cfg.FUNC_MODIF_FLAG_PRIVATE, false, false},
+		{"protected controller method", "UserController", "helper", cfg.FUNC_MODIF_FLAG_PROTECTED, false, false},
+		{"form builder", "App\\Form\\UserForm", "buildForm", cfg.FUNC_MODIF_FLAG_PUBLIC, false, true},
+		{"service method", "UserService", "show", cfg.FUNC_MODIF_FLAG_PUBLIC, false, false},
+	}
+	config := NewResponseConfig()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := newMethod(t, tt.class, tt.method, tt.flags)
+			if got := config.isEntryPoint(fn); got != tt.entry {
+				t.Errorf("isEntryPoint() = %v, want %v", got, tt.entry)
+			}
+			if got := config.isRenderBuilder(fn); got != tt.builder {
+				t.Errorf("isRenderBuilder() = %v, want %v", got, tt.builder)
+			}
+		})
+	}
+}
+
+func TestResponseConfig(t *testing.T) {
+	config := NewResponseConfig()
+	config.AddEntryPoint("*Handler::handle")
+	config.AddResponseClass("App\\Http\\XmlResponse", "Application/XML")
+	config.AddContentSetter("setBody")
+	config.AddRenderBuilder("*::view")
+
+	if !config.isEntryPoint(newMethod(t, "App\\UserHandler", "handle", cfg.FUNC_MODIF_FLAG_PUBLIC)) {
+		t.Errorf("added entry point doesn't match")
+	}
+	if !config.isRenderBuilder(newMethod(t, "UserBlock", "view", cfg.FUNC_MODIF_FLAG_PUBLIC)) {
+		t.Errorf("added render builder doesn't match")
+	}
+	if config.ResponseClasses["xmlresponse"] != "application/xml" {
+		t.Errorf("ResponseClasses[xmlresponse] = %q, want application/xml", config.ResponseClasses["xmlresponse"])
+	}
+	if !config.isContentSetter("SETBODY") || config.isContentSetter("setStatusCode") {
+		t.Errorf("isContentSetter() doesn't match the added setter only")
+	}
+}
+
+func TestGetResponseSink(t *testing.T) {
+	tainted := cfg.NewTemporaryOperand(nil)
+	newResponse := func(class string, args ...cfg.Operand) cfg.Op {
+		return cfg.NewOpExprNew(cfg.NewOperandString(class), args, nil)
+	}
+	plainHeaders := cfg.NewOpExprArray(
+		[]cfg.Operand{cfg.NewOperandString("Content-Type")},
+		[]cfg.Operand{cfg.NewOperandString("text/plain")},
+		make([]bool, 1), nil).Result
+	htmlHeaders := cfg.NewOpExprArray(
+		[]cfg.Operand{cfg.NewOperandString("Content-Type")},
+		[]cfg.Operand{cfg.NewOperandString("text/html; charset=UTF-8")},
+		make([]bool, 1), nil).Result
+	response := cfg.NewOperandVariable(cfg.NewOperandString("$response"), nil)
+
+	entryReturn := cfg.NewOpReturn(tainted, nil)
+	jsonResponse := cfg.NewOperandObject("JsonResponse")
+	responseReturn := cfg.NewOpReturn(jsonResponse, nil)
+	action := newMethod(t, "UserController", "show", cfg.FUNC_MODIF_FLAG_PUBLIC)
+	action.CFGBlock.AddInstructions(entryReturn)
+	action.CFGBlock.AddInstructions(responseReturn)
+	script := cfg.NewScript(action, "/app/UserController.php")
+	script.AddFunc(action)
+
+	tests := []struct {
+		name        string
+		op          cfg.Op
+		wantTarget  string
+		wantHandler string
+	}{
+		{"entry point return", entryReturn, "return value", "UserController::show"},
+		{"other return", cfg.NewOpReturn(tainted, nil), "", ""},
+		{"new Response", newResponse("Symfony\\Component\\HttpFoundation\\Response", tainted), "new Response", "helper"},
+		{"new JsonResponse", newResponse("JsonResponse", tainted), "", ""},
+		{"Response status", newResponse("Response", cfg.NewOperandString(""), tainted), "", ""},
+		{"plain text Response", newResponse("Response", tainted, cfg.NewOperandNumber(200), plainHeaders), "", ""},
+		{"html Response", newResponse("Response", tainted, cfg.NewOperandNumber(200), htmlHeaders), "new Response", "helper"},
+		{"other class", newResponse("Logger", tainted), "", ""},
+		{"setContent", newMethodCall(response, "setContent", tainted), "setContent()", "helper"},
+		{"setStatusCode", newMethodCall(response, "setStatusCode", tainted), "", ""},
+	}
+	pg := NewPathGenerator()
+	pg.currFuncName = "helper"
+	pg.entryReturns = findEntryPointReturns(map[string]*cfg.Script{"/app/UserController.php": script}, pg.response)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink, ok := pg.getResponseSink(tt.op, tainted, taintState{prefix: "<div>"})
+			if ok != (tt.wantTarget != "") || sink.Target != tt.wantTarget {
+				t.Fatalf("getResponseSink() = %q, %v, want %q", sink.Target, ok, tt.wantTarget)
+			}
+			if !ok {
+				return
+			}
+			if sink.Rule != taint.RULE_XSS || sink.Handler != tt.wantHandler {
+				t.Errorf("sink is %s of %q, want %s of %q", sink.Rule, sink.Handler, taint.RULE_XSS, tt.wantHandler)
+			}
+		})
+	}
+
+	// body of the returned response is checked when it is created
+	if sink, ok := pg.getResponseSink(responseReturn, jsonResponse, taintState{}); ok {
+		t.Errorf("getResponseSink() of returned JsonResponse = %q, want no sink", sink.Target)
+	}
+}
+
+func TestIsViewCall(t *testing.T) {
+	data := cfg.NewTemporaryOperand(nil)
+	view := newCall("view", cfg.NewOperandString("profile"), data)
+	assigned := cfg.NewOperandVariable(cfg.NewOperandString("$view"), nil)
+	cfg.NewOpExprAssign(assigned, view.Result, nil, nil, nil)
+	this := cfg.NewOperandVariable(cfg.NewOperandString("$this"), nil)
+	tests := []struct {
+		name string
+		op   cfg.Op
+		want bool
+	}{
+		{"view()", view, true},
+		{"View::make()", newStaticCall("Illuminate\\Support\\Facades\\View", "make", cfg.NewOperandString("profile"), data), true},
+		{"with() chain", newMethodCall(newMethodCall(view.Result, "with", data).Result, "with", data), true},
+		{"assigned view", assigned.GetWriter(), true},
+		{"other function", newCall("render", cfg.NewOperandString("profile"), data), false},
+		{"other static method", newStaticCall("View", "exists", cfg.NewOperandString("profile")), false},
+		{"other method chain", newMethodCall(view.Result, "toHtml"), false},
+		{"Symfony render()", newMethodCall(this, "render", cfg.NewOperandString("profile.html.twig"), data), true},
+		{"Twig renderView()", newMethodCall(this, "renderView", cfg.NewOperandString("profile.html.twig"), data), true},
+		{"render() without template", newMethodCall(this, "render"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isViewCall(tt.op); got != tt.want {
+				t.Errorf("isViewCall() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
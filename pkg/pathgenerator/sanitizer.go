@@ -0,0 +1,221 @@
+package pathgenerator
+
+import (
+	"strings"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/cfgtraverser"
+	"github.com/rxhunter00/XSS-Taint/pkg/rules"
+	"github.com/rxhunter00/XSS-Taint/pkg/taint"
+)
+
+// User function which every return value is a sanitized parameter,
+// such as function e($s) { return htmlspecialchars($s, ENT_QUOTES); }
+type Sanitizer struct {
+	Func   *cfg.Func
+	Script *cfg.Script
+	// Sanitizing ops which result is returned
+	Wraps []cfg.Op
+}
+
+type sanitizerSet struct {
+	funcs   map[string]*Sanitizer  // lowercased scoped name
+	methods map[string][]*cfg.Func // all methods of each lowercased name
+	byFunc  map[*cfg.Func]*Sanitizer
+}
+
+func newSanitizerSet(scripts map[string]*cfg.Script) *sanitizerSet {
+	set := &sanitizerSet{
+		funcs:   make(map[string]*Sanitizer),
+		methods: make(map[string][]*cfg.Func),
+		byFunc:  make(map[*cfg.Func]*Sanitizer),
+	}
+	for _, script := range scripts {
+		for _, fn := range script.FuncsMap {
+			if fn.FunctionClass != nil {
+				name := strings.ToLower(fn.Name)
+				set.methods[name] = append(set.methods[name], fn)
+			}
+		}
+	}
+	return set
+}
+
+func (set *sanitizerSet) add(sanitizer *Sanitizer) {
+	set.funcs[strings.ToLower(sanitizer.Func.GetScopedName())] = sanitizer
+	set.byFunc[sanitizer.Func] = sanitizer
+}
+
+func (set *sanitizerSet) lookupFunc(name string) (*Sanitizer, bool) {
+	name = strings.ToLower(strings.TrimPrefix(name, "\\"))
+	if sanitizer, ok := set.funcs[name]; ok {
+		return sanitizer, true
+	}
+	// namespaced call can be declared without namespace
+	if i := strings.LastIndex(name, "\\"); i >= 0 {
+		sanitizer, ok := set.funcs[name[i+1:]]
+		return sanitizer, ok
+	}
+	return nil, false
+}
+
+// Method called on unknown class is a sanitizer if every method with the name is
+func (set *sanitizerSet) lookupMethod(name string) (*Sanitizer, bool) {
+	fns := set.methods[strings.ToLower(name)]
+	if len(fns) == 0 {
+		return nil, false
+	}
+	for _, fn := range fns {
+		if _, ok := set.byFunc[fn]; !ok {
+			return nil, false
+		}
+	}
+	return set.byFunc[fns[0]], true
+}
+
+// InferSanitizers find user functions and methods that wrap a sanitizer
+func InferSanitizers(scripts map[string]*cfg.Script, ruleSet *rules.RuleSet) []*Sanitizer {
+	pg := NewPathGenerator()
+	pg.ruleSet = ruleSet
+	pg.sanitizers = newSanitizerSet(scripts)
+
+	sanitizers := make([]*Sanitizer, 0)
+	// wrapper of inferred sanitizer is also sanitizer
+	for found := true; found; {
+		found = false
+		for _, script := range scripts {
+			for _, fn := range script.FuncsMap {
+				if _, ok := pg.sanitizers.byFunc[fn]; ok {
+					continue
+				}
+				if wraps, ok := pg.inferSanitizer(fn); ok {
+					sanitizer := &Sanitizer{Func: fn, Script: script, Wraps: wraps}
+					pg.sanitizers.add(sanitizer)
+					sanitizers = append(sanitizers, sanitizer)
+					found = true
+				}
+			}
+		}
+	}
+	return sanitizers
+}
+
+// Check if every return of fn is a sanitized parameter or a literal
+func (pg *PathGenerator) inferSanitizer(fn *cfg.Func) ([]cfg.Op, bool) {
+	collector := &returnCollector{}
+	traverser := cfgtraverser.NewTraverser()
+	traverser.AddBlockTraverser(collector)
+	traverser.TraverseFunc(fn)
+
+	wraps := make([]cfg.Op, 0)
+	for _, ret := range collector.returns {
+		if ret.Expr == nil {
+			continue
+		}
+		retWraps, ok := pg.getSanitizedValue(ret.Expr, make(map[cfg.Operand]struct{}))
+		if !ok {
+			return nil, false
+		}
+		wraps = append(wraps, retWraps...)
+	}
+	return wraps, len(wraps) > 0
+}
+
+// Get ops sanitizing parameter which produce the value of oper
+func (pg *PathGenerator) getSanitizedValue(oper cfg.Operand, visited map[cfg.Operand]struct{}) ([]cfg.Op, bool) {
+	if _, ok := visited[oper]; ok {
+		return nil, true
+	}
+	visited[oper] = struct{}{}
+
+	switch writer := oper.GetWriter().(type) {
+	case nil:
+		switch oper.(type) {
+		case *cfg.OperandString, *cfg.OperandNumber, *cfg.OperandBool, *cfg.OperandNull:
+			return nil, true
+		}
+		return nil, false
+	case *cfg.OpExprAssign:
+		return pg.getSanitizedValue(writer.Expr, visited)
+	case *cfg.OpPhi:
+		wraps := make([]cfg.Op, 0)
+		for _, phiOper := range writer.GetPhiOperands() {
+			phiWraps, ok := pg.getSanitizedValue(phiOper, visited)
+			if !ok {
+				return nil, false
+			}
+			wraps = append(wraps, phiWraps...)
+		}
+		return wraps, true
+	case *cfg.OpExprArrayDimFetch:
+		// fetching with parameter as key doesn't sanitize the value
+		return nil, false
+	default:
+		for _, input := range getInputVars(writer) {
+			if !isParamDerived(input, make(map[cfg.Operand]struct{})) {
+				continue
+			}
+			if labels, ok := pg.transformLabels(writer, input, taint.LABELS_RAW); !ok || labels != taint.LABELS_RAW {
+				return []cfg.Op{writer}, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Check if the value of oper come from function parameter
+func isParamDerived(oper cfg.Operand, visited map[cfg.Operand]struct{}) bool {
+	if _, ok := visited[oper]; ok {
+		return false
+	}
+	visited[oper] = struct{}{}
+
+	switch writer := oper.GetWriter().(type) {
+	case nil:
+		return false
+	case *cfg.OpExprParam:
+		return true
+	case *cfg.OpPhi:
+		for _, phiOper := range writer.GetPhiOperands() {
+			if isParamDerived(phiOper, visited) {
+				return true
+			}
+		}
+	default:
+		for _, input := range getInputVars(writer) {
+			if isParamDerived(input, visited) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func getInputVars(op cfg.Op) []cfg.Operand {
+	inputs := make([]cfg.Operand, 0)
+	for vrName, vr := range op.GetOpVars() {
+		if vr != nil && !cfg.IsWriteVar(op, vrName) {
+			inputs = append(inputs, vr)
+		}
+	}
+	for _, vrList := range op.GetOpListVars() {
+		for _, vr := range vrList {
+			if vr != nil {
+				inputs = append(inputs, vr)
+			}
+		}
+	}
+	return inputs
+}
+
+type returnCollector struct {
+	cfgtraverser.NullTraverser
+
+	returns []*cfg.OpReturn
+}
+
+func (c *returnCollector) EnterOp(op cfg.Op, block *cfg.Block) {
+	if ret, ok := op.(*cfg.OpReturn); ok {
+		c.returns = append(c.returns, ret)
+	}
+}
@@ -0,0 +1,116 @@
+package pathgenerator
+
+import (
+	"testing"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/rules"
+	"github.com/rxhunter00/XSS-Taint/pkg/taint"
+)
+
+// Function with parameter $s which body is built by body from the parameter value
+func newParamFunc(t *testing.T, name string, body func(block *cfg.Block, param cfg.Operand)) *cfg.Func {
+	t.Helper()
+	fn, err := cfg.NewFunc(name, cfg.FUNC_MODIF_FLAG_PUBLIC, cfg.NewOpTypeVoid(nil), cfg.NewBlock(0), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	param := cfg.NewOpExprParam(cfg.NewOperandString("$s"), false, false, nil, nil, nil, nil, nil)
+	fn.Params = append(fn.Params, param)
+	fn.CFGBlock.AddInstructions(param)
+	body(fn.CFGBlock, param.Result)
+	return fn
+}
+
+func returnCall(funcName string) func(*cfg.Block, cfg.Operand) {
+	return func(block *cfg.Block, param cfg.Operand) {
+		call := newCall(funcName, param)
+		block.AddInstructions(call)
+		block.AddInstructions(cfg.NewOpReturn(call.Result, nil))
+	}
+}
+
+func TestInferSanitizers(t *testing.T) {
+	tests := []struct {
+		name string
+		body func(*cfg.Block, cfg.Operand)
+		// labels of the value returned for raw argument, raw if not a sanitizer
+		want taint.Labels
+	}{
+		{"escape wrapper", returnCall("htmlspecialchars"), taint.LABEL_HTML_ESCAPED},
+		{"wrapper of wrapper", returnCall("e"), taint.LABEL_HTML_ESCAPED},
+		{"pass through", func(block *cfg.Block, param cfg.Operand) {
+			block.AddInstructions(cfg.NewOpReturn(param, nil))
+		}, taint.LABELS_RAW},
+		{"literal only", func(block *cfg.Block, param cfg.Operand) {
+			block.AddInstructions(cfg.NewOpReturn(cfg.NewOperandString(""), nil))
+		}, taint.LABELS_RAW},
+		{"one raw return", func(block *cfg.Block, param cfg.Operand) {
+			call := newCall("htmlspecialchars", param)
+			block.AddInstructions(call)
+			block.AddInstructions(cfg.NewOpReturn(call.Result, nil))
+			block.AddInstructions(cfg.NewOpReturn(param, nil))
+		}, taint.LABELS_RAW},
+		{"not a sanitizer", returnCall("trim"), taint.LABELS_RAW},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newParamFunc(t, "e", returnCall("htmlspecialchars"))
+			fn := newParamFunc(t, "wrap", tt.body)
+			script := cfg.NewScript(e, "/app/helpers.php")
+			script.AddFunc(e)
+			script.AddFunc(fn)
+
+			ruleSet := rules.Default()
+			sanitizers := InferSanitizers(map[string]*cfg.Script{"/app/helpers.php": script}, ruleSet)
+			var inferred *Sanitizer
+			for _, sanitizer := range sanitizers {
+				if sanitizer.Func == fn {
+					inferred = sanitizer
+				}
+			}
+			if tt.want == taint.LABELS_RAW {
+				if inferred != nil {
+					t.Errorf("wrap is inferred as sanitizer")
+				}
+				return
+			}
+			if inferred == nil {
+				t.Fatalf("wrap is not inferred as sanitizer")
+			}
+			pg := NewPathGenerator()
+			pg.ruleSet = ruleSet
+			pg.sanitizers = newSanitizerSet(nil)
+			for _, sanitizer := range sanitizers {
+				pg.sanitizers.add(sanitizer)
+			}
+			if got := pg.applySanitizer(inferred, taint.LABELS_RAW); got != tt.want {
+				t.Errorf("labels = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplySanitizer(t *testing.T) {
+	arg := cfg.NewTemporaryOperand(nil)
+	tests := []struct {
+		name  string
+		wraps []cfg.Op
+		want  taint.Labels
+	}{
+		{"escape", []cfg.Op{newCall("htmlspecialchars", arg)}, taint.LABEL_HTML_ESCAPED},
+		{"escape and trim", []cfg.Op{newCall("htmlspecialchars", arg), newCall("trim", arg)}, taint.LABELS_RAW},
+		{"wrap without the argument", []cfg.Op{cfg.NewOpExprValid(arg, nil), newCall("htmlspecialchars", arg)}, taint.LABEL_HTML_ESCAPED},
+		{"no wrap with the argument", []cfg.Op{cfg.NewOpExprValid(arg, nil)}, taint.LABELS_RAW},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pg := NewPathGenerator()
+			pg.ruleSet = rules.Default()
+			pg.sanitizers = newSanitizerSet(nil)
+			if got := pg.applySanitizer(&Sanitizer{Wraps: tt.wraps}, taint.LABELS_RAW); got != tt.want {
+				t.Errorf("labels = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
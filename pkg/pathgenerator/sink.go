@@ -0,0 +1,381 @@
+package pathgenerator
+
+import (
+	"strings"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/cfgtraverser"
+	"github.com/rxhunter00/XSS-Taint/pkg/rules"
+	"github.com/rxhunter00/XSS-Taint/pkg/taint"
+)
+
+// Sink reached by the tainted value
+type Sink struct {
+	Rule taint.RuleID
+	// Html context of XSS sink
+	Context taint.Context
+	// Constant start of the redirect URL, header value, SQL query or include path before the tainted value
+	Prefix string
+	// What the tainted value control, such as Location header
+	Target string
+	// Entry point producing the response, set for response sinks
+	Handler string
+	// Labels added by the sink before the output, such as esc_html_e
+	Escapes taint.Labels
+}
+
+type sinkFinder func(pg *PathGenerator, op cfg.Op, taintedVar cfg.Operand, state taintState) (Sink, bool)
+
+// Sink finders of the rule sets
+var sinkFinders = []sinkFinder{
+	(*PathGenerator).getXSSSink,
+	(*PathGenerator).getEmailSink,
+	(*PathGenerator).getHTTPSink,
+	(*PathGenerator).getInjectionSink,
+	(*PathGenerator).getRenderSink,
+	(*PathGenerator).getRuleSink,
+}
+
+// Get the sink of op, false if op is not a sink of enabled rule for the tainted value
+func (pg *PathGenerator) getSink(op cfg.Op, taintedVar cfg.Operand, state taintState) (Sink, bool) {
+	for _, find := range sinkFinders {
+		if sink, ok := find(pg, op, taintedVar, state); ok && pg.isRuleEnabled(sink.Rule) {
+			return sink, true
+		}
+	}
+	return Sink{}, false
+}
+
+// Get sink of the call in the rule files
+func (pg *PathGenerator) getRuleSink(op cfg.Op, taintedVar cfg.Operand, state taintState) (Sink, bool) {
+	call, ok := rules.GetCall(op)
+	if !ok {
+		return Sink{}, false
+	}
+	ruleSink, ok := pg.ruleSet.GetSink(call, taintedVar)
+	if !ok {
+		return Sink{}, false
+	}
+	sink := Sink{Rule: ruleSink.Rule, Prefix: state.prefix, Target: call.String(), Escapes: ruleSink.GetAddedLabels()}
+	if sink.Rule.IsHTML() {
+		htmlPrefix := ""
+		if callOp, ok := op.(*cfg.OpExprFunctionCall); ok {
+			// function echoing its argument, such as _e
+			htmlPrefix = callOp.HTMLPrefix
+		}
+		sink.Context = taint.GetContext(htmlPrefix + state.prefix)
+	}
+	return sink, true
+}
+
+func (pg *PathGenerator) getXSSSink(op cfg.Op, taintedVar cfg.Operand, state taintState) (Sink, bool) {
+	if context, ok := pg.getSinkContext(op, taintedVar, state); ok {
+		return Sink{Rule: taint.RULE_XSS, Context: context, Target: "html output"}, true
+	}
+	return pg.getResponseSink(op, taintedVar, state)
+}
+
+// Get open redirect or header injection sink of op
+func (pg *PathGenerator) getHTTPSink(op cfg.Op, taintedVar cfg.Operand, state taintState) (Sink, bool) {
+	switch opT := op.(type) {
+	case *cfg.OpExprFunctionCall:
+		funcNameStr, _ := cfg.GetOperandName(opT.Name)
+		funcNameStr = strings.ToLower(strings.TrimPrefix(funcNameStr, "\\"))
+		switch funcNameStr {
+		case "header":
+			if isArg(opT.Args, 0, taintedVar) {
+				return getHeaderSink(state.prefix), true
+			}
+		case "setcookie", "setrawcookie":
+			if isArg(opT.Args, 0, taintedVar) {
+				return Sink{Rule: taint.RULE_HEADER_INJECTION, Target: "cookie name"}, true
+			} else if isArg(opT.Args, 1, taintedVar) {
+				return Sink{Rule: taint.RULE_HEADER_INJECTION, Target: "cookie value"}, true
+			}
+		case "redirect", "wp_redirect":
+			if isArg(opT.Args, 0, taintedVar) {
+				return Sink{Rule: taint.RULE_OPEN_REDIRECT, Target: funcNameStr + "()"}, true
+			}
+		}
+	case *cfg.OpExprMethodCall:
+		methodNameStr, err := cfg.GetOperandName(opT.Name)
+		if err != nil || !isArg(opT.Args, 0, taintedVar) {
+			break
+		}
+		switch strings.ToLower(methodNameStr) {
+		case "redirect":
+			return Sink{Rule: taint.RULE_OPEN_REDIRECT, Target: "redirect()"}, true
+		case "to", "away":
+			// redirect()->to($url)
+			if isRedirector(opT.Var) {
+				return Sink{Rule: taint.RULE_OPEN_REDIRECT, Target: "redirect()->" + methodNameStr + "()"}, true
+			}
+		}
+	case *cfg.OpExprStaticCall:
+		classNameStr, err := cfg.GetOperandName(opT.Class)
+		if err != nil || !isArg(opT.Args, 0, taintedVar) {
+			break
+		}
+		methodNameStr, _ := cfg.GetOperandName(opT.Name)
+		switch strings.ToLower(methodNameStr) {
+		case "to", "away":
+			// Redirect::to($url)
+			if strings.EqualFold(getShortName(classNameStr), "redirect") {
+				return Sink{Rule: taint.RULE_OPEN_REDIRECT, Target: "Redirect::" + methodNameStr + "()"}, true
+			}
+		}
+	case *cfg.OpExprNew:
+		classNameStr, err := cfg.GetOperandName(opT.Class)
+		if err == nil && isArg(opT.Args, 0, taintedVar) && strings.EqualFold(getShortName(classNameStr), "RedirectResponse") {
+			return Sink{Rule: taint.RULE_OPEN_REDIRECT, Target: "RedirectResponse"}, true
+		}
+	}
+	return Sink{}, false
+}
+
+// Header line before the tainted value decide what the value control
+func getHeaderSink(prefix string) Sink {
+	i := strings.IndexByte(prefix, ':')
+	if i < 0 {
+		return Sink{Rule: taint.RULE_HEADER_INJECTION, Target: "header name"}
+	}
+	name := strings.TrimSpace(prefix[:i])
+	value := strings.TrimLeft(prefix[i+1:], " \t")
+	switch strings.ToLower(name) {
+	case "location":
+		return Sink{Rule: taint.RULE_OPEN_REDIRECT, Prefix: value, Target: "Location header"}
+	case "refresh":
+		// Refresh: 0; url=https://example.com/
+		url := ""
+		if j := strings.Index(strings.ToLower(value), "url="); j >= 0 {
+			url = strings.TrimLeft(value[j+len("url="):], "'\"")
+		}
+		return Sink{Rule: taint.RULE_OPEN_REDIRECT, Prefix: url, Target: "Refresh header"}
+	}
+	return Sink{Rule: taint.RULE_HEADER_INJECTION, Prefix: value, Target: name + " header"}
+}
+
+// Check if the sink accept value with the labels
+func (sink Sink) Accepts(labels taint.Labels) bool {
+	switch sink.Rule {
+	case taint.RULE_OPEN_REDIRECT:
+		return taint.AcceptsRedirect(labels, sink.Prefix)
+	case taint.RULE_HEADER_INJECTION:
+		return taint.AcceptsHeader(labels)
+	case taint.RULE_SQL_INJECTION:
+		return taint.AcceptsSQL(labels, sink.Prefix)
+	case taint.RULE_COMMAND_INJECTION:
+		return taint.AcceptsCommand(labels)
+	case taint.RULE_CODE_INJECTION:
+		return taint.AcceptsCode(labels)
+	case taint.RULE_FILE_INCLUSION:
+		return taint.AcceptsInclude(labels, sink.Prefix)
+	}
+	return sink.Context.Accepts(labels)
+}
+
+// Get echo appending the output of the call to the active output buffer with the labels added
+// before the output, false if the call doesn't output the tainted value or is outside output buffer
+func (pg *PathGenerator) getBufferedOutput(op cfg.Op, taintedVar cfg.Operand) (*cfg.OpEcho, taint.Labels, bool) {
+	callOp, ok := op.(*cfg.OpExprFunctionCall)
+	if !ok || callOp.OutputBuffer == nil {
+		return nil, taint.LABELS_RAW, false
+	}
+	if pg.isOutputSink(op, taintedVar) {
+		return callOp.OutputBuffer, taint.LABELS_RAW, true
+	}
+	call, ok := rules.GetCall(op)
+	if !ok {
+		return nil, taint.LABELS_RAW, false
+	}
+	if sink, ok := pg.ruleSet.GetSink(call, taintedVar); ok && sink.Output {
+		return callOp.OutputBuffer, sink.GetAddedLabels(), true
+	}
+	return nil, taint.LABELS_RAW, false
+}
+
+// Check if op write the tainted value to the response body
+func (pg *PathGenerator) isOutputSink(op cfg.Op, taintedVar cfg.Operand) bool {
+	switch opT := op.(type) {
+	case *cfg.OpExit:
+		// exit with integer is the exit status and output nothing
+		if opT.Expr != taintedVar {
+			return false
+		}
+		switch cfg.GetOperVal(taintedVar).(type) {
+		case *cfg.OperandNumber, *cfg.OperandBool:
+			return false
+		}
+		return true
+	case *cfg.OpExprFunctionCall:
+		funcNameStr, _ := cfg.GetOperandName(opT.Name)
+		return pg.isOutputCall(strings.ToLower(strings.TrimPrefix(funcNameStr, "\\")), opT.Args, taintedVar)
+	}
+	return false
+}
+
+// Check if the tainted value is outputted by the builtin function at its argument position,
+// output functions without condition on the stream are in the rule files
+func (pg *PathGenerator) isOutputCall(funcNameStr string, args []cfg.Operand, taintedVar cfg.Operand) bool {
+	switch funcNameStr {
+	case "printf":
+		return isFormatOutput(args, 0, taintedVar, true)
+	case "vprintf":
+		return isFormatOutput(args, 0, taintedVar, false)
+	case "fprintf":
+		return len(args) > 0 && isOutputStream(args[0]) && isFormatOutput(args, 1, taintedVar, true)
+	case "vfprintf":
+		return len(args) > 0 && isOutputStream(args[0]) && isFormatOutput(args, 1, taintedVar, false)
+	case "fwrite", "fputs", "file_put_contents":
+		return len(args) > 0 && isOutputStream(args[0]) && isArg(args, 1, taintedVar)
+	case "trigger_error", "user_error":
+		return pg.displayErrors && isArg(args, 0, taintedVar)
+	}
+	return false
+}
+
+// Check if the tainted value is the format or an argument outputted by %s of printf like function,
+// variadic function take the arguments after the format, otherwise an array of arguments
+func isFormatOutput(args []cfg.Operand, formatPos int, taintedVar cfg.Operand, variadic bool) bool {
+	if isArg(args, formatPos, taintedVar) {
+		return true
+	}
+	if formatPos >= len(args) {
+		return false
+	}
+	isValue := isArg(args, formatPos+1, taintedVar)
+	if variadic {
+		for i := formatPos + 1; i < len(args); i++ {
+			isValue = isValue || args[i] == taintedVar
+		}
+	}
+	if !isValue {
+		return false
+	}
+	format, ok := cfg.GetConstString(args[formatPos])
+	return !ok || hasStringConversion(format)
+}
+
+// Check if printf format has %s conversion, other conversions output numbers only
+func hasStringConversion(format string) bool {
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		j := i + 1
+		if j < len(format) && format[j] == '%' {
+			i = j
+			continue
+		}
+		// argnum, flags, width and precision, such as %1$'*10.5s
+		for j < len(format) {
+			if format[j] == '\'' {
+				// custom padding character
+				j += 2
+			} else if strings.IndexByte("0123456789$-+ .", format[j]) >= 0 {
+				j++
+			} else {
+				break
+			}
+		}
+		if j < len(format) && format[j] == 's' {
+			return true
+		}
+		i = j
+	}
+	return false
+}
+
+// Check if oper is a stream writing to the response, such as fopen('php://output', 'w'),
+// STDOUT and php://stdout write to the process output which is the response only in CGI
+func isOutputStream(oper cfg.Operand) bool {
+	if str, ok := cfg.GetConstString(oper); ok {
+		return isOutputURL(str)
+	}
+	for {
+		switch writer := oper.GetWriter().(type) {
+		case *cfg.OpExprAssign:
+			oper = writer.Expr
+			continue
+		case *cfg.OpExprFunctionCall:
+			funcNameStr, _ := cfg.GetOperandName(writer.Name)
+			if !strings.EqualFold(strings.TrimPrefix(funcNameStr, "\\"), "fopen") || len(writer.Args) == 0 {
+				return false
+			}
+			str, ok := cfg.GetConstString(writer.Args[0])
+			return ok && isOutputURL(str)
+		}
+		return false
+	}
+}
+
+func isOutputURL(url string) bool {
+	url = strings.ToLower(strings.TrimSpace(url))
+	return url == "php://output"
+}
+
+// Check if argument i is a constant true value
+func isTrueArg(args []cfg.Operand, i int) bool {
+	if i >= len(args) {
+		return false
+	}
+	switch val := cfg.GetOperVal(args[i]).(type) {
+	case *cfg.OperandBool:
+		return val.Val
+	case *cfg.OperandNumber:
+		return val.Val != 0
+	}
+	return false
+}
+
+// Check if display_errors is turned on by ini_set in any script
+func isDisplayErrorsOn(scripts map[string]*cfg.Script) bool {
+	for _, script := range scripts {
+		for _, fn := range cfgtraverser.GetFuncs(script) {
+			for _, call := range fn.Calls {
+				callOp, ok := call.(*cfg.OpExprFunctionCall)
+				if !ok || len(callOp.Args) < 2 {
+					continue
+				}
+				funcNameStr, _ := cfg.GetOperandName(callOp.Name)
+				if !strings.EqualFold(strings.TrimPrefix(funcNameStr, "\\"), "ini_set") {
+					continue
+				}
+				name, _ := cfg.GetConstString(callOp.Args[0])
+				if name != "display_errors" {
+					continue
+				}
+				if isTrueArg(callOp.Args, 1) {
+					return true
+				}
+				switch value, _ := cfg.GetConstString(callOp.Args[1]); strings.ToLower(value) {
+				case "1", "on", "true", "yes", "stdout":
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Check if oper is the redirector returned by redirect()
+func isRedirector(oper cfg.Operand) bool {
+	callOp, ok := oper.GetWriter().(*cfg.OpExprFunctionCall)
+	if !ok {
+		return false
+	}
+	funcNameStr, _ := cfg.GetOperandName(callOp.Name)
+	return strings.EqualFold(getShortName(funcNameStr), "redirect")
+}
+
+func isArg(args []cfg.Operand, i int, oper cfg.Operand) bool {
+	return i < len(args) && args[i] == oper
+}
+
+// Get name without namespace
+func getShortName(name string) string {
+	if i := strings.LastIndex(name, "\\"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,187 @@
+package pathgenerator
+
+import (
+	"testing"
+
+	"github.com/VKCOM/php-parser/pkg/position"
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/rules"
+	"github.com/rxhunter00/XSS-Taint/pkg/taint"
+)
+
+func newCall(name string, args ...cfg.Operand) *cfg.OpExprFunctionCall {
+	return cfg.NewOpExprFunctionCall(cfg.NewOperandString(name), args, nil, make([]*position.Position, len(args)), nil)
+}
+
+func TestGetBufferedOutput(t *testing.T) {
+	tainted := cfg.NewTemporaryOperand(nil)
+	tests := []struct {
+		name        string
+		call        *cfg.OpExprFunctionCall
+		buffered    bool
+		want        bool
+		wantEscapes taint.Labels
+	}{
+		{name: "printf inside buffer", call: newCall("printf", tainted), buffered: true, want: true},
+		{name: "printf outside buffer", call: newCall("printf", tainted)},
+		{name: "print_r inside buffer", call: newCall("print_r", tainted), buffered: true, want: true},
+		{name: "print_r returning the output", call: newCall("print_r", tainted, cfg.NewOperandBool(true)), buffered: true},
+		{
+			name:        "escaping output inside buffer",
+			call:        newCall("esc_html_e", tainted),
+			buffered:    true,
+			want:        true,
+			wantEscapes: taint.LABEL_HTML_ESCAPED,
+		},
+		{name: "non output function", call: newCall("sprintf", tainted), buffered: true},
+	}
+	pg := NewPathGenerator()
+	pg.ruleSet = rules.Default()
+	file, err := rules.Load([]byte(`{"sinks": [{"function": "esc_html_e", "rule": "xss", "args": [0], "add": ["html-escaped"], "output": true}]}`), "test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pg.ruleSet.Add(file)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.buffered {
+				tt.call.OutputBuffer = cfg.NewOpEchoBuffered(cfg.NewTemporaryOperand(nil), cfg.NewOperandString(""), nil)
+			}
+			echo, escapes, ok := pg.getBufferedOutput(tt.call, tainted)
+			if ok != tt.want {
+				t.Fatalf("getBufferedOutput() ok = %v, want %v", ok, tt.want)
+			}
+			if !ok {
+				return
+			}
+			if echo != tt.call.OutputBuffer {
+				t.Errorf("output doesn't go to the buffer of the call")
+			}
+			if escapes != tt.wantEscapes {
+				t.Errorf("escapes = %s, want %s", escapes, tt.wantEscapes)
+			}
+		})
+	}
+}
+
+func TestTraceBufferedOutput(t *testing.T) {
+	tainted := cfg.NewTemporaryOperand(nil)
+	call := newCall("printf", tainted)
+	call.OutputBuffer = cfg.NewOpEchoBuffered(cfg.NewTemporaryOperand(nil), cfg.NewOperandString(""), nil)
+	call.OutputBuffer.HTMLPrefix = "<p>"
+	// buffer content echoed after ob_get_clean()
+	echo := cfg.NewOpEcho(call.OutputBuffer.Result, nil)
+
+	pg := NewPathGenerator()
+	pg.ruleSet = rules.Default()
+	pg.visited = make(map[cfg.Op]map[cfg.Operand]map[string]struct{})
+	pg.currPath = []cfg.Op{call}
+	if err := pg.traceTaintFlow(call, tainted, taintState{labels: taint.LABELS_RAW}); err != nil {
+		t.Fatal(err)
+	}
+	if len(pg.detectedPaths) != 1 {
+		t.Fatalf("got %d paths, want 1", len(pg.detectedPaths))
+	}
+	path := pg.detectedPaths[0]
+	if sinkOp := path.Ops[len(path.Ops)-1]; sinkOp != echo {
+		t.Errorf("sink is %T, want the echo of the buffer", sinkOp)
+	}
+	throughBuffer := false
+	for _, op := range path.Ops {
+		throughBuffer = throughBuffer || op == call.OutputBuffer
+	}
+	if !throughBuffer {
+		t.Errorf("path doesn't go through the buffered output of printf")
+	}
+}
+
+func TestGetSinkContentTypes(t *testing.T) {
+	phiOf := func(opers ...cfg.Operand) cfg.Operand {
+		result := cfg.NewTemporaryOperand(nil)
+		phi := cfg.NewOpPhi(result, cfg.NewBlock(0), nil)
+		for _, oper := range opers {
+			phi.AddOperandtoPhi(oper)
+		}
+		return result
+	}
+	tests := []struct {
+		name        string
+		contentType cfg.Operand
+		want        []string
+	}{
+		{"never set", nil, nil},
+		{"json", cfg.NewOperandString("application/json"), []string{"application/json"}},
+		{"non constant", cfg.NewOperandString(""), []string{taint.CONTENT_TYPE_UNKNOWN}},
+		{
+			"set on one branch",
+			phiOf(cfg.NewOperandString("text/plain"), cfg.NewOperandString("application/json")),
+			[]string{"application/json", "text/plain"},
+		},
+		{"not set before the function", phiOf(), []string{taint.CONTENT_TYPE_DEFAULT}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			echo := cfg.NewOpEcho(cfg.NewTemporaryOperand(nil), nil)
+			echo.ContentType = tt.contentType
+			got := getSinkContentTypes(echo)
+			if len(got) != len(tt.want) {
+				t.Fatalf("getSinkContentTypes() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("getSinkContentTypes()[%d] = %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGetHeaderSink(t *testing.T) {
+	tests := []struct {
+		prefix     string
+		wantRule   taint.RuleID
+		wantPrefix string
+		wantTarget string
+	}{
+		{"", taint.RULE_HEADER_INJECTION, "", "header name"},
+		{"Location: ", taint.RULE_OPEN_REDIRECT, "", "Location header"},
+		{"location:/profile?id=", taint.RULE_OPEN_REDIRECT, "/profile?id=", "Location header"},
+		{"Refresh: 0; url=", taint.RULE_OPEN_REDIRECT, "", "Refresh header"},
+		{"Refresh: 0; URL='/home?", taint.RULE_OPEN_REDIRECT, "/home?", "Refresh header"},
+		{"X-User: ", taint.RULE_HEADER_INJECTION, "", "X-User header"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			sink := getHeaderSink(tt.prefix)
+			if sink.Rule != tt.wantRule || sink.Prefix != tt.wantPrefix || sink.Target != tt.wantTarget {
+				t.Errorf("getHeaderSink() = %s %q %q, want %s %q %q", sink.Rule, sink.Prefix, sink.Target, tt.wantRule, tt.wantPrefix, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestGetHTTPSink(t *testing.T) {
+	tainted := cfg.NewTemporaryOperand(nil)
+	tests := []struct {
+		name     string
+		op       cfg.Op
+		wantRule taint.RuleID
+	}{
+		{"header", newCall("header", tainted), taint.RULE_HEADER_INJECTION},
+		{"cookie value", newCall("setcookie", cfg.NewOperandString("name"), tainted), taint.RULE_HEADER_INJECTION},
+		{"cookie expiry", newCall("setcookie", cfg.NewOperandString("name"), cfg.NewOperandString(""), tainted), ""},
+		{"wp_redirect", newCall("wp_redirect", tainted), taint.RULE_OPEN_REDIRECT},
+		{"redirect response", cfg.NewOpExprNew(cfg.NewOperandString("RedirectResponse"), []cfg.Operand{tainted}, nil), taint.RULE_OPEN_REDIRECT},
+		{"other response", cfg.NewOpExprNew(cfg.NewOperandString("Response"), []cfg.Operand{tainted}, nil), ""},
+	}
+	pg := NewPathGenerator()
+	pg.ruleSet = rules.Default()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink, ok := pg.getHTTPSink(tt.op, tainted, taintState{})
+			if ok != (tt.wantRule != "") || sink.Rule != tt.wantRule {
+				t.Errorf("getHTTPSink() = %s, %v, want %s", sink.Rule, ok, tt.wantRule)
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/taint"
+)
+
+// Psalm taint annotation in doc comment, such as
+//
+//	@psalm-taint-source input
+//	@psalm-taint-sink html $content
+//	@psalm-taint-escape html
+//	@psalm-taint-specialize
+var annotationPattern = regexp.MustCompile(`@psalm-taint-(source|sink|escape|unescape|specialize)\b[ \t]*([^\r\n]*)`)
+
+// Rule of each Psalm sink kind
+var sinkKindRules = map[string]taint.RuleID{
+	"html":       taint.RULE_XSS,
+	"has_quotes": taint.RULE_XSS,
+	"sql":        taint.RULE_SQL_INJECTION,
+	"shell":      taint.RULE_COMMAND_INJECTION,
+	"eval":       taint.RULE_CODE_INJECTION,
+	"include":    taint.RULE_FILE_INCLUSION,
+	"header":     taint.RULE_HEADER_INJECTION,
+	"cookie":     taint.RULE_HEADER_INJECTION,
+}
+
+// Labels of each Psalm escape kind, html escapes the tags and has_quotes the quotes,
+// the labels of both are given by getEscapeLabels
+var escapeKindLabels = map[string][]string{
+	"html":       nil,
+	"has_quotes": nil,
+	"sql":        {"sql-escaped", "sql-quoted"},
+	"shell":      {"shell-arg-escaped"},
+	"header":     {"crlf-stripped"},
+	"cookie":     {"crlf-stripped"},
+	"include":    {"basename"},
+}
+
+// Attributes equivalent to the Psalm annotations, matched by short name
+const (
+	ATTR_TAINT_SOURCE = "taintsource"
+	ATTR_TAINT_SINK   = "taintsink"
+	ATTR_ESCAPES      = "escapes"
+)
+
+// Read taint annotations and attributes of the user functions and methods as rules,
+// return the errors of the annotations which are ignored
+func (rs *RuleSet) AddAnnotations(scripts map[string]*cfg.Script) ValidationErrors {
+	errs := make(ValidationErrors, 0)
+	for _, script := range scripts {
+		for _, fn := range script.FuncsMap {
+			v := &validator{fileName: fn.Filepath}
+			file, specialized := v.readAnnotations(fn)
+			if specialized {
+				rs.specialized[getSpecializedKey(fn)] = append(rs.specialized[getSpecializedKey(fn)], fn)
+			}
+			rs.Add(file)
+			errs = append(errs, v.errs...)
+		}
+	}
+	return errs
+}
+
+func getSpecializedKey(fn *cfg.Func) string {
+	if fn.FunctionClass != nil {
+		return "::" + strings.ToLower(fn.Name)
+	}
+	return strings.ToLower(getShortName(fn.Name))
+}
+
+// Get the specialized function called, the taint of each call is traced into its body.
+// Method of unknown object is resolved only if a single class declares it
+func (rs *RuleSet) GetSpecialized(call Call) (*cfg.Func, bool) {
+	if call.Function != "" {
+		fns := rs.specialized[strings.ToLower(getShortName(call.Function))]
+		if len(fns) == 1 {
+			return fns[0], true
+		}
+		return nil, false
+	}
+	fns := rs.specialized["::"+strings.ToLower(call.Method)]
+	if call.Class == "" || resolveClass(call.Class, rs.parents) == "" {
+		if len(fns) == 1 {
+			return fns[0], true
+		}
+		return nil, false
+	}
+	for _, fn := range fns {
+		if isSubclassOf(call.Class, strings.ToLower(getShortName(fn.FunctionClass.Val)), rs.parents) {
+			return fn, true
+		}
+	}
+	return nil, false
+}
+
+// Get rules of the annotations and attributes of the function, and if it is specialized
+func (v *validator) readAnnotations(fn *cfg.Func) (*RuleFile, bool) {
+	file := &RuleFile{}
+	path := fn.GetScopedName()
+	specialized := false
+	newMatcher := func() Matcher {
+		if fn.FunctionClass != nil {
+			return Matcher{Method: fn.FunctionClass.Val + "::" + fn.Name}
+		}
+		return Matcher{Function: fn.Name}
+	}
+	// escape kinds of the function are combined into one sanitizer
+	escapes := make(map[string]struct{})
+	unescapes := make(map[string]struct{})
+
+	for _, match := range annotationPattern.FindAllStringSubmatch(fn.DocComment, -1) {
+		fields := strings.Fields(strings.TrimSuffix(strings.TrimSpace(match[2]), "*/"))
+		kind := ""
+		if len(fields) > 0 {
+			kind = strings.ToLower(fields[0])
+		}
+		switch match[1] {
+		case "source":
+			file.Sources = append(file.Sources, &Source{Matcher: newMatcher()})
+		case "sink":
+			if len(fields) < 2 {
+				v.addError(path, "@psalm-taint-sink needs the kind and the parameter")
+				continue
+			}
+			v.addSink(file, fn, path, newMatcher(), kind, fields[1])
+		case "escape", "unescape":
+			if strings.HasPrefix(kind, "(") {
+				v.addError(path, "conditional @psalm-taint-%s is not supported", match[1])
+				continue
+			}
+			if _, ok := escapeKindLabels[kind]; !ok {
+				v.addError(path, "unsupported taint escape kind '%s'", kind)
+			} else if match[1] == "unescape" {
+				unescapes[kind] = struct{}{}
+			} else {
+				escapes[kind] = struct{}{}
+			}
+		case "specialize":
+			specialized = true
+		}
+	}
+
+	for _, attr := range getAttrs(fn.GetAttrGroups()) {
+		switch getAttrName(attr) {
+		case ATTR_TAINT_SOURCE:
+			file.Sources = append(file.Sources, &Source{Matcher: newMatcher()})
+		case ATTR_ESCAPES:
+			kind := getAttrKind(attr)
+			if _, ok := escapeKindLabels[kind]; ok {
+				escapes[kind] = struct{}{}
+			} else {
+				v.addError(path, "unsupported taint escape kind '%s'", kind)
+			}
+		}
+	}
+	if labels := getEscapeLabels(escapes, false); len(labels) > 0 {
+		file.Sanitizers = append(file.Sanitizers, &Sanitizer{Matcher: newMatcher(), Add: labels})
+	}
+	if labels := getEscapeLabels(unescapes, true); len(labels) > 0 {
+		file.Sanitizers = append(file.Sanitizers, &Sanitizer{Matcher: newMatcher(), Remove: labels})
+	}
+	for _, param := range fn.Params {
+		for _, attr := range getAttrs(param.AttrGroups) {
+			if getAttrName(attr) == ATTR_TAINT_SINK {
+				paramName, _ := cfg.GetOperandName(param.Name)
+				v.addSink(file, fn, path, newMatcher(), getAttrKind(attr), paramName)
+			}
+		}
+	}
+
+	// entries with errors are ignored, the others are still added
+	sources := make([]*Source, 0, len(file.Sources))
+	for i, source := range file.Sources {
+		errCount := len(v.errs)
+		v.validateSource(fmt.Sprintf("%s.sources[%d]", path, i), source)
+		if len(v.errs) == errCount {
+			sources = append(sources, source)
+		}
+	}
+	sinks := make([]*Sink, 0, len(file.Sinks))
+	for i, sink := range file.Sinks {
+		errCount := len(v.errs)
+		v.validateSink(fmt.Sprintf("%s.sinks[%d]", path, i), sink)
+		if len(v.errs) == errCount {
+			sinks = append(sinks, sink)
+		}
+	}
+	sanitizers := make([]*Sanitizer, 0, len(file.Sanitizers))
+	for i, sanitizer := range file.Sanitizers {
+		errCount := len(v.errs)
+		v.validateSanitizer(fmt.Sprintf("%s.sanitizers[%d]", path, i), sanitizer)
+		if len(v.errs) == errCount {
+			sanitizers = append(sanitizers, sanitizer)
+		}
+	}
+	file.Sources, file.Sinks, file.Sanitizers = sources, sinks, sanitizers
+	return file, specialized
+}
+
+func (v *validator) addSink(file *RuleFile, fn *cfg.Func, path string, m Matcher, kind string, paramName string) {
+	rule, ok := sinkKindRules[kind]
+	if !ok {
+		v.addError(path, "unsupported taint sink kind '%s'", kind)
+		return
+	}
+	paramName = strings.TrimPrefix(paramName, "$")
+	for i, param := range fn.Params {
+		if name, err := cfg.GetOperandName(param.Name); err == nil && strings.TrimPrefix(name, "$") == paramName {
+			file.Sinks = append(file.Sinks, &Sink{Matcher: m, Rule: rule, Args: []int{i}})
+			return
+		}
+	}
+	v.addError(path, "unknown parameter '$%s' of taint sink", paramName)
+}
+
+// Get labels of the escape kinds, value escaped for html without has_quotes is still unsafe in
+// quoted attribute, unescaping either of them removes every html escaping
+func getEscapeLabels(kinds map[string]struct{}, isUnescape bool) []string {
+	labels := make([]string, 0)
+	_, html := kinds["html"]
+	_, quotes := kinds["has_quotes"]
+	switch {
+	case isUnescape && (html || quotes):
+		labels = append(labels, "html-escaped", "html-escaped-double-quotes", "html-escaped-no-quotes")
+	case html && quotes:
+		labels = append(labels, "html-escaped")
+	case html:
+		labels = append(labels, "html-escaped-no-quotes")
+	}
+	names := make([]string, 0, len(kinds))
+	for kind := range kinds {
+		names = append(names, kind)
+	}
+	sort.Strings(names)
+	for _, kind := range names {
+		for _, label := range escapeKindLabels[kind] {
+			if !containsString(labels, label) {
+				labels = append(labels, label)
+			}
+		}
+	}
+	return labels
+}
+
+func containsString(list []string, val string) bool {
+	for _, v := range list {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
+
+func getAttrs(attrGroups []*cfg.OpAttributeGroup) []*cfg.OpAttribute {
+	attrs := make([]*cfg.OpAttribute, 0)
+	for _, attrGroup := range attrGroups {
+		attrs = append(attrs, attrGroup.Attrs...)
+	}
+	return attrs
+}
+
+func getAttrName(attr *cfg.OpAttribute) string {
+	name, _ := cfg.GetOperandName(attr.Name)
+	return strings.ToLower(getShortName(name))
+}
+
+// Get the kind argument of the attribute, html if not set
+func getAttrKind(attr *cfg.OpAttribute) string {
+	if len(attr.Args) > 0 {
+		if kind, ok := cfg.GetConstString(attr.Args[0]); ok {
+			return strings.ToLower(kind)
+		}
+	}
+	return "html"
+}
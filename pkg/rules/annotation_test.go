@@ -0,0 +1,92 @@
+package rules
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+)
+
+func TestAddAnnotations(t *testing.T) {
+	tests := []struct {
+		name           string
+		funcName       string
+		params         []string
+		doc            string
+		wantSources    int
+		wantSinks      int
+		wantSanitizers int
+		wantErrs       int
+	}{
+		{"source", "get_input", nil, "/** @psalm-taint-source input */", 1, 0, 0, 0},
+		{"sink of parameter", "render", []string{"$html"}, "/** @psalm-taint-sink html $html */", 0, 1, 0, 0},
+		{"escape", "clean", []string{"$s"}, "/** @psalm-taint-escape html */", 0, 0, 1, 0},
+		{"unknown sink parameter", "render", []string{"$html"}, "/**\n * @psalm-taint-sink html $body\n * @psalm-taint-escape html\n */", 0, 0, 1, 1},
+		{"unsupported escape kind", "clean", []string{"$s"}, "/**\n * @psalm-taint-escape ldap\n * @psalm-taint-sink sql $s\n */", 0, 1, 0, 1},
+		{"invalid function name", "{closure}", []string{"$s"}, "/**\n * @psalm-taint-source input\n * @psalm-taint-escape html\n */", 0, 0, 0, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, err := cfg.NewFunc(tt.funcName, cfg.FUNC_MODIF_FLAG_PUBLIC, cfg.NewOpTypeVoid(nil), cfg.NewBlock(0), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fn.DocComment = tt.doc
+			for _, name := range tt.params {
+				fn.Params = append(fn.Params, cfg.NewOpExprParam(cfg.NewOperandString(name), false, false, nil, nil, nil, nil, nil))
+			}
+			script := cfg.NewScript(fn, "/app/helpers.php")
+			script.AddFunc(fn)
+
+			rs := NewRuleSet()
+			errs := rs.AddAnnotations(map[string]*cfg.Script{"/app/helpers.php": script})
+			if len(errs) != tt.wantErrs {
+				t.Errorf("errors = %v, want %d", errs, tt.wantErrs)
+			}
+			if len(rs.sources) != tt.wantSources || len(rs.sinks) != tt.wantSinks || len(rs.sanitizers) != tt.wantSanitizers {
+				t.Errorf("added %d sources, %d sinks, %d sanitizers, want %d, %d, %d",
+					len(rs.sources), len(rs.sinks), len(rs.sanitizers), tt.wantSources, tt.wantSinks, tt.wantSanitizers)
+			}
+		})
+	}
+}
+
+func TestEscapeAnnotationLabels(t *testing.T) {
+	tests := []struct {
+		name       string
+		doc        string
+		wantAdd    []string
+		wantRemove []string
+	}{
+		{"html", "/** @psalm-taint-escape html */", []string{"html-escaped-no-quotes"}, nil},
+		{"html and quotes", "/**\n * @psalm-taint-escape html\n * @psalm-taint-escape has_quotes\n */", []string{"html-escaped"}, nil},
+		{"quotes only", "/** @psalm-taint-escape has_quotes */", nil, nil},
+		{"html and sql", "/**\n * @psalm-taint-escape sql\n * @psalm-taint-escape html\n */", []string{"html-escaped-no-quotes", "sql-escaped", "sql-quoted"}, nil},
+		{"same labels", "/**\n * @psalm-taint-escape header\n * @psalm-taint-escape cookie\n */", []string{"crlf-stripped"}, nil},
+		{"unescape html", "/** @psalm-taint-unescape html */", nil, []string{"html-escaped", "html-escaped-double-quotes", "html-escaped-no-quotes"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, err := cfg.NewFunc("clean", cfg.FUNC_MODIF_FLAG_PUBLIC, cfg.NewOpTypeVoid(nil), cfg.NewBlock(0), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fn.DocComment = tt.doc
+			script := cfg.NewScript(fn, "/app/helpers.php")
+			script.AddFunc(fn)
+
+			rs := NewRuleSet()
+			if errs := rs.AddAnnotations(map[string]*cfg.Script{"/app/helpers.php": script}); len(errs) != 0 {
+				t.Fatalf("errors = %v", errs)
+			}
+			var add, remove []string
+			for _, sanitizer := range rs.sanitizers {
+				add = append(add, sanitizer.Add...)
+				remove = append(remove, sanitizer.Remove...)
+			}
+			if !reflect.DeepEqual(add, tt.wantAdd) || !reflect.DeepEqual(remove, tt.wantRemove) {
+				t.Errorf("added %q and removed %q, want %q and %q", add, remove, tt.wantAdd, tt.wantRemove)
+			}
+		})
+	}
+}
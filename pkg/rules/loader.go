@@ -0,0 +1,312 @@
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/taint"
+)
+
+// Invalid entry of rule file
+type ValidationError struct {
+	File string
+	// Entry of the error such as sinks[2], empty if the file can't be decoded
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%s: %s", e.File, e.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.File, e.Path, e.Message)
+}
+
+// Every invalid entry of rule file
+type ValidationErrors []*ValidationError
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func LoadFile(filePath string) (*RuleFile, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return Load(data, filePath)
+}
+
+// Decode and validate JSON rule file, unknown keys are errors
+func Load(data []byte, fileName string) (*RuleFile, error) {
+	file := &RuleFile{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(file); err != nil {
+		return nil, ValidationErrors{{File: fileName, Message: err.Error()}}
+	}
+	v := &validator{fileName: fileName}
+	for i, source := range file.Sources {
+		v.validateSource(fmt.Sprintf("sources[%d]", i), source)
+	}
+	for i, sink := range file.Sinks {
+		v.validateSink(fmt.Sprintf("sinks[%d]", i), sink)
+	}
+	for i, sanitizer := range file.Sanitizers {
+		v.validateSanitizer(fmt.Sprintf("sanitizers[%d]", i), sanitizer)
+	}
+	for i, renderKey := range file.RenderKeys {
+		v.validateRenderKey(fmt.Sprintf("renderKeys[%d]", i), renderKey)
+	}
+	for i, formatter := range file.Formatters {
+		v.validateFormatter(fmt.Sprintf("formatters[%d]", i), formatter)
+	}
+	for class, parent := range file.Parents {
+		if !isIdentifier(getShortName(class)) || !isIdentifier(getShortName(parent)) {
+			v.addError(fmt.Sprintf("parents[%s]", class), "invalid class name")
+		}
+	}
+	for property, class := range file.Properties {
+		className, propName, found := strings.Cut(property, "::")
+		if !found || !isIdentifier(className) || !isIdentifier(propName) || !isIdentifier(getShortName(class)) {
+			v.addError(fmt.Sprintf("properties[%s]", property), "expected Class::property with class name")
+		}
+	}
+	for name, class := range file.Globals {
+		if !isIdentifier(name) || !isIdentifier(getShortName(class)) {
+			v.addError(fmt.Sprintf("globals[%s]", name), "expected variable name without $ with class name")
+		}
+	}
+	if len(v.errs) > 0 {
+		return nil, v.errs
+	}
+	return file, nil
+}
+
+type validator struct {
+	fileName string
+	errs     ValidationErrors
+}
+
+func (v *validator) addError(path string, format string, args ...interface{}) {
+	v.errs = append(v.errs, &ValidationError{File: v.fileName, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validateSource(path string, source *Source) {
+	if source == nil {
+		v.addError(path, "empty rule")
+		return
+	}
+	v.validateMatcher(path, &source.Matcher, true)
+	v.validateArgs(path+".byRef", source.ByRef)
+	v.validateByRefFunction(path, &source.Matcher, source.ByRef)
+	if source.Superglobal != "" && (len(source.ByRef) > 0 || source.Result != nil) {
+		v.addError(path, "result and byRef apply to call only")
+	}
+	if !source.IsResultTainted() && len(source.ByRef) == 0 {
+		v.addError(path, "source taints neither the result nor a byRef argument")
+	}
+}
+
+func (v *validator) validateSink(path string, sink *Sink) {
+	if sink == nil {
+		v.addError(path, "empty rule")
+		return
+	}
+	v.validateMatcher(path, &sink.Matcher, false)
+	v.validateRule(path, sink.Rule)
+	for i, arg := range sink.Args {
+		if arg < ARG_LAST {
+			v.addError(fmt.Sprintf("%s.args[%d]", path, i), "invalid argument position %d", arg)
+		}
+	}
+	if len(sink.Add) > 0 && !sink.Rule.IsHTML() {
+		v.addError(path+".add", "labels can only be added by html sink")
+	}
+	if sink.Output && (sink.Rule != taint.RULE_XSS || sink.Function == "") {
+		v.addError(path+".output", "only xss function sink can write to the response body")
+	}
+	sink.addLabels = v.parseLabels(path+".add", sink.Add)
+}
+
+func (v *validator) validateRenderKey(path string, renderKey *RenderKey) {
+	if renderKey == nil {
+		v.addError(path, "empty rule")
+		return
+	}
+	if renderKey.Key == "" {
+		v.addError(path, "missing key")
+	}
+	v.validateRule(path, renderKey.Rule)
+	if len(renderKey.Add) > 0 && !renderKey.Rule.IsHTML() {
+		v.addError(path+".add", "labels can only be added by html sink")
+	}
+	renderKey.addLabels = v.parseLabels(path+".add", renderKey.Add)
+}
+
+func (v *validator) validateFormatter(path string, formatter *Formatter) {
+	if formatter == nil {
+		v.addError(path, "empty rule")
+		return
+	}
+	v.validateMatcher(path, &formatter.Matcher, false)
+	v.validateRule(path, formatter.Rule)
+	if formatter.Arg < 0 {
+		v.addError(path+".arg", "invalid argument position %d", formatter.Arg)
+	}
+	if len(formatter.Placeholders) == 0 {
+		v.addError(path, "missing placeholders")
+	}
+	formatter.placeholderLabels = make(map[string]taint.Labels)
+	for prefix, names := range formatter.Placeholders {
+		if len(prefix) != 1 {
+			v.addError(fmt.Sprintf("%s.placeholders[%s]", path, prefix), "placeholder prefix must be one character")
+		}
+		formatter.placeholderLabels[prefix] = v.parseLabels(fmt.Sprintf("%s.placeholders[%s]", path, prefix), names)
+	}
+}
+
+func (v *validator) validateRule(path string, rule taint.RuleID) {
+	if rule == "" {
+		v.addError(path, "missing rule")
+	} else if taint.GetRule(rule) == nil {
+		v.addError(path+".rule", "unknown rule '%s'", rule)
+	}
+}
+
+func (v *validator) validateSanitizer(path string, sanitizer *Sanitizer) {
+	if sanitizer == nil {
+		v.addError(path, "empty rule")
+		return
+	}
+	v.validateMatcher(path, &sanitizer.Matcher, false)
+	v.validateArgs(path+".byRef", sanitizer.ByRef)
+	v.validateByRefFunction(path, &sanitizer.Matcher, sanitizer.ByRef)
+	if len(sanitizer.Add) == 0 && len(sanitizer.Remove) == 0 {
+		v.addError(path, "sanitizer neither adds nor removes a label")
+	}
+	sanitizer.addLabels = v.parseLabels(path+".add", sanitizer.Add)
+	sanitizer.removeLabels = v.parseLabels(path+".remove", sanitizer.Remove)
+}
+
+func (v *validator) parseLabels(path string, names []string) taint.Labels {
+	labels := taint.LABELS_RAW
+	for i, name := range names {
+		label, err := taint.ParseLabel(name)
+		if err != nil {
+			v.addError(fmt.Sprintf("%s[%d]", path, i), "%v", err)
+			continue
+		}
+		labels = labels.Add(label)
+	}
+	return labels
+}
+
+func (v *validator) validateArgs(path string, args []int) {
+	for i, arg := range args {
+		if arg < 0 {
+			v.addError(fmt.Sprintf("%s[%d]", path, i), "invalid argument position %d", arg)
+		}
+	}
+}
+
+// Arguments passed by reference are written when building, so the name must be exact
+func (v *validator) validateByRefFunction(path string, m *Matcher, args []int) {
+	if len(args) > 0 && strings.HasSuffix(m.Function, "*") {
+		v.addError(path+".byRef", "byRef needs the exact function name")
+	}
+}
+
+// Check the matcher and set its lowercased names
+func (v *validator) validateMatcher(path string, m *Matcher, isSource bool) {
+	set := 0
+	for _, name := range []string{m.Function, m.Method, m.Superglobal} {
+		if name != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		v.addError(path, "exactly one of function, method and superglobal must be set")
+		return
+	}
+
+	switch {
+	case m.Function != "":
+		if strings.Contains(m.Function, "::") || !isIdentifier(strings.TrimSuffix(getShortName(m.Function), "*")) {
+			v.addError(path+".function", "invalid function name '%s'", m.Function)
+		}
+		m.function = strings.ToLower(getShortName(m.Function))
+	case m.Method != "":
+		className, methodName, found := strings.Cut(m.Method, "::")
+		if !found || !isIdentifier(methodName) || (className != "*" && !isIdentifier(getShortName(strings.TrimPrefix(className, "\\")))) {
+			v.addError(path+".method", "invalid method '%s', expected Class::method or *::method", m.Method)
+		}
+		m.class = strings.ToLower(getShortName(className))
+		m.method = strings.ToLower(methodName)
+	case m.Superglobal != "":
+		if !isSource {
+			v.addError(path+".superglobal", "superglobal can only be a source")
+		} else if !cfg.IsSuperGlobal(m.superglobalName()) {
+			v.addError(path+".superglobal", "unsupported superglobal '%s'", m.Superglobal)
+		}
+		if len(m.When) > 0 || len(m.Unless) > 0 {
+			v.addError(path, "conditions apply to call only")
+		}
+	}
+	if m.Superglobal == "" && len(m.Keys) > 0 {
+		v.addError(path+".keys", "keys apply to superglobal only")
+	}
+	for i, cond := range m.When {
+		v.validateCondition(fmt.Sprintf("%s.when[%d]", path, i), cond)
+	}
+	for i, cond := range m.Unless {
+		v.validateCondition(fmt.Sprintf("%s.unless[%d]", path, i), cond)
+	}
+}
+
+func (v *validator) validateCondition(path string, cond *Condition) {
+	if cond == nil {
+		v.addError(path, "empty condition")
+		return
+	}
+	if cond.Arg < 0 {
+		v.addError(path+".arg", "invalid argument position %d", cond.Arg)
+	}
+	if !cond.Missing && len(cond.Flags) == 0 && cond.Equals == nil {
+		v.addError(path, "condition needs missing, flags or equals")
+	}
+	if cond.Missing && (len(cond.Flags) > 0 || cond.Equals != nil) {
+		v.addError(path, "missing argument can't have flags or equals")
+	}
+	switch cond.Equals.(type) {
+	case nil, bool, float64, string:
+	default:
+		v.addError(path+".equals", "equals must be a string, number or boolean")
+	}
+	for i, flag := range cond.Flags {
+		if !isIdentifier(flag) {
+			v.addError(fmt.Sprintf("%s.flags[%d]", path, i), "invalid constant name '%s'", flag)
+		}
+	}
+}
+
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		isLetter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+		if !isLetter && (i == 0 || c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,185 @@
+package rules
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+)
+
+// Load the rule file, return the paths of the invalid entries
+func loadErrors(t *testing.T, data string) []string {
+	t.Helper()
+	_, err := Load([]byte(data), "test.json")
+	if err == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Load() error is %T, want ValidationErrors", err)
+	}
+	paths := make([]string, 0, len(errs))
+	for _, e := range errs {
+		paths = append(paths, e.Path)
+	}
+	return paths
+}
+
+func TestLoadOutputSink(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"xss function sink", `{"sinks": [{"function": "the_*", "rule": "xss", "output": true}]}`, nil},
+		{"method sink", `{"sinks": [{"method": "View::show", "rule": "xss", "output": true}]}`, []string{"sinks[0].output"}},
+		{"non xss sink", `{"sinks": [{"function": "mysql_query", "rule": "sql-injection", "output": true}]}`, []string{"sinks[0].output"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := loadErrors(t, tt.data)
+			if len(got) != len(tt.want) {
+				t.Fatalf("errors = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("errors[%d] = %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"valid rules", `{
+			"sources": [{"superglobal": "_GET", "keys": ["q"]}, {"function": "fgets"}, {"function": "parse_str", "byRef": [1], "result": false}],
+			"sinks": [{"method": "*::render", "rule": "xss", "args": [-1]}],
+			"sanitizers": [{"function": "wp_kses_*", "add": ["html-sanitized"]}],
+			"renderKeys": [{"key": "#markup", "rule": "xss", "add": ["html-sanitized"]}],
+			"formatters": [{"function": "t", "rule": "xss", "arg": 1, "placeholders": {"@": ["html-escaped"], "!": []}}],
+			"parents": {"App\\Http\\FormRequest": "Request"},
+			"properties": {"Request::query": "InputBag"}
+		}`, nil},
+		{"empty rule", `{"sinks": [null]}`, []string{"sinks[0]"}},
+		{"no matcher", `{"sinks": [{"rule": "xss"}]}`, []string{"sinks[0]"}},
+		{"two matchers", `{"sinks": [{"function": "echo_html", "method": "View::echo", "rule": "xss"}]}`, []string{"sinks[0]"}},
+		{"namespaced method function", `{"sinks": [{"function": "View::render", "rule": "xss"}]}`, []string{"sinks[0].function"}},
+		{"method without class", `{"sinks": [{"method": "render", "rule": "xss"}]}`, []string{"sinks[0].method"}},
+		{"superglobal sink", `{"sinks": [{"superglobal": "_GET", "rule": "xss"}]}`, []string{"sinks[0].superglobal"}},
+		{"unsupported superglobal", `{"sources": [{"superglobal": "_ENV_VARS"}]}`, []string{"sources[0].superglobal"}},
+		{"keys of function", `{"sources": [{"function": "fgets", "keys": ["q"]}]}`, []string{"sources[0].keys"}},
+		{"superglobal condition", `{"sources": [{"superglobal": "_GET", "when": [{"arg": 0, "missing": true}]}]}`, []string{"sources[0]"}},
+		{"superglobal byRef", `{"sources": [{"superglobal": "_GET", "byRef": [0]}]}`, []string{"sources[0]"}},
+		{"source tainting nothing", `{"sources": [{"function": "fgets", "result": false}]}`, []string{"sources[0]"}},
+		{"negative byRef", `{"sources": [{"function": "parse_str", "byRef": [-1]}]}`, []string{"sources[0].byRef[0]"}},
+		{"byRef of prefix", `{"sanitizers": [{"function": "clean_*", "byRef": [0], "add": ["html-escaped"]}]}`, []string{"sanitizers[0].byRef"}},
+		{"missing rule", `{"sinks": [{"function": "render"}]}`, []string{"sinks[0]"}},
+		{"unknown rule", `{"sinks": [{"function": "render", "rule": "csrf"}]}`, []string{"sinks[0].rule"}},
+		{"invalid arg", `{"sinks": [{"function": "render", "rule": "xss", "args": [0, -2]}]}`, []string{"sinks[0].args[1]"}},
+		{"labels of non html sink", `{"sinks": [{"function": "query", "rule": "sql-injection", "add": ["sql-escaped"]}]}`, []string{"sinks[0].add"}},
+		{"unknown label", `{"sanitizers": [{"function": "clean", "add": ["html-escaped", "clean"]}]}`, []string{"sanitizers[0].add[1]"}},
+		{"sanitizer without labels", `{"sanitizers": [{"function": "clean"}]}`, []string{"sanitizers[0]"}},
+		{"render key without key", `{"renderKeys": [{"rule": "xss"}]}`, []string{"renderKeys[0]"}},
+		{"formatter without placeholders", `{"formatters": [{"function": "t", "rule": "xss", "arg": 1}]}`, []string{"formatters[0]"}},
+		{"long placeholder prefix", `{"formatters": [{"function": "t", "rule": "xss", "arg": 1, "placeholders": {"%%": []}}]}`, []string{"formatters[0].placeholders[%%]"}},
+		{"invalid parent", `{"parents": {"Form Request": "Request"}}`, []string{"parents[Form Request]"}},
+		{"property without class", `{"properties": {"query": "InputBag"}}`, []string{"properties[query]"}},
+		{"global with dollar", `{"globals": {"$wpdb": "wpdb"}}`, []string{"globals[$wpdb]"}},
+		{"unknown field", `{"sinks": [{"function": "render", "rule": "xss", "arguments": [0]}]}`, []string{""}},
+		{"several errors", `{"sinks": [{"function": "render"}, {"function": "show", "rule": "csrf"}]}`, []string{"sinks[0]", "sinks[1].rule"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := loadErrors(t, tt.data)
+			if len(got) != len(tt.want) {
+				t.Fatalf("errors = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("errors[%d] = %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConditions(t *testing.T) {
+	tests := []struct {
+		name string
+		cond string
+		want []string
+	}{
+		{"missing", `{"arg": 1, "missing": true}`, nil},
+		{"flags", `{"arg": 1, "flags": ["ENT_QUOTES"]}`, nil},
+		{"equals", `{"arg": 1, "equals": true}`, nil},
+		{"no check", `{"arg": 1}`, []string{"sinks[0].when[0]"}},
+		{"missing with flags", `{"arg": 1, "missing": true, "flags": ["ENT_QUOTES"]}`, []string{"sinks[0].when[0]"}},
+		{"negative arg", `{"arg": -1, "missing": true}`, []string{"sinks[0].when[0].arg"}},
+		{"array equals", `{"arg": 1, "equals": [1]}`, []string{"sinks[0].when[0].equals"}},
+		{"invalid flag", `{"arg": 1, "flags": ["ENT-QUOTES"]}`, []string{"sinks[0].when[0].flags[0]"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := loadErrors(t, `{"sinks": [{"function": "render", "rule": "xss", "when": [`+tt.cond+`]}]}`)
+			if len(got) != len(tt.want) {
+				t.Fatalf("errors = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("errors[%d] = %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestConditionHolds(t *testing.T) {
+	constFetch := func(name string) cfg.Operand {
+		return cfg.NewOpExprConstFetch(cfg.NewOperandString(name), nil).Result
+	}
+	flags := cfg.NewOpExprBinaryBitwiseOr(constFetch("ENT_QUOTES"), constFetch("ENT_HTML5"), nil).Result
+	tests := []struct {
+		name string
+		cond Condition
+		args []cfg.Operand
+		want bool
+	}{
+		{"missing argument", Condition{Arg: 1, Missing: true}, []cfg.Operand{flags}, true},
+		{"passed argument", Condition{Arg: 0, Missing: true}, []cfg.Operand{flags}, false},
+		{"or-ed flag", Condition{Arg: 0, Flags: []string{"ENT_QUOTES"}}, []cfg.Operand{flags}, true},
+		{"other flag", Condition{Arg: 0, Flags: []string{"ENT_NOQUOTES"}}, []cfg.Operand{flags}, false},
+		{"flag of unknown value", Condition{Arg: 0, Flags: []string{"ENT_QUOTES"}}, []cfg.Operand{cfg.NewTemporaryOperand(nil)}, false},
+		{"equals true", Condition{Arg: 0, Equals: true}, []cfg.Operand{cfg.NewOperandBool(true)}, true},
+		{"equals true of one", Condition{Arg: 0, Equals: true}, []cfg.Operand{cfg.NewOperandNumber(1)}, true},
+		{"equals true of false", Condition{Arg: 0, Equals: true}, []cfg.Operand{cfg.NewOperandBool(false)}, false},
+		{"equals number", Condition{Arg: 0, Equals: float64(2)}, []cfg.Operand{cfg.NewOperandNumber(2)}, true},
+		{"equals string", Condition{Arg: 0, Equals: "html"}, []cfg.Operand{cfg.NewOperandString("html")}, true},
+		{"equals other string", Condition{Arg: 0, Equals: "html"}, []cfg.Operand{cfg.NewOperandString("text")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cond.holds(tt.args); got != tt.want {
+				t.Errorf("holds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadBuiltinRules(t *testing.T) {
+	files := map[string][]byte{"default.json": defaultRules}
+	for _, pack := range packs {
+		files[pack.Name+".json"] = pack.rules
+	}
+	for name, data := range files {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(data, name); err != nil {
+				t.Errorf("Load() = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package rules
+
+import (
+	"bytes"
+	_ "embed"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+//go:embed wordpress.json
+var wordpressRules []byte
+
+//go:embed laravel.json
+var laravelRules []byte
+
+//go:embed symfony.json
+var symfonyRules []byte
+
+//go:embed drupal.json
+var drupalRules []byte
+
+// Rules of a framework, enabled when its files are detected
+type Pack struct {
+	Name   string
+	rules  []byte
+	detect func(filePath string, src []byte) bool
+}
+
+var packs = []*Pack{
+	{Name: "wordpress", rules: wordpressRules, detect: isWordPressFile},
+	{Name: "laravel", rules: laravelRules, detect: isLaravelFile},
+	{Name: "symfony", rules: symfonyRules, detect: isSymfonyFile},
+	{Name: "drupal", rules: drupalRules, detect: isDrupalFile},
+}
+
+// Get the packs of the frameworks the file belong to
+func DetectPacks(filePath string, src []byte) []*Pack {
+	detected := make([]*Pack, 0)
+	for _, pack := range packs {
+		if pack.detect(filePath, src) {
+			detected = append(detected, pack)
+		}
+	}
+	return detected
+}
+
+// Add rules of the pack, false if it is already added
+func (rs *RuleSet) AddPack(pack *Pack) bool {
+	if _, ok := rs.packs[pack.Name]; ok {
+		return false
+	}
+	file, err := Load(pack.rules, pack.Name+".json")
+	if err != nil {
+		log.Fatalf("Error loading %s rules: %v", pack.Name, err)
+	}
+	rs.packs[pack.Name] = struct{}{}
+	rs.Add(file)
+	return true
+}
+
+func hasPathSegment(filePath string, segments ...string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(filePath), "/") {
+		for _, segment := range segments {
+			if part == segment {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Header comment of the main plugin file, WordPress only read the first 8 KB
+var wpPluginHeader = regexp.MustCompile(`(?mi)^(?:[ \t]*<\?php)?[ \t/*#@]*Plugin Name[ \t]*:`)
+
+func isWordPressFile(filePath string, src []byte) bool {
+	if hasPathSegment(filePath, "wp-content", "wp-includes") {
+		return true
+	}
+	if len(src) > 8192 {
+		src = src[:8192]
+	}
+	return wpPluginHeader.Match(src)
+}
+
+func isLaravelFile(filePath string, src []byte) bool {
+	return strings.HasSuffix(filePath, ".blade.php") || bytes.Contains(src, []byte("Illuminate\\"))
+}
+
+// Import or namespace of Symfony, a mention in a comment or string isn't enough
+var symfonyImport = regexp.MustCompile(`(?m)^[ \t]*(?:use|namespace)[ \t]+\\?Symfony\\`)
+
+func isSymfonyFile(filePath string, src []byte) bool {
+	return strings.HasSuffix(filePath, ".twig") || symfonyImport.Match(src)
+}
+
+func isDrupalFile(filePath string, src []byte) bool {
+	switch filepath.Ext(filePath) {
+	case ".module", ".theme":
+		return true
+	}
+	return bytes.Contains(src, []byte("Drupal\\"))
+}
@@ -0,0 +1,72 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsWordPressFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		src      string
+		want     bool
+	}{
+		{"plugin directory", "/var/www/wp-content/plugins/shop/admin.php", "<?php echo 1;", true},
+		{"core directory", "wp-includes/formatting.php", "<?php", true},
+		{"plugin header", "/src/shop.php", "<?php\n/**\n * Plugin Name: Shop\n * Version: 1.0\n */", true},
+		{"plugin header comment", "/src/shop.php", "<?php\n// Plugin Name : Shop", true},
+		{"header after 8 KB", "/src/shop.php", "<?php\n" + strings.Repeat("\n", 8192) + "/* Plugin Name: Shop */", false},
+		{"similar directory", "/src/wp-contents/shop.php", "<?php", false},
+		{"plugin name in code", "/src/shop.php", "<?php\n$label = 'Plugin Name: Shop';", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isWordPressFile(tt.filePath, []byte(tt.src)); got != tt.want {
+				t.Errorf("isWordPressFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSymfonyFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		src      string
+		want     bool
+	}{
+		{"twig template", "templates/index.html.twig", "{{ name }}", true},
+		{"import", "src/Controller/HomeController.php", "<?php\nnamespace App\\Controller;\n\nuse Symfony\\Component\\HttpFoundation\\Request;", true},
+		{"leading backslash import", "src/Kernel.php", "<?php\n    use \\Symfony\\Bundle\\FrameworkBundle\\Kernel\\MicroKernelTrait;", true},
+		{"symfony namespace", "vendor/symfony/http-foundation/Request.php", "<?php\nnamespace Symfony\\Component\\HttpFoundation;", true},
+		{"mention in comment", "lib/compat.php", "<?php\n// works like Symfony\\Component\\HttpFoundation\\Request", false},
+		{"mention in string", "lib/compat.php", "<?php\n$class = 'Symfony\\Component\\HttpFoundation\\Request';", false},
+		{"other import", "src/Kernel.php", "<?php\nuse SymfonyCasts\\Bundle\\Verify;", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSymfonyFile(tt.filePath, []byte(tt.src)); got != tt.want {
+				t.Errorf("isSymfonyFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddPack(t *testing.T) {
+	detected := DetectPacks("/var/www/wp-content/themes/blog/functions.php", []byte("<?php"))
+	if len(detected) != 1 || detected[0].Name != "wordpress" {
+		t.Fatalf("DetectPacks() = %v, want the wordpress pack", detected)
+	}
+	rs := NewRuleSet()
+	if !rs.AddPack(detected[0]) {
+		t.Errorf("AddPack() = false, want true")
+	}
+	sinks := len(rs.sinks)
+	if sinks == 0 {
+		t.Errorf("wordpress pack has no sinks")
+	}
+	if rs.AddPack(detected[0]) || len(rs.sinks) != sinks {
+		t.Errorf("pack is added twice")
+	}
+}
@@ -0,0 +1,398 @@
+package rules
+
+import (
+	"strings"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/taint"
+)
+
+// Sources, sinks and sanitizers of a rule file
+type RuleFile struct {
+	Sources    []*Source    `json:"sources"`
+	Sinks      []*Sink      `json:"sinks"`
+	Sanitizers []*Sanitizer `json:"sanitizers"`
+	// Parent of the framework classes which are not scanned, such as FormRequest: Request
+	Parents map[string]string `json:"parents,omitempty"`
+	// Class of the framework object properties, such as Request::query: InputBag
+	Properties map[string]string `json:"properties,omitempty"`
+	// Class of the global variables set by the framework, such as wpdb: wpdb
+	Globals map[string]string `json:"globals,omitempty"`
+	// Keys of the render arrays built by controllers and form builders which are output, such as #markup
+	RenderKeys []*RenderKey `json:"renderKeys,omitempty"`
+	// Calls replacing the placeholders of the format string by the values of an array, such as t()
+	Formatters []*Formatter `json:"formatters,omitempty"`
+}
+
+// What the rule apply to, exactly one of function, method and superglobal is set
+type Matcher struct {
+	// Function name, namespace is ignored, ending with * to match the prefix such as the_*
+	Function string `json:"function,omitempty"`
+	// Class::method, also matching the subclasses, * for any class
+	Method string `json:"method,omitempty"`
+	// Superglobal name such as _GET, only for source
+	Superglobal string `json:"superglobal,omitempty"`
+	// Tainted keys of the superglobal, every key if empty
+	Keys []string `json:"keys,omitempty"`
+	// Every condition must hold for the rule to apply
+	When []*Condition `json:"when,omitempty"`
+	// The rule doesn't apply if any condition hold
+	Unless []*Condition `json:"unless,omitempty"`
+
+	function string
+	class    string
+	method   string
+}
+
+// Condition on the constant argument of the call
+type Condition struct {
+	Arg int `json:"arg"`
+	// Argument is not passed
+	Missing bool `json:"missing,omitempty"`
+	// Argument is or-ed with any of the constants, such as ENT_QUOTES
+	Flags []string `json:"flags,omitempty"`
+	// Argument is the constant string, number or boolean
+	Equals interface{} `json:"equals,omitempty"`
+}
+
+type Source struct {
+	Matcher
+	// Result of the call is tainted, true if not set
+	Result *bool `json:"result,omitempty"`
+	// Arguments passed by reference which receive the tainted value
+	ByRef []int `json:"byRef,omitempty"`
+}
+
+type Sink struct {
+	Matcher
+	Rule taint.RuleID `json:"rule"`
+	// Argument positions reaching the sink, -1 for the last, every argument if empty
+	Args []int `json:"args,omitempty"`
+	// Labels added by the sink before the output, such as esc_html_e
+	Add []string `json:"add,omitempty"`
+	// Set when the sink writes to the response body, inside output buffer the value goes to the buffer
+	Output bool `json:"output,omitempty"`
+
+	addLabels taint.Labels
+}
+
+// Render array key which value is output, such as '#markup' => $html
+type RenderKey struct {
+	Key  string       `json:"key"`
+	Rule taint.RuleID `json:"rule"`
+	// Labels added by the renderer before the output, such as Xss::filterAdmin of #markup
+	Add []string `json:"add,omitempty"`
+
+	addLabels taint.Labels
+}
+
+type Formatter struct {
+	Matcher
+	Rule taint.RuleID `json:"rule"`
+	// Position of the array of placeholder values
+	Arg int `json:"arg"`
+	// Labels added to the value by the first character of the placeholder, such as @: [html-escaped],
+	// value of placeholder without labels is output as is, other placeholders are not replaced
+	Placeholders map[string][]string `json:"placeholders"`
+
+	placeholderLabels map[string]taint.Labels
+}
+
+type Sanitizer struct {
+	Matcher
+	// Labels added to the result
+	Add []string `json:"add,omitempty"`
+	// Labels removed from the result, such as by decoding
+	Remove []string `json:"remove,omitempty"`
+	// Arguments passed by reference which are sanitized in place
+	ByRef []int `json:"byRef,omitempty"`
+
+	addLabels    taint.Labels
+	removeLabels taint.Labels
+}
+
+// Apply the sanitizer to the labels of the tainted value
+func (s *Sanitizer) Apply(labels taint.Labels) taint.Labels {
+	return labels.Remove(s.removeLabels).Add(s.addLabels)
+}
+
+// Get labels the sink add to the tainted value
+func (s *Sink) GetAddedLabels() taint.Labels {
+	return s.addLabels
+}
+
+// Get labels the renderer add to the value of the key
+func (k *RenderKey) GetAddedLabels() taint.Labels {
+	return k.addLabels
+}
+
+// Get labels added to the value of the placeholder, false if the placeholder is not replaced
+func (f *Formatter) GetPlaceholderLabels(placeholder string) (taint.Labels, bool) {
+	if placeholder == "" {
+		return taint.LABELS_RAW, false
+	}
+	labels, ok := f.placeholderLabels[placeholder[:1]]
+	return labels, ok
+}
+
+// Check if the result of the source call is tainted
+func (s *Source) IsResultTainted() bool {
+	return s.Result == nil || *s.Result
+}
+
+const ARG_LAST = -1
+
+// Check if the tainted value is passed at the sink argument positions
+func (s *Sink) IsSinkArg(args []cfg.Operand, taintedVar cfg.Operand) bool {
+	if len(s.Args) == 0 {
+		for _, arg := range args {
+			if arg == taintedVar {
+				return true
+			}
+		}
+		return false
+	}
+	for _, i := range s.Args {
+		if i == ARG_LAST {
+			i = len(args) - 1
+		}
+		if i >= 0 && i < len(args) && args[i] == taintedVar {
+			return true
+		}
+	}
+	return false
+}
+
+// Function or method call matched by the rules
+type Call struct {
+	// Function name, empty for method call
+	Function string
+	// Class of static call or of the object, empty if unknown
+	Class  string
+	Method string
+	Args   []cfg.Operand
+}
+
+func NewFunctionCall(name string, args []cfg.Operand) Call {
+	return Call{Function: name, Args: args}
+}
+
+// Get the call of op, false if op is not a call with constant name.
+// new Class(...) is the call of Class::__construct
+func GetCall(op cfg.Op) (Call, bool) {
+	switch opT := op.(type) {
+	case *cfg.OpExprNew:
+		classNameStr, err := cfg.GetOperandName(opT.Class)
+		if err != nil {
+			return Call{}, false
+		}
+		return Call{Class: classNameStr, Method: "__construct", Args: opT.Args}, true
+	case *cfg.OpExprFunctionCall:
+		funcNameStr, err := cfg.GetOperandName(opT.Name)
+		if err != nil {
+			return Call{}, false
+		}
+		return NewFunctionCall(funcNameStr, opT.Args), true
+	case *cfg.OpExprMethodCall:
+		methodNameStr, err := cfg.GetOperandName(opT.Name)
+		if err != nil {
+			return Call{}, false
+		}
+		className := getObjectClass(opT.Var)
+		if className == "" {
+			// property of $this or of typed parameter, such as $this->renderer->render()
+			className = opT.ObjectProperty
+		}
+		return Call{Class: className, Method: methodNameStr, Args: opT.Args}, true
+	case *cfg.OpExprStaticCall:
+		methodNameStr, err := cfg.GetOperandName(opT.Name)
+		if err != nil {
+			return Call{}, false
+		}
+		classNameStr, _ := cfg.GetOperandName(opT.Class)
+		switch strings.ToLower(classNameStr) {
+		case "self", "static", "parent":
+			classNameStr = ""
+		}
+		return Call{Class: classNameStr, Method: methodNameStr, Args: opT.Args}, true
+	}
+	return Call{}, false
+}
+
+// Name of the call as written in the report, such as Input::get()
+func (c Call) String() string {
+	if c.Function != "" {
+		return getShortName(c.Function) + "()"
+	}
+	if strings.HasPrefix(c.Class, "$") {
+		return c.Class + "->" + c.Method + "()"
+	}
+	if c.Class != "" {
+		return getShortName(c.Class) + "::" + c.Method + "()"
+	}
+	return c.Method + "()"
+}
+
+// Get class of the object created by new or of the typed parameter, following assignments.
+// Property of known class is Class::property, its class is given by the rule properties,
+// and global variable is $name
+func getObjectClass(oper cfg.Operand) string {
+	for i := 0; oper != nil && i < 32; i++ {
+		if obj, ok := cfg.GetOperVal(oper).(*cfg.OperandObject); ok {
+			return obj.ClassName
+		}
+		if global, ok := oper.(*cfg.OperandBoundVariable); ok && global.Scope == cfg.BOUND_VAR_SCOPE_GLOBAL {
+			// global variable set by the framework, its class is given by the rule globals
+			name, _ := cfg.GetOperandName(global.Name)
+			return name
+		}
+		switch writer := oper.GetWriter().(type) {
+		case *cfg.OpExprNew:
+			classNameStr, _ := cfg.GetOperandName(writer.Class)
+			return classNameStr
+		case *cfg.OpExprParam:
+			// class of the parameter type, such as Request $request
+			return writer.ClassType
+		case *cfg.OpExprPropertyFetch:
+			className := getObjectClass(writer.Var)
+			propName, err := cfg.GetOperandName(writer.Name)
+			if className == "" || err != nil {
+				return ""
+			}
+			return getShortName(className) + "::" + propName
+		case *cfg.OpExprAssign:
+			oper = writer.Expr
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+func getShortName(name string) string {
+	if i := strings.LastIndex(name, "\\"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// Check if the matcher apply to the call, method of unknown object matches only if matchUnknown is set
+func (m *Matcher) matchCall(call Call, parents map[string]string, matchUnknown bool) bool {
+	if call.Function != "" {
+		if !m.matchFunction(strings.ToLower(getShortName(call.Function))) {
+			return false
+		}
+	} else if m.method == "" || m.method != strings.ToLower(call.Method) || !m.matchClass(call.Class, parents, matchUnknown) {
+		return false
+	}
+	for _, cond := range m.When {
+		if !cond.holds(call.Args) {
+			return false
+		}
+	}
+	for _, cond := range m.Unless {
+		if cond.holds(call.Args) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Matcher) matchFunction(name string) bool {
+	if strings.HasSuffix(m.function, "*") {
+		return strings.HasPrefix(name, strings.TrimSuffix(m.function, "*"))
+	}
+	return m.function != "" && m.function == name
+}
+
+// Only the method name is known for unknown object, it could be of any class
+func (m *Matcher) matchClass(class string, parents map[string]string, matchUnknown bool) bool {
+	if m.class == "*" {
+		return true
+	}
+	if resolveClass(class, parents) == "" {
+		return matchUnknown
+	}
+	return isSubclassOf(class, m.class, parents)
+}
+
+// Check if the class is the parent or its subclass, false if the class is not known
+func isSubclassOf(class string, parent string, parents map[string]string) bool {
+	if parent == "*" {
+		return true
+	}
+	class = resolveClass(class, parents)
+	for i := 0; class != "" && i < 32; i++ {
+		if class == parent {
+			return true
+		}
+		class = parents[class]
+	}
+	return false
+}
+
+// Get lowercased class without namespace, Class::property is resolved to the class of the property
+// declared by the class or its parents and $variable to the class of the global, empty if it is not known
+func resolveClass(class string, parents map[string]string) string {
+	parts := strings.Split(strings.ToLower(class), "::")
+	class = getShortName(parts[0])
+	if strings.HasPrefix(class, "$") {
+		class = parents[class]
+	}
+	for _, propName := range parts[1:] {
+		propClass := ""
+		for i := 0; class != "" && i < 32; i++ {
+			if propClass = parents[class+"::"+propName]; propClass != "" {
+				break
+			}
+			class = parents[class]
+		}
+		if propClass == "" {
+			return ""
+		}
+		class = propClass
+	}
+	return class
+}
+
+func (c *Condition) holds(args []cfg.Operand) bool {
+	if c.Arg >= len(args) {
+		return c.Missing
+	}
+	if c.Missing {
+		return false
+	}
+	arg := args[c.Arg]
+	if len(c.Flags) > 0 {
+		names, ok := cfg.GetConstNames(arg)
+		if !ok {
+			return false
+		}
+		found := false
+		for _, name := range names {
+			for _, flag := range c.Flags {
+				found = found || name == flag
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	switch expected := c.Equals.(type) {
+	case bool:
+		switch val := cfg.GetOperVal(arg).(type) {
+		case *cfg.OperandBool:
+			return val.Val == expected
+		case *cfg.OperandNumber:
+			return (val.Val != 0) == expected
+		}
+		return false
+	case float64:
+		val, ok := cfg.GetOperVal(arg).(*cfg.OperandNumber)
+		return ok && val.Val == expected
+	case string:
+		val, ok := cfg.GetConstString(arg)
+		return ok && val == expected
+	}
+	return true
+}
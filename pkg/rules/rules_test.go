@@ -0,0 +1,153 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+)
+
+func newPackRuleSet(t *testing.T, names ...string) *RuleSet {
+	t.Helper()
+	rs := Default()
+	for _, name := range names {
+		for _, pack := range packs {
+			if pack.Name == name {
+				rs.AddPack(pack)
+			}
+		}
+	}
+	return rs
+}
+
+func TestMatchUnknownReceiver(t *testing.T) {
+	tests := []struct {
+		name          string
+		call          Call
+		wantSource    bool
+		wantSanitizer bool
+	}{
+		{"typed request", Call{Class: "Illuminate\\Http\\Request", Method: "input"}, true, false},
+		{"form request", Call{Class: "App\\Http\\Requests\\StoreUser", Method: "input"}, true, false},
+		{"unknown object", Call{Method: "input"}, false, false},
+		{"other class", Call{Class: "Collection", Method: "get"}, false, false},
+		{"property of unknown class", Call{Class: "Controller::request", Method: "input"}, false, false},
+		{"global wpdb", Call{Class: "$wpdb", Method: "get_results"}, true, false},
+		{"other global", Call{Class: "$db", Method: "get_results"}, false, false},
+		{"typed purifier", Call{Class: "Mews\\Purifier\\Purifier", Method: "clean"}, false, true},
+		{"unknown purifier", Call{Method: "clean"}, false, true},
+		{"unknown wpdb", Call{Method: "prepare"}, false, true},
+		{"other class clean", Call{Class: "Collection", Method: "clean"}, false, false},
+	}
+	rs := newPackRuleSet(t, "laravel", "wordpress")
+	rs.LinkClasses(map[string]*cfg.Script{"/app/StoreUser.php": {
+		ClassParents: map[string]string{"App\\Http\\Requests\\StoreUser": "FormRequest"},
+	}})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := rs.GetSource(tt.call); got != tt.wantSource {
+				t.Errorf("GetSource() = %v, want %v", got, tt.wantSource)
+			}
+			if _, got := rs.GetSanitizer(tt.call); got != tt.wantSanitizer {
+				t.Errorf("GetSanitizer() = %v, want %v", got, tt.wantSanitizer)
+			}
+		})
+	}
+}
+
+func TestMatchUnknownReceiverSymfony(t *testing.T) {
+	tests := []struct {
+		name string
+		call Call
+		want bool
+	}{
+		{"query of typed request", Call{Class: "Symfony\\Component\\HttpFoundation\\Request::query", Method: "get"}, true},
+		{"attributes of typed request", Call{Class: "Request::attributes", Method: "all"}, true},
+		{"headers of typed request", Call{Class: "Request::headers", Method: "get"}, true},
+		{"typed parameter bag", Call{Class: "ParameterBag", Method: "get"}, true},
+		{"unknown object", Call{Method: "get"}, false},
+		{"property of other class", Call{Class: "Container::parameters", Method: "get"}, false},
+		{"other class", Call{Class: "EntityRepository", Method: "get"}, false},
+	}
+	rs := newPackRuleSet(t, "symfony")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := rs.GetSource(tt.call); got != tt.want {
+				t.Errorf("GetSource() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchUnknownReceiverSink(t *testing.T) {
+	query := cfg.NewTemporaryOperand(nil)
+	tests := []struct {
+		name  string
+		class string
+		want  bool
+	}{
+		{"global wpdb", "$wpdb", true},
+		{"unknown object", "", false},
+		{"unknown global", "$db", false},
+	}
+	rs := newPackRuleSet(t, "wordpress")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call := Call{Class: tt.class, Method: "get_var", Args: []cfg.Operand{query}}
+			if _, got := rs.GetSink(call, query); got != tt.want {
+				t.Errorf("GetSink() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchSQLMethodSink(t *testing.T) {
+	query := cfg.NewTemporaryOperand(nil)
+	tests := []struct {
+		name   string
+		class  string
+		method string
+		want   bool
+	}{
+		{"PDO query", "PDO", "query", true},
+		{"mysqli prepare", "mysqli", "prepare", true},
+		{"SQLite3 exec", "SQLite3", "exec", true},
+		{"Doctrine connection", "Doctrine\\DBAL\\Connection", "prepare", true},
+		{"mysqli execute_query", "mysqli", "execute_query", true},
+		{"subclass of PDO", "Database", "query", true},
+		{"unknown object query", "", "query", false},
+		{"other class query", "Collection", "query", false},
+		{"other class exec", "Process", "exec", false},
+		{"unknown object multi_query", "", "multi_query", true},
+	}
+	rs := Default()
+	rs.LinkClasses(map[string]*cfg.Script{"/app/Database.php": {
+		ClassParents: map[string]string{"App\\Database": "PDO"},
+	}})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call := Call{Class: tt.class, Method: tt.method, Args: []cfg.Operand{query}}
+			if _, got := rs.GetSink(call, query); got != tt.want {
+				t.Errorf("GetSink() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetCallGlobal(t *testing.T) {
+	script := cfg.NewScript(nil, "/app/plugin.php")
+	// global $wpdb; $wpdb->get_results($sql);
+	wpdb := cfg.NewTemporaryOperand(nil)
+	cfg.NewOpExprAssign(wpdb, script.GetGlobalVar("$wpdb"), nil, nil, nil)
+	methodCall := cfg.NewOpExprMethodCall(wpdb, cfg.NewOperandString("get_results"), nil, nil, nil, nil, nil)
+
+	call, ok := GetCall(methodCall)
+	if !ok {
+		t.Fatalf("GetCall() failed")
+	}
+	if call.Class != "$wpdb" {
+		t.Errorf("Class = %q, want $wpdb", call.Class)
+	}
+	if call.String() != "$wpdb->get_results()" {
+		t.Errorf("String() = %q, want $wpdb->get_results()", call.String())
+	}
+}
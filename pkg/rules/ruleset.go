@@ -0,0 +1,214 @@
+package rules
+
+import (
+	_ "embed"
+	"log"
+	"strings"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+)
+
+// Built-in sources, sinks and sanitizers
+//
+//go:embed default.json
+var defaultRules []byte
+
+// Rules of every loaded file, the first matching rule apply
+type RuleSet struct {
+	sources    []*Source
+	sinks      []*Sink
+	sanitizers []*Sanitizer
+	renderKeys []*RenderKey
+	formatters []*Formatter
+	// parent of each class, lowercased without namespace
+	parents map[string]string
+	// functions annotated with @psalm-taint-specialize, keyed by lowercased name or ::method
+	specialized map[string][]*cfg.Func
+	// names of the added framework packs
+	packs map[string]struct{}
+}
+
+func NewRuleSet() *RuleSet {
+	return &RuleSet{
+		sources:     make([]*Source, 0),
+		sinks:       make([]*Sink, 0),
+		sanitizers:  make([]*Sanitizer, 0),
+		renderKeys:  make([]*RenderKey, 0),
+		formatters:  make([]*Formatter, 0),
+		parents:     make(map[string]string),
+		specialized: make(map[string][]*cfg.Func),
+		packs:       make(map[string]struct{}),
+	}
+}
+
+// Get rule set of the built-in rule file
+func Default() *RuleSet {
+	file, err := Load(defaultRules, "default.json")
+	if err != nil {
+		log.Fatalf("Error loading default rules: %v", err)
+	}
+	rs := NewRuleSet()
+	rs.Add(file)
+	return rs
+}
+
+func (rs *RuleSet) Add(file *RuleFile) {
+	rs.sources = append(rs.sources, file.Sources...)
+	rs.sinks = append(rs.sinks, file.Sinks...)
+	rs.sanitizers = append(rs.sanitizers, file.Sanitizers...)
+	rs.renderKeys = append(rs.renderKeys, file.RenderKeys...)
+	rs.formatters = append(rs.formatters, file.Formatters...)
+	for class, parent := range file.Parents {
+		rs.parents[strings.ToLower(getShortName(class))] = strings.ToLower(getShortName(parent))
+	}
+	// Class::property is matched as subclass of the property class
+	for property, class := range file.Properties {
+		rs.parents[strings.ToLower(property)] = strings.ToLower(getShortName(class))
+	}
+	for name, class := range file.Globals {
+		rs.parents["$"+strings.ToLower(name)] = strings.ToLower(getShortName(class))
+	}
+}
+
+// Link classes declared in the scripts to their parent, so rule of a class match its subclasses.
+// Typed property is linked to its class like the properties of the rule files
+func (rs *RuleSet) LinkClasses(scripts map[string]*cfg.Script) {
+	for _, script := range scripts {
+		for class, parent := range script.ClassParents {
+			rs.parents[strings.ToLower(getShortName(class))] = strings.ToLower(getShortName(parent))
+		}
+		for property, class := range script.PropertyTypes {
+			className, propName, _ := strings.Cut(property, "::")
+			rs.parents[strings.ToLower(getShortName(className)+"::"+propName)] = strings.ToLower(getShortName(class))
+		}
+	}
+}
+
+// Resolve the interfaces and abstract classes bound in the dependency injection container to
+// the concrete class, so rule of the concrete class match call on the bound type.
+// Must be called after LinkClasses, binding to a subclass of the bound class is ignored
+func (rs *RuleSet) AddBindings(bindings map[string]string) {
+	for abstract, concrete := range bindings {
+		abstract = strings.ToLower(getShortName(abstract))
+		concrete = strings.ToLower(getShortName(concrete))
+		if abstract == concrete || isSubclassOf(concrete, abstract, rs.parents) {
+			continue
+		}
+		rs.parents[abstract] = concrete
+	}
+}
+
+// Get arguments passed by reference of the sources and sanitizers
+func (rs *RuleSet) GetByRefArgs() cfg.ByRefArgs {
+	byRefArgs := make(cfg.ByRefArgs)
+	for _, source := range rs.sources {
+		source.addByRefArgs(byRefArgs, source.ByRef)
+	}
+	for _, sanitizer := range rs.sanitizers {
+		sanitizer.addByRefArgs(byRefArgs, sanitizer.ByRef)
+	}
+	return byRefArgs
+}
+
+// Get the builtin functions and the sink functions writing to the response body
+func (rs *RuleSet) GetOutputFuncs() cfg.OutputFuncs {
+	outputFuncs := cfg.NewOutputFuncs()
+	for _, sink := range rs.sinks {
+		if sink.Output {
+			outputFuncs.Add(sink.function)
+		}
+	}
+	return outputFuncs
+}
+
+func (m *Matcher) addByRefArgs(byRefArgs cfg.ByRefArgs, args []int) {
+	if len(args) == 0 {
+		return
+	}
+	if m.function != "" {
+		byRefArgs.Add(m.function, args)
+		return
+	}
+	// class of the object is not known when building
+	byRefArgs.Add("::"+m.method, args)
+	if m.class != "*" {
+		byRefArgs.Add(m.class+"::"+m.method, args)
+	}
+}
+
+// Get tainted keys of the superglobal, nil if every key is tainted,
+// false if the superglobal is not a source
+func (rs *RuleSet) GetSuperglobalKeys(symbolicName string) ([]string, bool) {
+	found := false
+	keys := make([]string, 0)
+	for _, source := range rs.sources {
+		if source.Superglobal == "" || cfg.SuperGlobals[source.superglobalName()] != symbolicName {
+			continue
+		}
+		if len(source.Keys) == 0 {
+			return nil, true
+		}
+		found = true
+		keys = append(keys, source.Keys...)
+	}
+	return keys, found
+}
+
+func (m *Matcher) superglobalName() string {
+	return "$" + strings.TrimPrefix(m.Superglobal, "$")
+}
+
+// Get source of the call, method of unknown object is never a source
+func (rs *RuleSet) GetSource(call Call) (*Source, bool) {
+	for _, source := range rs.sources {
+		if source.Superglobal == "" && source.matchCall(call, rs.parents, false) {
+			return source, true
+		}
+	}
+	return nil, false
+}
+
+// Get sink of the call which the tainted value is passed to, method of unknown object is never a sink
+func (rs *RuleSet) GetSink(call Call, taintedVar cfg.Operand) (*Sink, bool) {
+	for _, sink := range rs.sinks {
+		if sink.matchCall(call, rs.parents, false) && sink.IsSinkArg(call.Args, taintedVar) {
+			return sink, true
+		}
+	}
+	return nil, false
+}
+
+// Get sanitizer of the call, method of unknown object is matched by the method name
+func (rs *RuleSet) GetSanitizer(call Call) (*Sanitizer, bool) {
+	for _, sanitizer := range rs.sanitizers {
+		if sanitizer.matchCall(call, rs.parents, true) {
+			return sanitizer, true
+		}
+	}
+	return nil, false
+}
+
+// Get the render array key which value is output
+func (rs *RuleSet) GetRenderKey(key string) (*RenderKey, bool) {
+	for _, renderKey := range rs.renderKeys {
+		if renderKey.Key == key {
+			return renderKey, true
+		}
+	}
+	return nil, false
+}
+
+// Check if any render array key is output, render arrays are not searched otherwise
+func (rs *RuleSet) HasRenderKeys() bool {
+	return len(rs.renderKeys) > 0
+}
+
+// Get formatter of the call which placeholder values are the array
+func (rs *RuleSet) GetFormatter(call Call, array cfg.Operand) (*Formatter, bool) {
+	for _, formatter := range rs.formatters {
+		if formatter.Arg < len(call.Args) && call.Args[formatter.Arg] == array && formatter.matchCall(call, rs.parents, false) {
+			return formatter, true
+		}
+	}
+	return nil, false
+}
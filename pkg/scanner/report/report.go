@@ -4,9 +4,15 @@ type ScanReport struct {
 	Paths struct {
 		Scanned []string `json:"scanned"`
 	} `json:"paths"`
-	TotalScanned int      `json:"total_scanned"`
-	TotalFinding int      `json:"total_finding"`
-	Results      []Result `json:"results"`
+	TotalScanned    int                `json:"total_scanned"`
+	TotalFinding    int                `json:"total_finding"`
+	TotalSuppressed int                `json:"total_suppressed"`
+	Results         []Result           `json:"results"`
+	Suppressed      []SuppressedResult `json:"suppressed"`
+	Sanitizers      []Sanitizer        `json:"inferred_sanitizers"`
+	Warnings        []string           `json:"warnings"`
+	// Framework rule packs enabled by detection
+	Packs []string `json:"packs"`
 }
 
 func NewScanReport(scannedPaths []string) *ScanReport {
@@ -19,6 +25,10 @@ func NewScanReport(scannedPaths []string) *ScanReport {
 		TotalScanned: len(scannedPaths),
 		TotalFinding: 0,
 		Results:      make([]Result, 0),
+		Suppressed:   make([]SuppressedResult, 0),
+		Sanitizers:   make([]Sanitizer, 0),
+		Warnings:     make([]string, 0),
+		Packs:        make([]string, 0),
 	}
 }
 
@@ -31,17 +41,76 @@ func (s *ScanReport) AddResult(result Result) {
 	s.TotalFinding = s.TotalFinding + 1
 }
 
+func (s *ScanReport) AddSuppressed(result Result, suppression Suppression) {
+	s.Suppressed = append(s.Suppressed, SuppressedResult{Result: result, Suppression: suppression})
+	s.TotalSuppressed = s.TotalSuppressed + 1
+}
+
+func (s *ScanReport) AddSanitizer(sanitizer Sanitizer) {
+	s.Sanitizers = append(s.Sanitizers, sanitizer)
+}
+
+func (s *ScanReport) AddWarning(warning string) {
+	s.Warnings = append(s.Warnings, warning)
+}
+
+// Finding dropped by suppression annotation
+type SuppressedResult struct {
+	Result      Result      `json:"result"`
+	Suppression Suppression `json:"suppression"`
+}
+
+// Suppression annotation and its justification
+type Suppression struct {
+	// Suppressed rule IDs, every rule if empty
+	Rules  []string `json:"rules"`
+	Reason string   `json:"reason"`
+	// Last day the suppression apply, as YYYY-MM-DD
+	Expires string `json:"expires,omitempty"`
+	// Set for @xss-taint-ignore-file
+	File bool   `json:"file"`
+	Path string `json:"path"`
+	Line int    `json:"line"`
+}
+
+// User function inferred as sanitizer, and the sanitizers it wraps
+type Sanitizer struct {
+	Name  string   `json:"name"`
+	Wraps []string `json:"wraps"`
+	Path  string   `json:"path"`
+	Start Loc      `json:"start"`
+	End   Loc      `json:"end"`
+}
+
+func NewSanitizer(name string, wraps []string, path string, start, end Loc) *Sanitizer {
+	return &Sanitizer{
+		Name:  name,
+		Wraps: wraps,
+		Path:  path,
+		Start: start,
+		End:   end,
+	}
+}
+
 type Result struct {
-	Path  string `json:"path"`
-	Start Loc    `json:"start"`
-	End   Loc    `json:"end"`
-	Extra struct {
+	CheckID  string `json:"check_id"`
+	CWE      string `json:"cwe"`
+	Severity string `json:"severity"`
+	Path     string `json:"path"`
+	Start    Loc    `json:"start"`
+	End      Loc    `json:"end"`
+	Extra    struct {
 		DataFlowTrace struct {
 			TaintSource      Node   `json:"taint_source"`
 			TaintSink        Node   `json:"taint_sink"`
 			IntermediateVars []Node `json:"intermediate_vars"`
 		} `json:"dataflow_trace"`
-		Message string `json:"message"`
+		Message     string    `json:"message"`
+		Category    string    `json:"category"`
+		Evidence    *Evidence `json:"evidence,omitempty"`
+		ContentType string    `json:"content_type,omitempty"`
+		Confidence  string    `json:"confidence"`
+		Handler     string    `json:"handler,omitempty"`
 	} `json:"extra"`
 }
 
@@ -56,7 +125,12 @@ func NewResult(start, end Loc, path string) *Result {
 				TaintSink        Node   `json:"taint_sink"`
 				IntermediateVars []Node `json:"intermediate_vars"`
 			} `json:"dataflow_trace"`
-			Message string `json:"message"`
+			Message     string    `json:"message"`
+			Category    string    `json:"category"`
+			Evidence    *Evidence `json:"evidence,omitempty"`
+			ContentType string    `json:"content_type,omitempty"`
+			Confidence  string    `json:"confidence"`
+			Handler     string    `json:"handler,omitempty"`
 		}{
 			DataFlowTrace: struct {
 				TaintSource      Node   `json:"taint_source"`
@@ -81,24 +155,61 @@ func (r *Result) AddIntermediateVar(vr Node) {
 	r.Extra.DataFlowTrace.IntermediateVars = append(r.Extra.DataFlowTrace.IntermediateVars, vr)
 }
 
+func (r *Result) SetCheckID(checkID string) {
+	r.CheckID = checkID
+}
+
+// Set the CWE and severity of the rule
+func (r *Result) SetClassification(cwe string, severity string) {
+	r.CWE = cwe
+	r.Severity = severity
+}
+
 func (r *Result) SetMessage(message string) {
 	r.Extra.Message = message
 }
 
+func (r *Result) SetCategory(category string) {
+	r.Extra.Category = category
+}
+
+func (r *Result) SetEvidence(evidence *Evidence) {
+	r.Extra.Evidence = evidence
+}
+
+// Set the response content type of the sink, lower confidence if it may not be html
+func (r *Result) SetContentType(contentType string, confidence string) {
+	r.Extra.ContentType = contentType
+	r.Extra.Confidence = confidence
+}
+
+// Set the entry point producing the response
+func (r *Result) SetHandler(handler string) {
+	r.Extra.Handler = handler
+}
+
 func (r *Result) Clone() Result {
 	intermediateVars := make([]Node, len(r.Extra.DataFlowTrace.IntermediateVars))
 	copy(intermediateVars, r.Extra.DataFlowTrace.IntermediateVars)
 	return Result{
-		Path:  r.Path,
-		Start: r.Start,
-		End:   r.End,
+		CheckID:  r.CheckID,
+		CWE:      r.CWE,
+		Severity: r.Severity,
+		Path:     r.Path,
+		Start:    r.Start,
+		End:      r.End,
 		Extra: struct {
 			DataFlowTrace struct {
 				TaintSource      Node   `json:"taint_source"`
 				TaintSink        Node   `json:"taint_sink"`
 				IntermediateVars []Node `json:"intermediate_vars"`
 			} `json:"dataflow_trace"`
-			Message string `json:"message"`
+			Message     string    `json:"message"`
+			Category    string    `json:"category"`
+			Evidence    *Evidence `json:"evidence,omitempty"`
+			ContentType string    `json:"content_type,omitempty"`
+			Confidence  string    `json:"confidence"`
+			Handler     string    `json:"handler,omitempty"`
 		}{
 			DataFlowTrace: struct {
 				TaintSource      Node   `json:"taint_source"`
@@ -109,10 +220,37 @@ func (r *Result) Clone() Result {
 				TaintSource:      r.Extra.DataFlowTrace.TaintSource,
 				TaintSink:        r.Extra.DataFlowTrace.TaintSink,
 			},
+			Message:     r.Extra.Message,
+			Category:    r.Extra.Category,
+			Evidence:    r.Extra.Evidence,
+			ContentType: r.Extra.ContentType,
+			Confidence:  r.Extra.Confidence,
+			Handler:     r.Extra.Handler,
 		},
 	}
 }
 
+// Misused sanitizer call, its flags and the context of the sink
+type Evidence struct {
+	Kind         string   `json:"kind"`
+	Sanitizer    Node     `json:"sanitizer"`
+	Flags        []string `json:"flags"`
+	MissingFlags []string `json:"missing_flags,omitempty"`
+	Context      string   `json:"context"`
+}
+
+func NewEvidence(kind string, sanitizer Node, flags []string, context string) *Evidence {
+	if flags == nil {
+		flags = make([]string, 0)
+	}
+	return &Evidence{
+		Kind:      kind,
+		Sanitizer: sanitizer,
+		Flags:     flags,
+		Context:   context,
+	}
+}
+
 type Loc struct {
 	Line   int `json:"line"`
 	Offset int `json:"offset"`
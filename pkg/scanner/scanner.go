@@ -6,13 +6,22 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
+	"time"
 
+	"github.com/rxhunter00/XSS-Taint/pkg/blade"
 	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
 	"github.com/rxhunter00/XSS-Taint/pkg/cfgtraverser"
 	"github.com/rxhunter00/XSS-Taint/pkg/cfgtraverser/simplifier"
 	"github.com/rxhunter00/XSS-Taint/pkg/cfgtraverser/sourcefinder"
+	"github.com/rxhunter00/XSS-Taint/pkg/container"
+	"github.com/rxhunter00/XSS-Taint/pkg/linker"
 	"github.com/rxhunter00/XSS-Taint/pkg/pathgenerator"
+	"github.com/rxhunter00/XSS-Taint/pkg/rules"
 	"github.com/rxhunter00/XSS-Taint/pkg/scanner/report"
+	"github.com/rxhunter00/XSS-Taint/pkg/taint"
+	"github.com/rxhunter00/XSS-Taint/pkg/templating"
+	"github.com/rxhunter00/XSS-Taint/pkg/twig"
 )
 
 type Scanner struct {
@@ -31,69 +40,122 @@ func NewScanner() *Scanner {
 	}
 }
 
-func Scan(dirPath string, filePaths []string) *report.ScanReport {
-	// build ssa form cfg for each file
-	scripts := make(map[string]*cfg.Script)
-	relPaths := make([]string, 0)
-	for _, filePath := range filePaths {
-
+func Scan(dirPath string, filePaths []string, config *pathgenerator.Config) *report.ScanReport {
+	// enable the rule packs of the detected frameworks before building
+	srcs := make([][]byte, len(filePaths))
+	packs := make([]string, 0)
+	twigCompiler := twig.NewCompiler()
+	services := container.NewContainer()
+	definitionErrs := make([]string, 0)
+	for i, filePath := range filePaths {
 		src, err := os.ReadFile(filePath)
 		if err != nil {
 			log.Fatal(err)
 		}
+		if container.IsDefinitionFile(filePath) {
+			if err := services.ReadDefinitions(filePath, src); err != nil {
+				relPath, _ := filepath.Rel(dirPath, filePath)
+				definitionErrs = append(definitionErrs, fmt.Sprintf("service definitions of %s are ignored, %v", relPath, err))
+			}
+			continue
+		}
+		srcs[i] = src
+		twigCompiler.ReadFilters(src)
+		for _, pack := range rules.DetectPacks(filePath, src) {
+			if config.RuleSet.AddPack(pack) {
+				packs = append(packs, pack.Name)
+			}
+		}
+	}
+
+	// build ssa form cfg for each file
+	byRefArgs := config.RuleSet.GetByRefArgs()
+	outputFuncs := config.RuleSet.GetOutputFuncs()
+	scripts := make(map[string]*cfg.Script)
+	relPaths := make([]string, 0)
+	suppressions := newSuppressionSet()
+	now := time.Now()
+	for i, filePath := range filePaths {
+		src := srcs[i]
+		if container.IsDefinitionFile(filePath) {
+			continue
+		}
 		relPath, err := filepath.Rel(dirPath, filePath)
 		if err != nil {
 			log.Fatal(err)
 		}
 		relPaths = append(relPaths, relPath)
 
-		script := cfg.BuildCFG(src, filePath)
+		// template is compiled to PHP, the positions are mapped back to the template
+		var sourceMap *templating.SourceMap
+		if strings.HasSuffix(filePath, linker.BLADE_EXTENSION) {
+			src, sourceMap = blade.Compile(src)
+		} else if strings.HasSuffix(filePath, linker.TWIG_EXTENSION) {
+			src, sourceMap = twigCompiler.Compile(src, filePath)
+		}
+		suppressions.addFile(src, relPath, now)
+
+		script := cfg.BuildCFG(src, filePath, outputFuncs, byRefArgs)
 
 		// OnFly
 		cfgTraverser := cfgtraverser.NewTraverser()
 		optimizer := simplifier.NewSimplifier()
-		sourceFinder := sourcefinder.NewSourceFinder()
 		cfgTraverser.AddBlockTraverser(optimizer)
 		cfgTraverser.Traverse(script)
+		if sourceMap != nil {
+			sourceMap.RemapPositions(script)
+		}
+		scripts[filePath] = script
+	}
+
+	// link value across script before finding the sources
+	linker.LinkSuperGlobals(scripts)
+	linker.LinkOutputBuffers(scripts)
+	linker.LinkHooks(scripts)
+	linker.LinkViews(scripts)
+	linker.LinkRoutes(scripts)
+	config.RuleSet.LinkClasses(scripts)
+	services.ReadProviders(scripts)
+	config.RuleSet.AddBindings(services.GetBindings(scripts))
+	annotationErrs := config.RuleSet.AddAnnotations(scripts)
 
-		cfgTraverser = cfgtraverser.NewTraverser()
+	for _, script := range scripts {
+		cfgTraverser := cfgtraverser.NewTraverser()
+		sourceFinder := sourcefinder.NewSourceFinder(config.RuleSet)
 		cfgTraverser.AddBlockTraverser(sourceFinder)
 		cfgTraverser.Traverse(script)
-		scripts[filePath] = script
 	}
 
-	paths := pathgenerator.GeneratePath(scripts)
+	sanitizers := pathgenerator.InferSanitizers(scripts, config.RuleSet)
+	paths := pathgenerator.GeneratePath(scripts, sanitizers, config)
 	newReport := report.NewScanReport(relPaths)
+	newReport.Packs = packs
 
-	for _, path := range paths {
+	for _, sanitizer := range sanitizers {
+		newReport.AddSanitizer(*SanitizerToReport(dirPath, sanitizer))
+	}
+
+	for _, taintPath := range paths {
+		path := taintPath.Ops
 		var source *report.Node
 		var sink *report.Node
 
 		traces := make([]*report.Node, 0)
 		for i := 0; i < len(path)-1; i++ {
+			isTrace := false
 			if len(traces) == 0 {
 				// source
-				switch path[i].(type) {
-				case *cfg.OpExprAssign, *cfg.OpExprArrayDimFetch, *cfg.OpExprParam:
-					if path[i].GetPosition() != nil {
-						intermVar, err := OptoReportNode(dirPath, path[i])
-						if err != nil {
-							log.Fatalf("Error converting intermediate var: %v", err)
-						}
-						traces = append(traces, intermVar)
-					}
-				}
+				isTrace = isSourceNode(path[i], i == 0)
 			} else {
-				switch path[i].(type) {
-				case *cfg.OpExprAssign, *cfg.OpExprFunctionCall, *cfg.OpExprMethodCall, *cfg.OpExprStaticCall, *cfg.OpEcho, *cfg.OpExprPrint:
-					if path[i].GetPosition() != nil {
-						intermVar, err := OptoReportNode(dirPath, path[i])
-						if err != nil {
-							log.Fatalf("Error converting intermediate var: %v", err)
-						}
-						traces = append(traces, intermVar)
-					}
+				// the last op is the sink, such as return or render array which isn't an intermediate var
+				isTrace = isIntermediateNode(path[i]) || i == len(path)-2
+			}
+			if isTrace && path[i].GetPosition() != nil {
+				intermVar, err := OptoReportNode(dirPath, path[i])
+				if err != nil {
+					log.Fatalf("Error converting intermediate var: %v", err)
 				}
+				traces = append(traces, intermVar)
 			}
 		}
 		if len(traces) > 0 {
@@ -105,14 +167,153 @@ func Scan(dirPath string, filePaths []string) *report.ScanReport {
 			for i := 1; i < len(traces)-1; i++ {
 				result.AddIntermediateVar(*traces[i])
 			}
-			result.SetMessage("XSS vulnerability")
-			newReport.AddResult(*result)
+			result.SetCheckID(string(taintPath.Sink.Rule))
+			if rule := taint.GetRule(taintPath.Sink.Rule); rule != nil {
+				result.SetClassification(rule.CWE, string(rule.Severity))
+			}
+			result.SetMessage(getMessage(taintPath))
+			result.SetCategory(string(taintPath.Sink.Rule))
+			if taintPath.Sink.Rule == taint.RULE_XSS {
+				result.SetCategory(CATEGORY_XSS)
+				if taintPath.Context.IsJavaScriptURL(taintPath.Labels) {
+					result.SetCategory(CATEGORY_JAVASCRIPT_URL)
+				}
+				if taintPath.Misuse != nil {
+					result.SetCategory(CATEGORY_SANITIZER_MISUSE)
+				}
+				result.SetContentType(getContentType(taintPath))
+			} else {
+				result.SetContentType("", CONFIDENCE_HIGH)
+			}
+			if taintPath.Misuse != nil {
+				result.SetEvidence(MisuseToEvidence(dirPath, taintPath))
+			}
+			result.SetHandler(taintPath.Sink.Handler)
+			if s := suppressions.match(result); s != nil {
+				newReport.AddSuppressed(*result, s.Suppression)
+			} else {
+				newReport.AddResult(*result)
+			}
 		}
 	}
+	for _, err := range annotationErrs {
+		if relPath, relErr := filepath.Rel(dirPath, err.File); relErr == nil {
+			err.File = relPath
+		}
+		newReport.AddWarning(fmt.Sprintf("taint annotation is ignored, %v", err))
+	}
+	for _, warning := range definitionErrs {
+		newReport.AddWarning(warning)
+	}
+	for _, warning := range suppressions.getWarnings(config.Rules) {
+		newReport.AddWarning(warning)
+	}
 
 	return newReport
 }
 
+const (
+	CATEGORY_XSS              = "xss"
+	CATEGORY_SANITIZER_MISUSE = "sanitizer-misuse"
+	CATEGORY_JAVASCRIPT_URL   = "javascript-url"
+)
+
+const (
+	CONFIDENCE_HIGH = "high"
+	CONFIDENCE_LOW  = "low"
+)
+
+// Check if op can be reported as the source, source call such as filter_input() or
+// $request->get() is only reported at the start of the path
+func isSourceNode(op cfg.Op, isFirst bool) bool {
+	switch op.(type) {
+	case *cfg.OpExprAssign, *cfg.OpExprArrayDimFetch, *cfg.OpExprParam, *cfg.OpExprKey, *cfg.OpExprValue:
+		return true
+	case *cfg.OpExprFunctionCall, *cfg.OpExprMethodCall, *cfg.OpExprStaticCall:
+		return isFirst
+	}
+	return false
+}
+
+// Check if op is reported as intermediate var of the path
+func isIntermediateNode(op cfg.Op) bool {
+	switch op.(type) {
+	case *cfg.OpExprAssign, *cfg.OpExprAssignRef, *cfg.OpExprArrayDimAssign, *cfg.OpExprFunctionCall, *cfg.OpExprMethodCall, *cfg.OpExprStaticCall, *cfg.OpEcho, *cfg.OpExprPrint:
+		return true
+	}
+	return false
+}
+
+func getMessage(taintPath pathgenerator.TaintPath) string {
+	switch taintPath.Sink.Rule {
+	case taint.RULE_OPEN_REDIRECT:
+		return fmt.Sprintf("Open redirect, %s value controls the target host of %s", taintPath.Labels, taintPath.Sink.Target)
+	case taint.RULE_HEADER_INJECTION:
+		return fmt.Sprintf("Response header injection, %s value without CRLF stripping is used in %s", taintPath.Labels, taintPath.Sink.Target)
+	case taint.RULE_EMAIL_XSS:
+		if taintPath.Misuse != nil {
+			return fmt.Sprintf("HTML email injection in %s, %s", taintPath.Sink.Target, taintPath.Misuse.Message)
+		}
+		return fmt.Sprintf("HTML email injection, %s value is not safe in %s of %s", taintPath.Labels, taintPath.Context, taintPath.Sink.Target)
+	case taint.RULE_SQL_INJECTION:
+		if taint.GetSQLQuote(taintPath.Sink.Prefix) != 0 {
+			return fmt.Sprintf("SQL injection, %s value is not escaped inside quoted string of %s", taintPath.Labels, taintPath.Sink.Target)
+		}
+		return fmt.Sprintf("SQL injection, %s value is used unquoted in %s", taintPath.Labels, taintPath.Sink.Target)
+	case taint.RULE_COMMAND_INJECTION:
+		return fmt.Sprintf("OS command injection, %s value without shell escaping is used in %s", taintPath.Labels, taintPath.Sink.Target)
+	case taint.RULE_CODE_INJECTION:
+		return fmt.Sprintf("Code injection, %s value is evaluated as PHP code by %s", taintPath.Labels, taintPath.Sink.Target)
+	case taint.RULE_FILE_INCLUSION:
+		if taintPath.Sink.Prefix == "" {
+			return fmt.Sprintf("Remote file inclusion, %s value controls the whole path of %s", taintPath.Labels, taintPath.Sink.Target)
+		}
+		return fmt.Sprintf("Local file inclusion, %s value can traverse out of the directory of %s", taintPath.Labels, taintPath.Sink.Target)
+	}
+	if taintPath.Misuse != nil {
+		return taintPath.Misuse.Message
+	}
+	if taintPath.Context.IsJavaScriptURL(taintPath.Labels) {
+		return fmt.Sprintf("JavaScript URL injection, scheme of %s value is not validated in %s", taintPath.Labels, taintPath.Context)
+	}
+	if taintPath.Sink.Handler != "" {
+		return fmt.Sprintf("XSS vulnerability in response of %s, %s value is not safe in %s of %s", taintPath.Sink.Handler, taintPath.Labels, taintPath.Context, taintPath.Sink.Target)
+	}
+	if taintPath.Labels == taint.LABELS_RAW {
+		return "XSS vulnerability"
+	}
+	return fmt.Sprintf("XSS vulnerability, %s value is not safe in %s", taintPath.Labels, taintPath.Context)
+}
+
+// Get content type of the sink response, confidence is low if it may not be html
+func getContentType(taintPath pathgenerator.TaintPath) (string, string) {
+	if len(taintPath.ContentTypes) == 0 {
+		return taint.CONTENT_TYPE_DEFAULT, CONFIDENCE_HIGH
+	}
+	confidence := CONFIDENCE_HIGH
+	for _, contentType := range taintPath.ContentTypes {
+		if contentType == taint.CONTENT_TYPE_UNKNOWN || !taint.IsHTMLContentType(contentType) {
+			confidence = CONFIDENCE_LOW
+		}
+	}
+	return strings.Join(taintPath.ContentTypes, "|"), confidence
+}
+
+func MisuseToEvidence(dirPath string, taintPath pathgenerator.TaintPath) *report.Evidence {
+	misuse := taintPath.Misuse
+	sanitizer := report.Node{}
+	if misuse.Sanitizer.GetPosition() != nil {
+		node, err := OptoReportNode(dirPath, misuse.Sanitizer)
+		if err != nil {
+			log.Fatalf("Error converting sanitizer: %v", err)
+		}
+		sanitizer = *node
+	}
+	evidence := report.NewEvidence(string(misuse.Kind), sanitizer, misuse.Flags, taintPath.Context.String())
+	evidence.MissingFlags = misuse.MissingFlags
+	return evidence
+}
+
 func OptoReportNode(dirPath string, op cfg.Op) (*report.Node, error) {
 	// read the content based on op position
 	filePath := op.GetFilePath()
@@ -134,6 +335,40 @@ func OptoReportNode(dirPath string, op cfg.Op) (*report.Node, error) {
 	return report.NewCodeNode(string(content), relPath, startLoc, endLoc), nil
 }
 
+func SanitizerToReport(dirPath string, sanitizer *pathgenerator.Sanitizer) *report.Sanitizer {
+	wraps := make([]string, 0)
+	seen := make(map[string]struct{})
+	for _, op := range sanitizer.Wraps {
+		name := op.GetType()
+		switch opT := op.(type) {
+		case *cfg.OpExprFunctionCall:
+			name, _ = cfg.GetOperandName(opT.Name)
+		case *cfg.OpExprStaticCall:
+			className, _ := cfg.GetOperandName(opT.Class)
+			methodName, _ := cfg.GetOperandName(opT.Name)
+			name = className + "::" + methodName
+		case *cfg.OpExprMethodCall:
+			methodName, _ := cfg.GetOperandName(opT.Name)
+			name = "->" + methodName
+		}
+		if _, ok := seen[name]; !ok {
+			seen[name] = struct{}{}
+			wraps = append(wraps, name)
+		}
+	}
+
+	relPath, err := filepath.Rel(dirPath, sanitizer.Script.Filepath)
+	if err != nil {
+		relPath = sanitizer.Script.Filepath
+	}
+	var start, end report.Loc
+	if pos := sanitizer.Func.GetPosition(); pos != nil {
+		start = report.NewLoc(pos.StartLine, pos.StartPos)
+		end = report.NewLoc(pos.EndLine, pos.EndPos)
+	}
+	return report.NewSanitizer(sanitizer.Func.GetScopedName(), wraps, relPath, start, end)
+}
+
 func GetFileContent(filePath string, endPos, startPos int) string {
 	file, err := os.Open(filePath)
 	if err != nil {
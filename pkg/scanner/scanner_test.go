@@ -0,0 +1,90 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/pathgenerator"
+	"github.com/rxhunter00/XSS-Taint/pkg/rules"
+)
+
+// Scan the PHP sources of each directory in testdata
+func TestScan(t *testing.T) {
+	tests := []struct {
+		dir string
+		// category, file and line of the sink of each finding
+		want []string
+	}{
+		{"annotations", []string{"sanitizer-misuse index.php:20"}},
+		{"content-type", []string{"xss index.php:8"}},
+		{"contexts", []string{"javascript-url index.php:3", "sanitizer-misuse index.php:5", "sanitizer-misuse index.php:6", "xss index.php:7"}},
+		{"controllers", []string{"xss index.php:6", "xss index.php:16"}},
+		{"drupal", []string{"xss GreetingController.php:14", "xss GreetingController.php:16", "xss GreetingController.php:17"}},
+		{"dynamic-vars", []string{"xss index.php:4", "xss index.php:7", "xss index.php:15", "xss index.php:19"}},
+		{"email", []string{"email-xss index.php:3"}},
+		{"filters", []string{"xss index.php:3", "xss index.php:9"}},
+		{"foreach", []string{"xss index.php:2", "xss index.php:5"}},
+		{"hooks", []string{"xss plugin.php:7", "xss plugin.php:19"}},
+		{"json-script", []string{"sanitizer-misuse index.php:3", "sanitizer-misuse index.php:5"}},
+		{"laravel", []string{"xss profile.blade.php:2"}},
+		{"misuse", []string{"sanitizer-misuse index.php:3", "sanitizer-misuse index.php:4"}},
+		{"output-buffer", []string{"xss index.php:5"}},
+		{"output-streams", []string{"xss index.php:3", "xss index.php:6"}},
+		{"providers", []string{"xss index.php:30"}},
+		{"redirects", []string{"open-redirect index.php:2", "header-injection index.php:4"}},
+		{"rule-files", []string{"xss index.php:3", "sql-injection index.php:5", "xss index.php:7"}},
+		{"sanitizer-wrappers", []string{"xss index.php:13", "sanitizer-misuse index.php:14"}},
+		{"sql-methods", []string{"sql-injection index.php:4", "sql-injection index.php:7"}},
+		{"superglobal-writes", []string{"xss index.php:4", "xss index.php:12", "xss index.php:14"}},
+		{"suppressions", []string{"xss index.php:4", "xss index.php:6"}},
+		{"symfony", []string{"xss ProfileController.php:17", "xss profile.html.twig:2", "xss profile.html.twig:4"}},
+		{"url-attributes", []string{"javascript-url index.php:3"}},
+		{"wordpress", []string{"xss plugin.php:6", "xss plugin.php:8", "sql-injection plugin.php:13"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			dirPath, err := filepath.Abs(filepath.Join("testdata", tt.dir))
+			if err != nil {
+				t.Fatal(err)
+			}
+			filePaths, err := filepath.Glob(filepath.Join(dirPath, "*"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// rules.json of the directory is added to the built-in rules
+			config := pathgenerator.NewConfig()
+			for i, filePath := range filePaths {
+				if filepath.Base(filePath) != "rules.json" {
+					continue
+				}
+				file, err := rules.LoadFile(filePath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				config.RuleSet.Add(file)
+				filePaths = append(filePaths[:i], filePaths[i+1:]...)
+				break
+			}
+			scanReport := Scan(dirPath, filePaths, config)
+			results := scanReport.Results
+			sort.SliceStable(results, func(i, j int) bool {
+				a, b := results[i].Extra.DataFlowTrace.TaintSink.Location, results[j].Extra.DataFlowTrace.TaintSink.Location
+				if a.Path != b.Path {
+					return a.Path < b.Path
+				}
+				return a.Start.Line < b.Start.Line
+			})
+			got := make([]string, 0, len(results))
+			for _, result := range results {
+				sink := result.Extra.DataFlowTrace.TaintSink.Location
+				got = append(got, fmt.Sprintf("%s %s:%d", result.Extra.Category, sink.Path, sink.Start.Line))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
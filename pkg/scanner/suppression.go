@@ -0,0 +1,187 @@
+package scanner
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/asttraverser/astutils"
+	"github.com/rxhunter00/XSS-Taint/pkg/scanner/report"
+	"github.com/rxhunter00/XSS-Taint/pkg/taint"
+)
+
+// Suppression annotation in comment, such as
+//
+//	// xss-taint-ignore[xss]: escaped by the template
+//	// xss-taint-ignore[sql-injection] expires=2026-12-31: fixed in the next release
+//	/* @xss-taint-ignore-file: generated code */
+//
+// without rule IDs every rule is suppressed
+var suppressionPattern = regexp.MustCompile(`(@xss-taint-ignore-file|xss-taint-ignore)(?:\[([^\]]*)\])?(?:\s+expires=([^\s:]+))?\s*(?::(.*))?`)
+
+const EXPIRES_LAYOUT = "2006-01-02"
+
+type suppression struct {
+	report.Suppression
+	// Lines of the comment
+	startLine int
+	endLine   int
+	used      bool
+}
+
+// Suppressions of the scanned files
+type suppressionSet struct {
+	byPath   map[string][]*suppression
+	warnings []string
+}
+
+func newSuppressionSet() *suppressionSet {
+	return &suppressionSet{
+		byPath:   make(map[string][]*suppression),
+		warnings: make([]string, 0),
+	}
+}
+
+func (set *suppressionSet) addWarning(path string, line int, format string, args ...interface{}) {
+	set.warnings = append(set.warnings, fmt.Sprintf("%s:%d: ", path, line)+fmt.Sprintf(format, args...))
+}
+
+// Read suppression annotations in the comments of the file
+func (set *suppressionSet) addFile(src []byte, relPath string, now time.Time) {
+	for _, comment := range astutils.GetComments(src, astutils.NewParserConfig(nil)) {
+		if comment.Position == nil {
+			continue
+		}
+		for i, line := range strings.Split(string(comment.Value), "\n") {
+			match := suppressionPattern.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			lineNum := comment.Position.StartLine + i
+			s := &suppression{
+				Suppression: report.Suppression{
+					Rules:   make([]string, 0),
+					Reason:  strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(match[4]), "*/")),
+					Expires: match[3],
+					File:    match[1] == "@xss-taint-ignore-file",
+					Path:    relPath,
+					Line:    lineNum,
+				},
+				startLine: comment.Position.StartLine,
+				endLine:   comment.Position.EndLine,
+			}
+			if set.isValid(s, match[2], now) {
+				set.byPath[relPath] = append(set.byPath[relPath], s)
+			}
+		}
+	}
+}
+
+// Check the rule IDs, reason and expiry of the suppression, warn if it is not applied
+func (set *suppressionSet) isValid(s *suppression, ruleList string, now time.Time) bool {
+	for _, id := range strings.Split(ruleList, ",") {
+		if id = strings.TrimSpace(id); id == "" {
+			continue
+		}
+		if taint.GetRule(taint.RuleID(id)) == nil {
+			set.addWarning(s.Path, s.Line, "suppression of unknown rule '%s' is ignored", id)
+			return false
+		}
+		s.Rules = append(s.Rules, id)
+	}
+	if s.Reason == "" {
+		set.addWarning(s.Path, s.Line, "suppression without reason is ignored")
+		return false
+	}
+	if s.Expires != "" {
+		expires, err := time.ParseInLocation(EXPIRES_LAYOUT, s.Expires, now.Location())
+		if err != nil {
+			set.addWarning(s.Path, s.Line, "suppression with invalid expiry date '%s' is ignored, expected YYYY-MM-DD", s.Expires)
+			return false
+		}
+		// the suppression apply until the end of the day
+		if !now.Before(expires.AddDate(0, 0, 1)) {
+			set.addWarning(s.Path, s.Line, "suppression expired on %s", s.Expires)
+			return false
+		}
+	}
+	return true
+}
+
+// Get the suppression of the result at its source or sink, nil if not suppressed
+func (set *suppressionSet) match(result *report.Result) *suppression {
+	nodes := []report.Node{result.Extra.DataFlowTrace.TaintSink, result.Extra.DataFlowTrace.TaintSource}
+	for _, node := range nodes {
+		for _, s := range set.byPath[node.Location.Path] {
+			if !s.hasRule(result.CheckID) {
+				continue
+			}
+			if s.File || s.isNextTo(node) {
+				s.used = true
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+func (s *suppression) hasRule(ruleID string) bool {
+	if len(s.Rules) == 0 {
+		return true
+	}
+	for _, id := range s.Rules {
+		if id == ruleID {
+			return true
+		}
+	}
+	return false
+}
+
+// Check if every rule of the suppression is checked, suppression of unchecked rule can't match
+func (s *suppression) isChecked(rules []taint.RuleID) bool {
+	if len(rules) == 0 {
+		return true
+	}
+	if len(s.Rules) == 0 {
+		return false
+	}
+	for _, id := range s.Rules {
+		checked := false
+		for _, rule := range rules {
+			checked = checked || string(rule) == id
+		}
+		if !checked {
+			return false
+		}
+	}
+	return true
+}
+
+// Comment on the same line as the statement or on the line before
+func (s *suppression) isNextTo(node report.Node) bool {
+	start := node.Location.Start.Line
+	end := node.Location.End.Line
+	return (s.startLine >= start && s.startLine <= end) || s.endLine == start-1
+}
+
+// Get warnings of ignored annotations and of suppressions which no longer match any finding
+// of the rules checked in the scan, every rule is checked if rules is empty
+func (set *suppressionSet) getWarnings(rules []taint.RuleID) []string {
+	warnings := make([]string, len(set.warnings))
+	copy(warnings, set.warnings)
+	paths := make([]string, 0, len(set.byPath))
+	for path := range set.byPath {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		for _, s := range set.byPath[path] {
+			if !s.used && s.isChecked(rules) {
+				warnings = append(warnings, fmt.Sprintf("%s:%d: stale suppression doesn't match any finding", s.Path, s.Line))
+			}
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,164 @@
+package scanner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/scanner/report"
+	"github.com/rxhunter00/XSS-Taint/pkg/taint"
+)
+
+func TestSuppressionPattern(t *testing.T) {
+	tests := []struct {
+		line    string
+		match   bool
+		file    bool
+		rules   string
+		expires string
+		reason  string
+	}{
+		{"// xss-taint-ignore[xss]: escaped by the template", true, false, "xss", "", " escaped by the template"},
+		{"// xss-taint-ignore: trusted input", true, false, "", "", " trusted input"},
+		{"// xss-taint-ignore[sql-injection, xss] expires=2026-12-31: fixed soon", true, false, "sql-injection, xss", "2026-12-31", " fixed soon"},
+		{"/* @xss-taint-ignore-file: generated code */", true, true, "", "", " generated code */"},
+		{"// xss-taint-ignore", true, false, "", "", ""},
+		{"// phpcs:ignore", false, false, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			match := suppressionPattern.FindStringSubmatch(tt.line)
+			if (match != nil) != tt.match {
+				t.Fatalf("match = %v, want %v", match != nil, tt.match)
+			}
+			if match == nil {
+				return
+			}
+			if file := match[1] == "@xss-taint-ignore-file"; file != tt.file {
+				t.Errorf("file = %v, want %v", file, tt.file)
+			}
+			if match[2] != tt.rules || match[3] != tt.expires || match[4] != tt.reason {
+				t.Errorf("rules, expires, reason = %q, %q, %q, want %q, %q, %q", match[2], match[3], match[4], tt.rules, tt.expires, tt.reason)
+			}
+		})
+	}
+}
+
+func TestSuppressionIsValid(t *testing.T) {
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		rules     string
+		reason    string
+		expires   string
+		want      bool
+		wantRules int
+	}{
+		{"every rule", "", "trusted", "", true, 0},
+		{"listed rules", "xss, open-redirect", "trusted", "", true, 2},
+		{"unknown rule", "xss,csrf", "trusted", "", false, 0},
+		{"no reason", "xss", "", "", false, 0},
+		{"not expired", "xss", "trusted", "2026-06-30", true, 1},
+		{"expires today", "xss", "trusted", "2026-06-15", true, 1},
+		{"expired", "xss", "trusted", "2026-06-14", false, 0},
+		{"invalid expiry", "xss", "trusted", "15/06/2026", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := newSuppressionSet()
+			s := &suppression{Suppression: report.Suppression{Rules: make([]string, 0), Reason: tt.reason, Expires: tt.expires, Path: "index.php", Line: 3}}
+			if got := set.isValid(s, tt.rules, now); got != tt.want {
+				t.Fatalf("isValid() = %v, want %v", got, tt.want)
+			}
+			if tt.want && len(s.Rules) != tt.wantRules {
+				t.Errorf("Rules = %v, want %d rules", s.Rules, tt.wantRules)
+			}
+			if !tt.want && len(set.warnings) != 1 {
+				t.Errorf("warnings = %v, want one", set.warnings)
+			}
+		})
+	}
+}
+
+func newSuppressedResult(checkID string, sinkPath string, sinkLine int, sourcePath string, sourceLine int) *report.Result {
+	result := report.NewResult(report.NewLoc(sinkLine, 0), report.NewLoc(sinkLine, 0), sinkPath)
+	result.CheckID = checkID
+	result.Extra.DataFlowTrace.TaintSink = *report.NewCodeNode("echo $name;", sinkPath, report.NewLoc(sinkLine, 0), report.NewLoc(sinkLine, 0))
+	result.Extra.DataFlowTrace.TaintSource = *report.NewCodeNode("$_GET['name']", sourcePath, report.NewLoc(sourceLine, 0), report.NewLoc(sourceLine, 0))
+	return result
+}
+
+func TestSuppressionMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		s      suppression
+		result *report.Result
+		want   bool
+	}{
+		{"line before sink", suppression{startLine: 9, endLine: 9}, newSuppressedResult("xss", "index.php", 10, "index.php", 2), true},
+		{"same line as sink", suppression{startLine: 10, endLine: 10}, newSuppressedResult("xss", "index.php", 10, "index.php", 2), true},
+		{"line before source", suppression{startLine: 1, endLine: 1}, newSuppressedResult("xss", "index.php", 10, "index.php", 2), true},
+		{"block comment before sink", suppression{startLine: 6, endLine: 9}, newSuppressedResult("xss", "index.php", 10, "index.php", 2), true},
+		{"two lines before sink", suppression{startLine: 8, endLine: 8}, newSuppressedResult("xss", "index.php", 10, "index.php", 2), false},
+		{"other file", suppression{startLine: 9, endLine: 9}, newSuppressedResult("xss", "view.php", 10, "view.php", 2), false},
+		{"whole file", suppression{Suppression: report.Suppression{File: true}, startLine: 1, endLine: 1}, newSuppressedResult("xss", "index.php", 10, "index.php", 2), true},
+		{"listed rule", suppression{Suppression: report.Suppression{Rules: []string{"xss"}}, startLine: 9, endLine: 9}, newSuppressedResult("xss", "index.php", 10, "index.php", 2), true},
+		{"other rule", suppression{Suppression: report.Suppression{Rules: []string{"sql-injection"}}, startLine: 9, endLine: 9}, newSuppressedResult("xss", "index.php", 10, "index.php", 2), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := newSuppressionSet()
+			s := tt.s
+			s.Path = "index.php"
+			set.byPath["index.php"] = []*suppression{&s}
+			got := set.match(tt.result)
+			if (got != nil) != tt.want {
+				t.Fatalf("match() = %v, want %v", got != nil, tt.want)
+			}
+			if s.used != tt.want {
+				t.Errorf("used = %v, want %v", s.used, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuppressionWarnings(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []taint.RuleID
+		want  []string
+	}{
+		{"every rule checked", nil, []string{
+			"index.php:1: suppression without reason is ignored",
+			"view.php:7: stale suppression doesn't match any finding",
+			"view.php:9: stale suppression doesn't match any finding",
+		}},
+		{"listed rule checked", []taint.RuleID{taint.RULE_XSS}, []string{
+			"index.php:1: suppression without reason is ignored",
+			"view.php:9: stale suppression doesn't match any finding",
+		}},
+		{"other rule checked", []taint.RuleID{taint.RULE_SQL_INJECTION}, []string{
+			"index.php:1: suppression without reason is ignored",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := newSuppressionSet()
+			set.addWarning("index.php", 1, "suppression without reason is ignored")
+			used := &suppression{Suppression: report.Suppression{Path: "index.php", Line: 3}, used: true}
+			stale := &suppression{Suppression: report.Suppression{Path: "view.php", Line: 7}}
+			staleXSS := &suppression{Suppression: report.Suppression{Rules: []string{"xss"}, Path: "view.php", Line: 9}}
+			set.byPath["index.php"] = []*suppression{used}
+			set.byPath["view.php"] = []*suppression{stale, staleXSS}
+
+			got := set.getWarnings(tt.rules)
+			if len(got) != len(tt.want) {
+				t.Fatalf("getWarnings() = %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("getWarnings()[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
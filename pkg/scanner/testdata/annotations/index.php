@@ -0,0 +1,21 @@
+<?php
+/**
+ * @psalm-taint-escape html
+ */
+function strip_markup($s)
+{
+    return preg_replace('/<[^>]*>/', '', $s);
+}
+
+/**
+ * @psalm-taint-escape html
+ * @psalm-taint-escape has_quotes
+ */
+function escape_attr($s)
+{
+    return str_replace(['<', '>', '"', "'"], '', $s);
+}
+
+echo '<p>' . strip_markup($_GET['a']) . '</p>';
+echo '<p title="' . strip_markup($_GET['b']) . '">';
+echo '<p title="' . escape_attr($_GET['c']) . '">';
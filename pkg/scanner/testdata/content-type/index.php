@@ -0,0 +1,8 @@
+<?php
+if (isset($_GET['json'])) {
+    header('Content-Type: application/json');
+    echo $_GET['json'];
+    exit;
+}
+header('Content-Type: text/html; charset=utf-8');
+echo $_GET['name'];
@@ -0,0 +1,7 @@
+<?php
+$u = $_GET['u'];
+echo "<a href=\"" . htmlspecialchars($u) . "\">link</a>";
+echo "<a title=\"" . htmlspecialchars($u) . "\">link</a>";
+echo '<a title=\'' . htmlspecialchars($u, ENT_NOQUOTES) . '\'>link</a>';
+echo "<script>var s = \"" . json_encode($u) . "\";</script>";
+echo "<script>var s = \"" . $u . "\";</script>";
@@ -0,0 +1,18 @@
+<?php
+class ProfileController
+{
+    public function show()
+    {
+        return '<h1>' . $_GET['name'] . '</h1>';
+    }
+
+    public function json()
+    {
+        return new JsonResponse(['name' => $_GET['name']]);
+    }
+
+    public function page()
+    {
+        return new Response('<h1>' . $_GET['name'] . '</h1>');
+    }
+}
@@ -0,0 +1,20 @@
+<?php
+namespace Drupal\greeting\Controller;
+
+use Drupal\Core\Controller\ControllerBase;
+use Drupal\Core\Render\Markup;
+
+class GreetingController extends ControllerBase
+{
+    public function greet()
+    {
+        $name = \Drupal::request()->query->get('name');
+        return [
+            'title' => ['#markup' => $name],
+            'message' => ['#children' => $name],
+            'greeting' => ['#markup' => $this->t('Hello @name', ['@name' => $name])],
+            'raw' => ['#markup' => $this->t('Hello !name', ['!name' => $name])],
+            'banner' => ['#markup' => Markup::create('<b>' . $name . '</b>')],
+        ];
+    }
+}
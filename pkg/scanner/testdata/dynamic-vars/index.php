@@ -0,0 +1,24 @@
+<?php
+extract($_POST);
+$$key = 'value';
+echo $name;
+
+$GLOBALS['title'] = $_GET['title'];
+echo $title;
+
+function setMessage()
+{
+    global $message;
+    $message = $_GET['message'];
+}
+setMessage();
+echo $message;
+
+$user = $_GET['user'];
+$vars = compact('user');
+echo $vars['user'];
+
+$safe = 'Hello';
+extract(['safe' => 'World'], EXTR_SKIP);
+$safe = 'Hi';
+echo $safe;
@@ -0,0 +1,4 @@
+<?php
+$headers = "MIME-Version: 1.0\r\nContent-Type: text/html; charset=UTF-8\r\n";
+mail('admin@example.com', 'Feedback', '<p>' . $_POST['message'] . '</p>', $headers);
+mail('admin@example.com', 'Feedback', $_POST['message']);
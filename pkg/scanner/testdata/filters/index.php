@@ -0,0 +1,10 @@
+<?php
+echo filter_input(INPUT_GET, 'id', FILTER_VALIDATE_INT);
+echo filter_var($_GET['name'], FILTER_UNSAFE_RAW);
+echo filter_var($_GET['name'], FILTER_CALLBACK, ['options' => 'htmlspecialchars']);
+echo filter_var($_GET['name'], FILTER_SANITIZE_SPECIAL_CHARS);
+
+$data = filter_input_array(INPUT_POST, ['age' => FILTER_VALIDATE_INT, 'bio' => FILTER_DEFAULT]);
+echo $data['age'];
+echo $data['bio'];
+echo $data['other'];
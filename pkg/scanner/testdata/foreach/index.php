@@ -0,0 +1,11 @@
+<?php
+foreach ($_GET as $k => $v) echo $k;
+
+foreach ($_POST['items'] as $item) {
+    echo $item;
+}
+
+$rows = [['name' => 'safe']];
+foreach ($rows as $row) {
+    echo $row['name'];
+}
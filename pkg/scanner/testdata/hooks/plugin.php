@@ -0,0 +1,20 @@
+<?php
+/*
+ * Plugin Name: Hooks
+ */
+function hooks_show_notice($message)
+{
+    echo '<div class="notice">' . $message . '</div>';
+}
+add_action('hooks_notice', 'hooks_show_notice');
+
+function hooks_add_suffix($title)
+{
+    return $title . ' - ' . $_GET['suffix'];
+}
+add_filter('hooks_title', 'hooks_add_suffix');
+
+do_action('hooks_notice', $_GET['message']);
+do_action('hooks_other_notice', $_GET['message']);
+echo '<h1>' . apply_filters('hooks_title', 'Welcome') . '</h1>';
+echo '<h2>' . apply_filters('hooks_subtitle', 'Welcome') . '</h2>';
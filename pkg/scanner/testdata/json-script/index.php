@@ -0,0 +1,5 @@
+<?php
+$config = $_GET['config'];
+echo '<script>var config = ' . json_encode($config) . ';</script>';
+echo '<script>var config = ' . json_encode($config, JSON_HEX_TAG | JSON_HEX_AMP | JSON_HEX_APOS | JSON_HEX_QUOT) . ';</script>';
+echo '<div data-config="' . json_encode($config) . '"></div>';
@@ -0,0 +1,15 @@
+<?php
+namespace App\Http\Controllers;
+
+use Illuminate\Http\Request;
+
+class ProfileController extends Controller
+{
+    public function show(Request $request)
+    {
+        return view('profile', [
+            'name' => $request->input('name'),
+            'bio' => e($request->input('bio')),
+        ]);
+    }
+}
@@ -0,0 +1,3 @@
+<h1>{{ $name }}</h1>
+<div>{!! $name !!}</div>
+<div>{!! $bio !!}</div>
@@ -0,0 +1,5 @@
+<?php
+$title = $_GET['title'];
+echo "<a title='" . htmlspecialchars($title, ENT_NOQUOTES) . "'>link</a>";
+echo '<a title="' . strip_tags($title) . '">link</a>';
+echo '<a title="' . htmlspecialchars($title) . '">link</a>';
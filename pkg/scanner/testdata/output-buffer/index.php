@@ -0,0 +1,9 @@
+<?php
+ob_start();
+printf('<p>%s</p>', $_GET['name']);
+$html = ob_get_clean();
+echo $html;
+
+ob_start();
+echo $_GET['q'];
+ob_end_clean();
@@ -0,0 +1,6 @@
+<?php
+$out = fopen('php://output', 'w');
+fwrite($out, $_GET['a']);
+fprintf(STDOUT, '%s', $_GET['b']);
+fwrite(fopen('php://stdout', 'w'), $_GET['c']);
+file_put_contents('php://output', $_GET['d']);
@@ -0,0 +1,14 @@
+<?php
+namespace App\Providers;
+
+use App\Html\Output;
+use App\Html\RawOutput;
+use Illuminate\Support\ServiceProvider;
+
+class AppServiceProvider extends ServiceProvider
+{
+    public function register()
+    {
+        $this->app->bind(Output::class, RawOutput::class);
+    }
+}
@@ -0,0 +1,14 @@
+<?php
+namespace App\Http;
+
+use App\Html\EscapedOutput;
+use App\Html\Output;
+
+class Kernel
+{
+    public function bootstrap()
+    {
+        // not the container of a service provider
+        $this->app->bind(Output::class, EscapedOutput::class);
+    }
+}
@@ -0,0 +1,31 @@
+<?php
+namespace App\Html;
+
+interface Output
+{
+    public function write($html);
+}
+
+class RawOutput implements Output
+{
+    /**
+     * @psalm-taint-sink html $html
+     */
+    public function write($html)
+    {
+        echo $html;
+    }
+}
+
+class EscapedOutput implements Output
+{
+    public function write($html)
+    {
+        echo htmlspecialchars($html);
+    }
+}
+
+function show(Output $out)
+{
+    $out->write($_GET['name']);
+}
@@ -0,0 +1,5 @@
+<?php
+header('Location: ' . $_GET['next']);
+header('Location: /account?tab=' . urlencode($_GET['tab']));
+header('X-Referrer: ' . $_GET['ref']);
+header('X-Page: ' . str_replace(["\r", "\n"], '', $_GET['page']));
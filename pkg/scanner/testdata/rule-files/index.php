@@ -0,0 +1,7 @@
+<?php
+$name = read_param('name');
+render_html('<p>' . $name . '</p>');
+render_html('<p>' . clean_html($name) . '</p>');
+run_query($db, 'SELECT * FROM users WHERE name = ' . $name);
+run_query('SELECT * FROM users WHERE name = ' . $name, $db);
+render_html(read_param('title'));
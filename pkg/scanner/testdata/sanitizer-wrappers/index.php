@@ -0,0 +1,14 @@
+<?php
+function e($s)
+{
+    return htmlspecialchars($s);
+}
+
+function label($s)
+{
+    return trim($s);
+}
+
+echo e($_GET['name']);
+echo label($_GET['name']);
+echo '<div class=' . e($_GET['class']) . '>';
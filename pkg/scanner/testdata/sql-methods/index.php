@@ -0,0 +1,10 @@
+<?php
+$id = $_GET['id'];
+$pdo = new PDO('sqlite::memory:');
+$pdo->query('SELECT * FROM users WHERE id = ' . $id);
+
+$db = new mysqli('localhost', 'user', 'password', 'app');
+$db->prepare("SELECT * FROM users WHERE name = '" . $id . "'");
+
+// class of the object isn't known
+$cache->exec('DELETE ' . $id);
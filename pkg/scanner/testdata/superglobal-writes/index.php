@@ -0,0 +1,14 @@
+<?php
+$_GET['page'] = (int) $_GET['page'];
+echo $_GET['page'];
+echo $_GET['query'];
+
+$_POST = array_map('htmlspecialchars', $_POST);
+echo $_POST['name'];
+
+function show()
+{
+    // may be called before the overwrite
+    echo $_GET['page'];
+}
+echo $_COOKIE['theme'];
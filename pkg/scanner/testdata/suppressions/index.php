@@ -0,0 +1,6 @@
+<?php
+// xss-taint-ignore[xss]: value is escaped by the reverse proxy
+echo $_GET['a'];
+echo $_GET['b'];
+// xss-taint-ignore[sql-injection]: other rule
+echo $_GET['c'];
@@ -0,0 +1,19 @@
+<?php
+namespace App\Controller;
+
+use Symfony\Bundle\FrameworkBundle\Controller\AbstractController;
+use Symfony\Component\HttpFoundation\Request;
+use Symfony\Component\HttpFoundation\Response;
+
+class ProfileController extends AbstractController
+{
+    public function show(Request $request)
+    {
+        return $this->render('profile.html.twig', ['name' => $request->query->get('name')]);
+    }
+
+    public function greet(Request $request)
+    {
+        return new Response('<p>Hello ' . $request->query->get('name') . '</p>');
+    }
+}
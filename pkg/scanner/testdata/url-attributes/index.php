@@ -0,0 +1,7 @@
+<?php
+$url = $_GET['url'];
+echo '<a href="' . htmlspecialchars($url) . '">link</a>';
+if (preg_match('#^https?://#', $url)) {
+    echo '<a href="' . htmlspecialchars($url) . '">link</a>';
+}
+echo '<a href="/search?q=' . urlencode($url) . '">link</a>';
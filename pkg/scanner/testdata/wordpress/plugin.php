@@ -0,0 +1,15 @@
+<?php
+/*
+ * Plugin Name: Greeting
+ */
+$greeting = get_option('greeting');
+echo '<p>' . $greeting . '</p>';
+echo '<p>' . esc_html($greeting) . '</p>';
+_e($_GET['message']);
+
+function greeting_find_posts()
+{
+    global $wpdb;
+    $wpdb->get_results("SELECT * FROM {$wpdb->posts} WHERE post_title = '" . $_GET['title'] . "'");
+    $wpdb->get_results($wpdb->prepare("SELECT * FROM {$wpdb->posts} WHERE post_title = %s", $_GET['title']));
+}
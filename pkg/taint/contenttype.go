@@ -0,0 +1,34 @@
+package taint
+
+import "strings"
+
+const (
+	CONTENT_TYPE_DEFAULT = "text/html"
+	// content type set by header() with non constant value
+	CONTENT_TYPE_UNKNOWN = "unknown"
+)
+
+// Content type which browser render as html or can run script in
+var htmlContentTypes = map[string]struct{}{
+	"text/html": {}, "application/xhtml+xml": {}, "image/svg+xml": {},
+	"text/xml": {}, "application/xml": {}, CONTENT_TYPE_UNKNOWN: {},
+}
+
+func IsHTMLContentType(contentType string) bool {
+	_, ok := htmlContentTypes[strings.ToLower(contentType)]
+	return ok
+}
+
+// Check if output can be rendered as html with any of the content types,
+// no content type is the default text/html
+func CanRenderHTML(contentTypes []string) bool {
+	if len(contentTypes) == 0 {
+		return true
+	}
+	for _, contentType := range contentTypes {
+		if IsHTMLContentType(contentType) {
+			return true
+		}
+	}
+	return false
+}
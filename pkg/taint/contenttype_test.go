@@ -0,0 +1,28 @@
+package taint
+
+import "testing"
+
+func TestCanRenderHTML(t *testing.T) {
+	tests := []struct {
+		name         string
+		contentTypes []string
+		want         bool
+	}{
+		{"never set", nil, true},
+		{"html", []string{"text/html"}, true},
+		{"json", []string{"application/json"}, false},
+		{"plain text", []string{"text/plain"}, false},
+		{"xhtml", []string{"application/xhtml+xml"}, true},
+		{"svg", []string{"image/svg+xml"}, true},
+		{"json or html", []string{"application/json", "text/html"}, true},
+		{"json or unknown", []string{"application/json", CONTENT_TYPE_UNKNOWN}, true},
+		{"uppercase", []string{"TEXT/HTML"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanRenderHTML(tt.contentTypes); got != tt.want {
+				t.Errorf("CanRenderHTML(%v) = %v, want %v", tt.contentTypes, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package taint
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Where the tainted value end up in the html document
+type ContextKind int
+
+const (
+	CONTEXT_HTML_TEXT ContextKind = iota
+	CONTEXT_HTML_COMMENT
+	// inside a tag, outside attribute value
+	CONTEXT_TAG
+	CONTEXT_ATTR
+	// start of URL attribute value, such as href
+	CONTEXT_URL_ATTR
+	// javascript or css attribute value, such as onclick or style
+	CONTEXT_SCRIPT_ATTR
+	CONTEXT_SCRIPT
+	CONTEXT_SCRIPT_STRING
+	CONTEXT_STYLE
+)
+
+type Context struct {
+	Kind ContextKind
+	// Quote of attribute value or script string, 0 if unquoted
+	Quote byte
+	// Attribute name
+	Attr string
+}
+
+var urlAttrs = map[string]struct{}{
+	"href": {}, "src": {}, "action": {}, "formaction": {}, "xlink:href": {}, "data": {},
+	"poster": {}, "background": {}, "cite": {}, "codebase": {}, "longdesc": {}, "manifest": {},
+}
+
+func (c Context) String() string {
+	quote := "unquoted"
+	switch c.Quote {
+	case '"':
+		quote = "double-quoted"
+	case '\'':
+		quote = "single-quoted"
+	case '`':
+		quote = "backtick-quoted"
+	}
+	switch c.Kind {
+	case CONTEXT_HTML_COMMENT:
+		return "html comment"
+	case CONTEXT_TAG:
+		return "html tag"
+	case CONTEXT_ATTR:
+		return fmt.Sprintf("%s attribute '%s'", quote, c.Attr)
+	case CONTEXT_URL_ATTR:
+		return fmt.Sprintf("%s url attribute '%s'", quote, c.Attr)
+	case CONTEXT_SCRIPT_ATTR:
+		return fmt.Sprintf("%s script attribute '%s'", quote, c.Attr)
+	case CONTEXT_SCRIPT:
+		return "script"
+	case CONTEXT_SCRIPT_STRING:
+		return fmt.Sprintf("%s script string", quote)
+	case CONTEXT_STYLE:
+		return "style"
+	}
+	return "html text"
+}
+
+// Check if value with the labels can not break out of the context
+func (c Context) Accepts(labels Labels) bool {
+	if labels.Has(LABEL_NUMERIC) {
+		return true
+	}
+	if labels.Has(LABEL_JS_ESCAPED) && c.acceptsJSON(labels) {
+		return true
+	}
+	accepted := LABEL_SAFE_CHARSET | LABEL_URL_ENCODED
+	switch c.Kind {
+	case CONTEXT_HTML_TEXT, CONTEXT_HTML_COMMENT:
+		accepted |= LABELS_HTML | LABEL_EMAIL
+		if c.Kind == CONTEXT_HTML_TEXT {
+			accepted |= LABEL_HTML_SANITIZED
+		}
+	case CONTEXT_ATTR, CONTEXT_SCRIPT_STRING:
+		accepted |= quoteEscapeLabels(c.Quote)
+		if c.Quote == '"' {
+			accepted |= LABEL_EMAIL
+		}
+	case CONTEXT_URL_ATTR:
+		// escaping doesn't prevent javascript: URL
+		if labels.Has(LABEL_URL_SCHEME_CHECKED) {
+			accepted |= quoteEscapeLabels(c.Quote)
+		}
+	case CONTEXT_SCRIPT:
+		return false
+	}
+	return labels.Has(accepted)
+}
+
+// Check if value with the labels can't break out of the URL attribute,
+// but can start a javascript: URL because its scheme is not validated
+func (c Context) IsJavaScriptURL(labels Labels) bool {
+	return c.Kind == CONTEXT_URL_ATTR && !c.Accepts(labels) && labels.Has(quoteEscapeLabels(c.Quote))
+}
+
+// Html escaping labels which encode the quote
+func quoteEscapeLabels(quote byte) Labels {
+	switch quote {
+	case '"':
+		return LABEL_HTML_ESCAPED | LABEL_HTML_ESCAPED_DQUOTES
+	case '\'':
+		return LABEL_HTML_ESCAPED
+	}
+	return LABELS_RAW
+}
+
+// Get JSON_HEX_* labels json_encode output need to not break out of the context,
+// false if no flags make it safe
+func (c Context) requiredJSONLabels() (Labels, bool) {
+	// json_encode encloses strings in literal double quotes which no flag encodes,
+	// so only the single quoted context can be made safe, with JSON_HEX_APOS
+	quoteLabel := LABELS_RAW
+	if c.Quote == '\'' {
+		quoteLabel = LABEL_JSON_HEX_APOS
+	}
+	switch c.Kind {
+	case CONTEXT_HTML_TEXT, CONTEXT_HTML_COMMENT, CONTEXT_SCRIPT:
+		// </script> or -->
+		return LABEL_JSON_HEX_TAG, true
+	case CONTEXT_SCRIPT_STRING:
+		// </script> ends the script even inside the string
+		return LABEL_JSON_HEX_TAG | quoteLabel, quoteLabel != LABELS_RAW
+	case CONTEXT_ATTR:
+		return quoteLabel, quoteLabel != LABELS_RAW
+	case CONTEXT_SCRIPT_ATTR:
+		// entities are decoded before the script run
+		return LABEL_JSON_HEX_AMP | quoteLabel, quoteLabel != LABELS_RAW
+	}
+	return LABELS_RAW, false
+}
+
+func (c Context) acceptsJSON(labels Labels) bool {
+	if c.Kind == CONTEXT_SCRIPT_ATTR && labels.Has(quoteEscapeLabels(c.Quote)) {
+		return true
+	}
+	required, ok := c.requiredJSONLabels()
+	return ok && labels&required == required
+}
+
+// Get JSON_HEX_* flags missing for json_encode output to be safe in the context,
+// false if no flags make it safe
+func (c Context) MissingJSONFlags(labels Labels) ([]string, bool) {
+	required, ok := c.requiredJSONLabels()
+	if !ok {
+		return nil, false
+	}
+	missing := make([]string, 0)
+	for _, hf := range jsonHexFlags {
+		if required.Has(hf.label) && !labels.Has(hf.label) {
+			missing = append(missing, hf.flag)
+		}
+	}
+	return missing, true
+}
+
+// Get context at the end of html prefix
+func GetContext(prefix string) Context {
+	const (
+		stText = iota
+		stComment
+		stTag
+		stAttrValue
+		stScript
+		stScriptString
+		stStyle
+	)
+	state := stText
+	tagName := ""
+	attrName := ""
+	var quote byte
+	valueStart := 0
+
+	s := prefix
+	lower := strings.ToLower(prefix)
+	for i := 0; i < len(s); {
+		switch state {
+		case stText:
+			if strings.HasPrefix(s[i:], "<!--") {
+				state = stComment
+				i += 4
+			} else if s[i] == '<' && i+1 < len(s) && isLetter(s[i+1]) {
+				j := i + 1
+				for j < len(s) && !isSpace(s[j]) && s[j] != '>' && s[j] != '/' {
+					j++
+				}
+				tagName = lower[i+1 : j]
+				state = stTag
+				i = j
+			} else {
+				i++
+			}
+		case stComment:
+			if strings.HasPrefix(s[i:], "-->") {
+				state = stText
+				i += 3
+			} else {
+				i++
+			}
+		case stTag:
+			if s[i] == '>' {
+				state = stText
+				switch tagName {
+				case "script":
+					state = stScript
+				case "style":
+					state = stStyle
+				}
+				i++
+			} else if isSpace(s[i]) || s[i] == '/' {
+				i++
+			} else {
+				j := i
+				for j < len(s) && !isSpace(s[j]) && s[j] != '=' && s[j] != '>' && s[j] != '/' {
+					j++
+				}
+				attrName = lower[i:j]
+				for j < len(s) && isSpace(s[j]) {
+					j++
+				}
+				if j < len(s) && s[j] == '=' {
+					j++
+					for j < len(s) && isSpace(s[j]) {
+						j++
+					}
+					state = stAttrValue
+					quote = 0
+					if j < len(s) && (s[j] == '"' || s[j] == '\'') {
+						quote = s[j]
+						j++
+					}
+					valueStart = j
+				} else if j == len(s) {
+					// attribute name is not finished
+					attrName = ""
+				}
+				i = j
+			}
+		case stAttrValue:
+			if quote != 0 && s[i] == quote {
+				state = stTag
+				i++
+			} else if quote == 0 && (isSpace(s[i]) || s[i] == '>') {
+				state = stTag
+			} else {
+				i++
+			}
+		case stScript:
+			if strings.HasPrefix(lower[i:], "</script") {
+				state = stText
+				i += len("</script")
+				for i < len(s) && s[i] != '>' {
+					i++
+				}
+				i++
+			} else if s[i] == '"' || s[i] == '\'' || s[i] == '`' {
+				state = stScriptString
+				quote = s[i]
+				i++
+			} else if strings.HasPrefix(s[i:], "//") {
+				for i < len(s) && s[i] != '\n' {
+					i++
+				}
+			} else {
+				i++
+			}
+		case stScriptString:
+			if s[i] == '\\' {
+				i += 2
+			} else if s[i] == quote {
+				state = stScript
+				i++
+			} else if strings.HasPrefix(lower[i:], "</script") {
+				state = stScript
+			} else {
+				i++
+			}
+		case stStyle:
+			if strings.HasPrefix(lower[i:], "</style") {
+				state = stText
+				i += len("</style")
+				for i < len(s) && s[i] != '>' {
+					i++
+				}
+				i++
+			} else {
+				i++
+			}
+		}
+	}
+
+	switch state {
+	case stComment:
+		return Context{Kind: CONTEXT_HTML_COMMENT}
+	case stTag:
+		return Context{Kind: CONTEXT_TAG}
+	case stAttrValue:
+		value := ""
+		if valueStart <= len(s) {
+			value = strings.TrimSpace(lower[valueStart:])
+		}
+		if strings.HasPrefix(attrName, "on") || attrName == "style" || strings.HasPrefix(value, "javascript:") {
+			return Context{Kind: CONTEXT_SCRIPT_ATTR, Quote: quote, Attr: attrName}
+		}
+		if _, ok := urlAttrs[attrName]; ok && canStartUnsafeScheme(value) {
+			return Context{Kind: CONTEXT_URL_ATTR, Quote: quote, Attr: attrName}
+		}
+		return Context{Kind: CONTEXT_ATTR, Quote: quote, Attr: attrName}
+	case stScript:
+		return Context{Kind: CONTEXT_SCRIPT}
+	case stScriptString:
+		return Context{Kind: CONTEXT_SCRIPT_STRING, Quote: quote}
+	case stStyle:
+		return Context{Kind: CONTEXT_STYLE}
+	}
+	return Context{Kind: CONTEXT_HTML_TEXT}
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
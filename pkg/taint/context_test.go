@@ -0,0 +1,110 @@
+package taint
+
+import "testing"
+
+func TestGetContext(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   Context
+	}{
+		{"", Context{Kind: CONTEXT_HTML_TEXT}},
+		{"<p>Hello ", Context{Kind: CONTEXT_HTML_TEXT}},
+		{"<!-- ", Context{Kind: CONTEXT_HTML_COMMENT}},
+		{"<!-- x --><p>", Context{Kind: CONTEXT_HTML_TEXT}},
+		{"<div ", Context{Kind: CONTEXT_TAG}},
+		{`<div title="`, Context{Kind: CONTEXT_ATTR, Quote: '"', Attr: "title"}},
+		{`<div title='`, Context{Kind: CONTEXT_ATTR, Quote: '\'', Attr: "title"}},
+		{`<div title=`, Context{Kind: CONTEXT_ATTR, Attr: "title"}},
+		{`<div title="x">`, Context{Kind: CONTEXT_HTML_TEXT}},
+		{`<a href="`, Context{Kind: CONTEXT_URL_ATTR, Quote: '"', Attr: "href"}},
+		{`<button onclick="`, Context{Kind: CONTEXT_SCRIPT_ATTR, Quote: '"', Attr: "onclick"}},
+		{`<script>var x = `, Context{Kind: CONTEXT_SCRIPT}},
+		{`<script>var x = '`, Context{Kind: CONTEXT_SCRIPT_STRING, Quote: '\''}},
+		{`<script>var x = "`, Context{Kind: CONTEXT_SCRIPT_STRING, Quote: '"'}},
+		{`<script>var x = 1;</script><p>`, Context{Kind: CONTEXT_HTML_TEXT}},
+		{`<style>`, Context{Kind: CONTEXT_STYLE}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			if got := GetContext(tt.prefix); got != tt.want {
+				t.Errorf("GetContext() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextAccepts(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		labels Labels
+		want   bool
+	}{
+		{"raw in html text", "<p>", LABELS_RAW, false},
+		{"escaped in html text", "<p>", LABEL_HTML_ESCAPED, true},
+		{"no quotes escaped in html text", "<p>", LABEL_HTML_ESCAPED_NOQUOTES, true},
+		{"no quotes escaped in attribute", `<div title="`, LABEL_HTML_ESCAPED_NOQUOTES, false},
+		{"double quotes escaped in double quoted attribute", `<div title="`, LABEL_HTML_ESCAPED_DQUOTES, true},
+		{"double quotes escaped in single quoted attribute", `<div title='`, LABEL_HTML_ESCAPED_DQUOTES, false},
+		{"escaped in unquoted attribute", `<div title=`, LABEL_HTML_ESCAPED, false},
+		{"escaped in href", `<a href="`, LABEL_HTML_ESCAPED, false},
+		{"escaped scheme checked in href", `<a href="`, LABEL_HTML_ESCAPED | LABEL_URL_SCHEME_CHECKED, true},
+		{"numeric in script", "<script>var x = ", LABEL_NUMERIC, true},
+		{"escaped in script", "<script>var x = ", LABEL_HTML_ESCAPED, false},
+		{"sanitized html in html text", "<p>", LABEL_HTML_SANITIZED, true},
+		{"sanitized html in attribute", `<div title="`, LABEL_HTML_SANITIZED, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetContext(tt.prefix).Accepts(tt.labels); got != tt.want {
+				t.Errorf("Accepts(%s) = %v, want %v", tt.labels, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextAcceptsJSON(t *testing.T) {
+	const jsonDefault = LABEL_JS_ESCAPED
+	allHex := LABEL_JSON_HEX_TAG | LABEL_JSON_HEX_AMP | LABEL_JSON_HEX_APOS | LABEL_JSON_HEX_QUOT
+	tests := []struct {
+		name   string
+		prefix string
+		labels Labels
+		want   bool
+		// JSON_HEX_* flags reported missing, nil if no flags make it safe
+		wantMissing []string
+	}{
+		{"default flags in script", "<script>var x = ", jsonDefault, false, []string{"JSON_HEX_TAG"}},
+		{"hex tag in script", "<script>var x = ", jsonDefault | LABEL_JSON_HEX_TAG, true, []string{}},
+		{"hex tag in html text", "<p>", jsonDefault | LABEL_JSON_HEX_TAG, true, []string{}},
+		{"hex apos in single quoted script string", "<script>var x = '", jsonDefault | LABEL_JSON_HEX_APOS, false, []string{"JSON_HEX_TAG"}},
+		{"hex tag and apos in single quoted script string", "<script>var x = '", jsonDefault | LABEL_JSON_HEX_TAG | LABEL_JSON_HEX_APOS, true, []string{}},
+		// json_encode output starts with a literal double quote
+		{"every flag in double quoted script string", `<script>var x = "`, jsonDefault | allHex, false, nil},
+		{"hex quot in double quoted attribute", `<div data-x="`, jsonDefault | LABEL_JSON_HEX_QUOT, false, nil},
+		{"hex apos in single quoted attribute", `<div data-x='`, jsonDefault | LABEL_JSON_HEX_APOS, true, []string{}},
+		{"every flag in double quoted event handler", `<button onclick="`, jsonDefault | allHex, false, nil},
+		{"hex amp and apos in single quoted event handler", `<button onclick='`, jsonDefault | LABEL_JSON_HEX_AMP | LABEL_JSON_HEX_APOS, true, []string{}},
+		{"escaped after json_encode in double quoted event handler", `<button onclick="`, jsonDefault | LABEL_HTML_ESCAPED, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context := GetContext(tt.prefix)
+			if got := context.Accepts(tt.labels); got != tt.want {
+				t.Errorf("Accepts(%s) = %v, want %v", tt.labels, got, tt.want)
+			}
+			missing, ok := context.MissingJSONFlags(tt.labels)
+			if ok != (tt.wantMissing != nil) {
+				t.Fatalf("MissingJSONFlags() ok = %v, want %v", ok, tt.wantMissing != nil)
+			}
+			if len(missing) != len(tt.wantMissing) {
+				t.Fatalf("MissingJSONFlags() = %v, want %v", missing, tt.wantMissing)
+			}
+			for i, flag := range tt.wantMissing {
+				if missing[i] != flag {
+					t.Errorf("MissingJSONFlags()[%d] = %s, want %s", i, missing[i], flag)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package taint
+
+import (
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+)
+
+// What a filter of the filter extension does to a tainted value
+type FilterEffect int
+
+const (
+	// value returned as is
+	FILTER_EFFECT_RAW FilterEffect = iota
+	// only digits, sign, and number separators
+	FILTER_EFFECT_NUMERIC
+	// true, false or null
+	FILTER_EFFECT_BOOL
+	// restricted charset such as IP, MAC address or hostname
+	FILTER_EFFECT_CHARSET
+	// email address, can not contain <, > or "
+	FILTER_EFFECT_EMAIL
+	// valid URL, can still be javascript: URL or contain <, > and "
+	FILTER_EFFECT_URL
+	// <, >, & and quotes encoded
+	FILTER_EFFECT_HTML_ESCAPED
+	// <, >, & encoded or tags stripped, quotes left as is
+	FILTER_EFFECT_HTML_ESCAPED_NOQUOTES
+	// percent encoded
+	FILTER_EFFECT_URL_ENCODED
+	// value passed to user callback
+	FILTER_EFFECT_CALLBACK
+)
+
+// Filter applied to a value
+type Filter struct {
+	Name   string
+	Flags  []string
+	Effect FilterEffect
+	// Name of the FILTER_CALLBACK function
+	Callback string
+}
+
+// Filter applied by call of filter_var, filter_input, filter_var_array or filter_input_array
+type FilterCall struct {
+	// Input value, nil for filter_input
+	Value cfg.Operand
+	// Filter applied to the value, or to every entries when array definition is not used
+	Filter *Filter
+	// Filter of each key in array definition, other keys are not returned
+	Keys map[string]*Filter
+}
+
+// Get labels of value filtered by the filter, callback is not applied. Validate filter return
+// the value unchanged or false, so the label only tells what the valid value can contain
+func (f *Filter) Labels() Labels {
+	switch f.Effect {
+	case FILTER_EFFECT_NUMERIC, FILTER_EFFECT_BOOL:
+		return LABEL_NUMERIC
+	case FILTER_EFFECT_CHARSET:
+		return LABEL_SAFE_CHARSET
+	case FILTER_EFFECT_EMAIL:
+		return LABEL_EMAIL
+	case FILTER_EFFECT_URL:
+		return LABEL_URL_VALIDATED
+	case FILTER_EFFECT_HTML_ESCAPED:
+		return LABEL_HTML_ESCAPED
+	case FILTER_EFFECT_HTML_ESCAPED_NOQUOTES:
+		return LABEL_HTML_ESCAPED_NOQUOTES
+	case FILTER_EFFECT_URL_ENCODED:
+		return LABEL_URL_ENCODED
+	}
+	return LABELS_RAW
+}
+
+func (f *Filter) hasFlag(flag string) bool {
+	for _, fl := range f.Flags {
+		if fl == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// Get filter call of filter extension function, nil if funcName is not filter function
+func ResolveFilterCall(funcName string, args []cfg.Operand) *FilterCall {
+	switch funcName {
+	case "filter_var":
+		// filter_var($value, $filter = FILTER_DEFAULT, $options = 0)
+		call := &FilterCall{Value: getArg(args, 0)}
+		call.Filter = NewFilter(getArg(args, 1), getArg(args, 2))
+		return call
+	case "filter_input":
+		// filter_input($type, $var_name, $filter = FILTER_DEFAULT, $options = 0)
+		call := &FilterCall{}
+		call.Filter = NewFilter(getArg(args, 2), getArg(args, 3))
+		return call
+	case "filter_var_array":
+		// filter_var_array($array, $options = FILTER_DEFAULT, $add_empty = true)
+		call := &FilterCall{Value: getArg(args, 0)}
+		call.resolveDefinition(getArg(args, 1))
+		return call
+	case "filter_input_array":
+		// filter_input_array($type, $options = FILTER_DEFAULT, $add_empty = true)
+		call := &FilterCall{}
+		call.resolveDefinition(getArg(args, 1))
+		return call
+	}
+	return nil
+}
+
+// Get filter from filter id and its options
+func NewFilter(filterOper, optionsOper cfg.Operand) *Filter {
+	f := &Filter{Name: "FILTER_DEFAULT"}
+	if filterOper != nil {
+		names, ok := cfg.GetConstNames(filterOper)
+		if !ok || len(names) != 1 {
+			// unknown filter, assume nothing
+			f.Name = ""
+			f.Effect = FILTER_EFFECT_RAW
+			return f
+		}
+		f.Name = names[0]
+	}
+	if optionsOper != nil {
+		f.resolveOptions(optionsOper)
+	}
+	f.resolveEffect()
+	return f
+}
+
+// Options is either flags or array with 'flags' and 'options' key
+func (f *Filter) resolveOptions(optionsOper cfg.Operand) {
+	if names, ok := cfg.GetConstNames(optionsOper); ok {
+		f.Flags = append(f.Flags, names...)
+		return
+	}
+	arr, ok := cfg.GetArrayLiteral(optionsOper)
+	if !ok {
+		return
+	}
+	for i, keyOper := range arr.Keys {
+		key, ok := cfg.GetConstString(keyOper)
+		if !ok {
+			continue
+		}
+		switch key {
+		case "flags":
+			if names, ok := cfg.GetConstNames(arr.Vals[i]); ok {
+				f.Flags = append(f.Flags, names...)
+			}
+		case "options":
+			// FILTER_CALLBACK take the callable as options
+			if callback, ok := cfg.GetConstString(arr.Vals[i]); ok {
+				f.Callback = callback
+			}
+		}
+	}
+}
+
+func (f *Filter) resolveEffect() {
+	switch f.Name {
+	case "FILTER_VALIDATE_INT", "FILTER_VALIDATE_FLOAT":
+		f.Effect = FILTER_EFFECT_NUMERIC
+	case "FILTER_VALIDATE_BOOL", "FILTER_VALIDATE_BOOLEAN":
+		f.Effect = FILTER_EFFECT_BOOL
+	case "FILTER_VALIDATE_IP", "FILTER_VALIDATE_MAC":
+		f.Effect = FILTER_EFFECT_CHARSET
+	case "FILTER_VALIDATE_DOMAIN":
+		// without FILTER_FLAG_HOSTNAME any character is allowed
+		f.Effect = FILTER_EFFECT_RAW
+		if f.hasFlag("FILTER_FLAG_HOSTNAME") {
+			f.Effect = FILTER_EFFECT_CHARSET
+		}
+	case "FILTER_VALIDATE_EMAIL":
+		f.Effect = FILTER_EFFECT_EMAIL
+	case "FILTER_VALIDATE_URL":
+		f.Effect = FILTER_EFFECT_URL
+	case "FILTER_VALIDATE_REGEXP":
+		f.Effect = FILTER_EFFECT_RAW
+	case "FILTER_SANITIZE_NUMBER_INT", "FILTER_SANITIZE_NUMBER_FLOAT":
+		f.Effect = FILTER_EFFECT_NUMERIC
+	case "FILTER_SANITIZE_SPECIAL_CHARS":
+		f.Effect = FILTER_EFFECT_HTML_ESCAPED
+	case "FILTER_SANITIZE_FULL_SPECIAL_CHARS", "FILTER_SANITIZE_STRING", "FILTER_SANITIZE_STRIPPED":
+		f.Effect = FILTER_EFFECT_HTML_ESCAPED
+		if f.hasFlag("FILTER_FLAG_NO_ENCODE_QUOTES") {
+			f.Effect = FILTER_EFFECT_HTML_ESCAPED_NOQUOTES
+		}
+	case "FILTER_SANITIZE_ENCODED":
+		f.Effect = FILTER_EFFECT_URL_ENCODED
+	case "FILTER_SANITIZE_EMAIL":
+		f.Effect = FILTER_EFFECT_EMAIL
+	case "FILTER_CALLBACK":
+		f.Effect = FILTER_EFFECT_CALLBACK
+	default:
+		// FILTER_DEFAULT, FILTER_UNSAFE_RAW, FILTER_SANITIZE_URL, FILTER_SANITIZE_ADD_SLASHES
+		f.Effect = FILTER_EFFECT_RAW
+	}
+}
+
+// Definition is either a filter for every entries, or array of key => filter or options array
+func (call *FilterCall) resolveDefinition(defOper cfg.Operand) {
+	if defOper == nil {
+		call.Filter = NewFilter(nil, nil)
+		return
+	}
+	arr, ok := cfg.GetArrayLiteral(defOper)
+	if !ok {
+		call.Filter = NewFilter(defOper, nil)
+		return
+	}
+	call.Keys = make(map[string]*Filter)
+	for i, keyOper := range arr.Keys {
+		key, ok := cfg.GetConstString(keyOper)
+		if !ok {
+			// unknown key, assume any key can be returned raw
+			call.Keys = nil
+			call.Filter = &Filter{Effect: FILTER_EFFECT_RAW}
+			return
+		}
+		call.Keys[key] = newDefinitionFilter(arr.Vals[i])
+	}
+}
+
+// Definition entry is either a filter or array with 'filter', 'flags' and 'options' key
+func newDefinitionFilter(entryOper cfg.Operand) *Filter {
+	arr, ok := cfg.GetArrayLiteral(entryOper)
+	if !ok {
+		return NewFilter(entryOper, nil)
+	}
+	var filterOper cfg.Operand
+	for i, keyOper := range arr.Keys {
+		if key, ok := cfg.GetConstString(keyOper); ok && key == "filter" {
+			filterOper = arr.Vals[i]
+		}
+	}
+	return NewFilter(filterOper, entryOper)
+}
+
+func getArg(args []cfg.Operand, i int) cfg.Operand {
+	if i < len(args) {
+		return args[i]
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package taint
+
+import (
+	"testing"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+)
+
+func constFetch(names ...string) cfg.Operand {
+	var oper cfg.Operand
+	for _, name := range names {
+		fetch := cfg.NewOpExprConstFetch(cfg.NewOperandString(name), nil)
+		if oper == nil {
+			oper = fetch.Result
+			continue
+		}
+		oper = cfg.NewOpExprBinaryBitwiseOr(oper, fetch.Result, nil).Result
+	}
+	return oper
+}
+
+func arrayLiteral(keys []string, vals []cfg.Operand) cfg.Operand {
+	keyOpers := make([]cfg.Operand, 0, len(keys))
+	for _, key := range keys {
+		keyOpers = append(keyOpers, cfg.NewOperandString(key))
+	}
+	return cfg.NewOpExprArray(keyOpers, vals, make([]bool, len(vals)), nil).Result
+}
+
+func TestNewFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  cfg.Operand
+		options cfg.Operand
+		want    Labels
+	}{
+		{"default filter", nil, nil, LABELS_RAW},
+		{"unknown filter", cfg.NewTemporaryOperand(nil), nil, LABELS_RAW},
+		{"validate int", constFetch("FILTER_VALIDATE_INT"), nil, LABEL_NUMERIC},
+		{"validate bool", constFetch("FILTER_VALIDATE_BOOLEAN"), nil, LABEL_NUMERIC},
+		{"validate email", constFetch("FILTER_VALIDATE_EMAIL"), nil, LABEL_EMAIL},
+		{"validate url", constFetch("FILTER_VALIDATE_URL"), nil, LABEL_URL_VALIDATED},
+		{"validate regexp", constFetch("FILTER_VALIDATE_REGEXP"), nil, LABELS_RAW},
+		{"validate domain", constFetch("FILTER_VALIDATE_DOMAIN"), nil, LABELS_RAW},
+		{"validate hostname", constFetch("FILTER_VALIDATE_DOMAIN"), constFetch("FILTER_FLAG_HOSTNAME"), LABEL_SAFE_CHARSET},
+		{
+			"validate hostname in options array",
+			constFetch("FILTER_VALIDATE_DOMAIN"),
+			arrayLiteral([]string{"flags"}, []cfg.Operand{constFetch("FILTER_FLAG_HOSTNAME")}),
+			LABEL_SAFE_CHARSET,
+		},
+		{"namespaced constant", constFetch("\\FILTER_VALIDATE_INT"), nil, LABEL_NUMERIC},
+		{"sanitize number", constFetch("FILTER_SANITIZE_NUMBER_INT"), nil, LABEL_NUMERIC},
+		{"sanitize special chars", constFetch("FILTER_SANITIZE_SPECIAL_CHARS"), nil, LABEL_HTML_ESCAPED},
+		{"sanitize full special chars", constFetch("FILTER_SANITIZE_FULL_SPECIAL_CHARS"), nil, LABEL_HTML_ESCAPED},
+		{
+			"sanitize full special chars without quotes",
+			constFetch("FILTER_SANITIZE_FULL_SPECIAL_CHARS"),
+			constFetch("FILTER_FLAG_NO_ENCODE_QUOTES"),
+			LABEL_HTML_ESCAPED_NOQUOTES,
+		},
+		{"sanitize url", constFetch("FILTER_SANITIZE_URL"), nil, LABELS_RAW},
+		{"unsafe raw", constFetch("FILTER_UNSAFE_RAW"), constFetch("FILTER_FLAG_STRIP_LOW", "FILTER_FLAG_STRIP_HIGH"), LABELS_RAW},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewFilter(tt.filter, tt.options).Labels(); got != tt.want {
+				t.Errorf("Labels() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterCallback(t *testing.T) {
+	options := arrayLiteral([]string{"options"}, []cfg.Operand{cfg.NewOperandString("strip_tags")})
+	filter := NewFilter(constFetch("FILTER_CALLBACK"), options)
+	if filter.Effect != FILTER_EFFECT_CALLBACK {
+		t.Errorf("Effect = %v, want FILTER_EFFECT_CALLBACK", filter.Effect)
+	}
+	if filter.Callback != "strip_tags" {
+		t.Errorf("Callback = %q, want strip_tags", filter.Callback)
+	}
+}
+
+func TestValidatedURLContext(t *testing.T) {
+	labels := NewFilter(constFetch("FILTER_VALIDATE_URL"), nil).Labels()
+	tests := []struct {
+		prefix string
+		want   bool
+	}{
+		// javascript:alert(1) is a valid URL
+		{`<a href="`, false},
+		{`<a href='`, false},
+		// quotes and < are allowed in the path
+		{`<div title="`, false},
+		{`<p>`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			if got := GetContext(tt.prefix).Accepts(labels); got != tt.want {
+				t.Errorf("Accepts(%s) = %v, want %v", labels, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveFilterCall(t *testing.T) {
+	value := cfg.NewTemporaryOperand(nil)
+	definition := arrayLiteral(
+		[]string{"id", "email", "name"},
+		[]cfg.Operand{
+			constFetch("FILTER_VALIDATE_INT"),
+			arrayLiteral([]string{"filter"}, []cfg.Operand{constFetch("FILTER_VALIDATE_EMAIL")}),
+			arrayLiteral([]string{"filter", "flags"}, []cfg.Operand{
+				constFetch("FILTER_SANITIZE_FULL_SPECIAL_CHARS"),
+				constFetch("FILTER_FLAG_NO_ENCODE_QUOTES"),
+			}),
+		},
+	)
+
+	call := ResolveFilterCall("filter_var_array", []cfg.Operand{value, definition})
+	if call.Value != value {
+		t.Errorf("Value is not the filtered array")
+	}
+	want := map[string]Labels{
+		"id":    LABEL_NUMERIC,
+		"email": LABEL_EMAIL,
+		"name":  LABEL_HTML_ESCAPED_NOQUOTES,
+	}
+	if len(call.Keys) != len(want) {
+		t.Fatalf("got %d keys, want %d", len(call.Keys), len(want))
+	}
+	for key, labels := range want {
+		filter, ok := call.Keys[key]
+		if !ok {
+			t.Errorf("key %q is missing", key)
+			continue
+		}
+		if got := filter.Labels(); got != labels {
+			t.Errorf("key %q Labels() = %s, want %s", key, got, labels)
+		}
+	}
+
+	if call := ResolveFilterCall("filter_input", []cfg.Operand{constFetch("INPUT_GET"), cfg.NewOperandString("id")}); call.Filter.Labels() != LABELS_RAW {
+		t.Errorf("filter_input without filter Labels() = %s, want raw", call.Filter.Labels())
+	}
+	if call := ResolveFilterCall("strip_tags", nil); call != nil {
+		t.Errorf("strip_tags is resolved as filter call")
+	}
+}
@@ -0,0 +1,58 @@
+package taint
+
+// Check if value with the labels, following the SQL query prefix, can't change the query
+func AcceptsSQL(labels Labels, prefix string) bool {
+	if labels.Has(LABEL_NUMERIC | LABEL_SAFE_CHARSET) {
+		return true
+	}
+	if GetSQLQuote(prefix) != 0 {
+		return labels.Has(LABEL_SQL_ESCAPED | LABEL_SLASHES_ADDED)
+	}
+	return labels.Has(LABEL_SQL_QUOTED)
+}
+
+// Get quote of the SQL string literal at the end of query prefix, 0 if outside string
+func GetSQLQuote(prefix string) byte {
+	var quote byte
+	for i := 0; i < len(prefix); i++ {
+		c := prefix[i]
+		switch {
+		case quote == 0 && (c == '\'' || c == '"' || c == '`'):
+			quote = c
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			// doubled quote is escaped quote
+			if i+1 < len(prefix) && prefix[i+1] == quote {
+				i++
+			} else {
+				quote = 0
+			}
+		}
+	}
+	if quote == '`' {
+		// backtick quoted identifier can't be escaped by the sql escaping
+		return 0
+	}
+	return quote
+}
+
+// Check if value with the labels can't run another shell command
+func AcceptsCommand(labels Labels) bool {
+	return labels.Has(LABEL_NUMERIC | LABEL_SAFE_CHARSET | LABEL_SHELL_ARG_ESCAPED | LABEL_SHELL_CMD_ESCAPED)
+}
+
+// Check if value with the labels can't run as PHP code
+func AcceptsCode(labels Labels) bool {
+	return labels.Has(LABEL_NUMERIC)
+}
+
+// Check if including the path starting with prefix, followed by value with the labels,
+// can't include file outside the directory or a remote file
+func AcceptsInclude(labels Labels, prefix string) bool {
+	if labels.Has(LABEL_NUMERIC | LABEL_SAFE_CHARSET) {
+		return true
+	}
+	// basename only keep the file inside the prefix directory
+	return prefix != "" && labels.Has(LABEL_BASENAME)
+}
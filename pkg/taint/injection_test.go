@@ -0,0 +1,95 @@
+package taint
+
+import "testing"
+
+func TestGetSQLQuote(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   byte
+	}{
+		{"SELECT * FROM users WHERE id = ", 0},
+		{"SELECT * FROM users WHERE name = '", '\''},
+		{`SELECT * FROM users WHERE name = "`, '"'},
+		{"SELECT * FROM users WHERE name = 'a' AND id = ", 0},
+		{`SELECT * FROM users WHERE name = 'it\'s `, '\''},
+		{"SELECT * FROM users WHERE name = 'it''s ", '\''},
+		{"SELECT * FROM `", 0},
+		{"SELECT * FROM `users` WHERE name = '", '\''},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			if got := GetSQLQuote(tt.prefix); got != tt.want {
+				t.Errorf("GetSQLQuote() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAcceptsSQL(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels Labels
+		prefix string
+		want   bool
+	}{
+		{"raw", LABELS_RAW, "WHERE id = ", false},
+		{"numeric", LABEL_NUMERIC, "WHERE id = ", true},
+		{"escaped in string", LABEL_SQL_ESCAPED, "WHERE name = '", true},
+		{"escaped outside string", LABEL_SQL_ESCAPED, "WHERE id = ", false},
+		{"slashes in string", LABEL_SLASHES_ADDED, "WHERE name = '", true},
+		{"quoted outside string", LABEL_SQL_QUOTED, "WHERE name = ", true},
+		{"html escaped in string", LABEL_HTML_ESCAPED, "WHERE name = '", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AcceptsSQL(tt.labels, tt.prefix); got != tt.want {
+				t.Errorf("AcceptsSQL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAcceptsCommandAndCode(t *testing.T) {
+	tests := []struct {
+		labels      Labels
+		wantCommand bool
+		wantCode    bool
+	}{
+		{LABELS_RAW, false, false},
+		{LABEL_NUMERIC, true, true},
+		{LABEL_SHELL_ARG_ESCAPED, true, false},
+		{LABEL_SHELL_CMD_ESCAPED, true, false},
+		{LABEL_HTML_ESCAPED, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.labels.String(), func(t *testing.T) {
+			if got := AcceptsCommand(tt.labels); got != tt.wantCommand {
+				t.Errorf("AcceptsCommand() = %v, want %v", got, tt.wantCommand)
+			}
+			if got := AcceptsCode(tt.labels); got != tt.wantCode {
+				t.Errorf("AcceptsCode() = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestAcceptsInclude(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels Labels
+		prefix string
+		want   bool
+	}{
+		{"raw", LABELS_RAW, "templates/", false},
+		{"safe charset", LABEL_SAFE_CHARSET, "", true},
+		{"basename in directory", LABEL_BASENAME, "templates/", true},
+		{"basename only", LABEL_BASENAME, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AcceptsInclude(tt.labels, tt.prefix); got != tt.want {
+				t.Errorf("AcceptsInclude() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
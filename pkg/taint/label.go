@@ -0,0 +1,138 @@
+package taint
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Labels is the set of encodings applied to a tainted value, empty set is raw value
+type Labels uint32
+
+const (
+	// htmlspecialchars with ENT_QUOTES, <>&"' encoded
+	LABEL_HTML_ESCAPED Labels = 1 << iota
+	// htmlspecialchars with ENT_COMPAT, ' is not encoded
+	LABEL_HTML_ESCAPED_DQUOTES
+	// htmlspecialchars with ENT_NOQUOTES, only <>& encoded
+	LABEL_HTML_ESCAPED_NOQUOTES
+	// strip_tags, quotes are left as is
+	LABEL_TAGS_STRIPPED
+	// addslashes, quotes are backslash escaped
+	LABEL_SLASHES_ADDED
+	// urlencode and rawurlencode
+	LABEL_URL_ENCODED
+	// json_encode
+	LABEL_JS_ESCAPED
+	// number or boolean, safe in every context
+	LABEL_NUMERIC
+	// validated or sanitized email address
+	LABEL_EMAIL
+	// validated URL, scheme can still be javascript:
+	LABEL_URL_VALIDATED
+	// restricted charset such as IP address or hostname
+	LABEL_SAFE_CHARSET
+	// json_encode flags, the characters are encoded as \u00XX
+	LABEL_JSON_HEX_TAG
+	LABEL_JSON_HEX_AMP
+	LABEL_JSON_HEX_APOS
+	LABEL_JSON_HEX_QUOT
+	// checked to start with a safe scheme or a relative path
+	LABEL_URL_SCHEME_CHECKED
+	// checked to be a path on the same host, such as /profile but not //evil.com
+	LABEL_RELATIVE_URL_CHECKED
+	// carriage return and line feed removed
+	LABEL_CRLF_STRIPPED
+	// mysqli_real_escape_string, safe inside quoted SQL string
+	LABEL_SQL_ESCAPED
+	// PDO::quote or pg_escape_literal, escaped and enclosed in quotes
+	LABEL_SQL_QUOTED
+	// escapeshellarg, quoted as a single shell argument
+	LABEL_SHELL_ARG_ESCAPED
+	// escapeshellcmd, shell metacharacters escaped but arguments can still be added
+	LABEL_SHELL_CMD_ESCAPED
+	// basename, directory part removed
+	LABEL_BASENAME
+	// markup filtered to allowed tags and attributes, such as wp_kses
+	LABEL_HTML_SANITIZED
+)
+
+const LABELS_RAW Labels = 0
+
+var labelNames = []struct {
+	label Labels
+	name  string
+}{
+	{LABEL_HTML_ESCAPED, "html-escaped"},
+	{LABEL_HTML_ESCAPED_DQUOTES, "html-escaped-double-quotes"},
+	{LABEL_HTML_ESCAPED_NOQUOTES, "html-escaped-no-quotes"},
+	{LABEL_TAGS_STRIPPED, "tags-stripped"},
+	{LABEL_SLASHES_ADDED, "slashes-added"},
+	{LABEL_URL_ENCODED, "url-encoded"},
+	{LABEL_JS_ESCAPED, "js-string-escaped"},
+	{LABEL_NUMERIC, "numeric"},
+	{LABEL_EMAIL, "validated-email"},
+	{LABEL_URL_VALIDATED, "validated-url"},
+	{LABEL_SAFE_CHARSET, "safe-charset"},
+	{LABEL_JSON_HEX_TAG, "json-hex-tag"},
+	{LABEL_JSON_HEX_AMP, "json-hex-amp"},
+	{LABEL_JSON_HEX_APOS, "json-hex-apos"},
+	{LABEL_JSON_HEX_QUOT, "json-hex-quot"},
+	{LABEL_URL_SCHEME_CHECKED, "url-scheme-checked"},
+	{LABEL_RELATIVE_URL_CHECKED, "relative-url-checked"},
+	{LABEL_CRLF_STRIPPED, "crlf-stripped"},
+	{LABEL_SQL_ESCAPED, "sql-escaped"},
+	{LABEL_SQL_QUOTED, "sql-quoted"},
+	{LABEL_SHELL_ARG_ESCAPED, "shell-arg-escaped"},
+	{LABEL_SHELL_CMD_ESCAPED, "shell-cmd-escaped"},
+	{LABEL_BASENAME, "basename"},
+	{LABEL_HTML_SANITIZED, "html-sanitized"},
+}
+
+const LABELS_HTML = LABEL_HTML_ESCAPED | LABEL_HTML_ESCAPED_DQUOTES | LABEL_HTML_ESCAPED_NOQUOTES
+
+const LABELS_JSON_HEX = LABEL_JSON_HEX_TAG | LABEL_JSON_HEX_AMP | LABEL_JSON_HEX_APOS | LABEL_JSON_HEX_QUOT
+
+var jsonHexFlags = []struct {
+	label Labels
+	flag  string
+}{
+	{LABEL_JSON_HEX_TAG, "JSON_HEX_TAG"},
+	{LABEL_JSON_HEX_AMP, "JSON_HEX_AMP"},
+	{LABEL_JSON_HEX_APOS, "JSON_HEX_APOS"},
+	{LABEL_JSON_HEX_QUOT, "JSON_HEX_QUOT"},
+}
+
+func (l Labels) Has(label Labels) bool {
+	return l&label != 0
+}
+
+func (l Labels) Add(label Labels) Labels {
+	return l | label
+}
+
+func (l Labels) Remove(label Labels) Labels {
+	return l &^ label
+}
+
+func (l Labels) String() string {
+	if l == LABELS_RAW {
+		return "raw"
+	}
+	names := make([]string, 0)
+	for _, ln := range labelNames {
+		if l.Has(ln.label) {
+			names = append(names, ln.name)
+		}
+	}
+	return strings.Join(names, "+")
+}
+
+// Get label by its name, such as html-escaped
+func ParseLabel(name string) (Labels, error) {
+	for _, ln := range labelNames {
+		if ln.name == name {
+			return ln.label, nil
+		}
+	}
+	return LABELS_RAW, fmt.Errorf("unknown label '%s'", name)
+}
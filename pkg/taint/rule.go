@@ -0,0 +1,106 @@
+package taint
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Type of vulnerability reported when tainted value reach a sink
+type RuleID string
+
+const (
+	RULE_XSS              RuleID = "xss"
+	RULE_OPEN_REDIRECT    RuleID = "open-redirect"
+	RULE_HEADER_INJECTION RuleID = "header-injection"
+	// html email body rendered by webmail client
+	RULE_EMAIL_XSS         RuleID = "email-xss"
+	RULE_SQL_INJECTION     RuleID = "sql-injection"
+	RULE_COMMAND_INJECTION RuleID = "command-injection"
+	RULE_CODE_INJECTION    RuleID = "code-injection"
+	RULE_FILE_INCLUSION    RuleID = "file-inclusion"
+)
+
+type Severity string
+
+const (
+	SEVERITY_CRITICAL Severity = "critical"
+	SEVERITY_HIGH     Severity = "high"
+	SEVERITY_MEDIUM   Severity = "medium"
+	SEVERITY_LOW      Severity = "low"
+)
+
+// Vulnerability class checked by the scanner
+type Rule struct {
+	ID       RuleID
+	Name     string
+	CWE      string
+	Severity Severity
+}
+
+var rules = []*Rule{
+	{RULE_XSS, "Cross-site scripting", "CWE-79", SEVERITY_HIGH},
+	{RULE_EMAIL_XSS, "HTML email injection", "CWE-79", SEVERITY_MEDIUM},
+	{RULE_OPEN_REDIRECT, "Open redirect", "CWE-601", SEVERITY_MEDIUM},
+	{RULE_HEADER_INJECTION, "Response header injection", "CWE-113", SEVERITY_MEDIUM},
+	{RULE_SQL_INJECTION, "SQL injection", "CWE-89", SEVERITY_CRITICAL},
+	{RULE_COMMAND_INJECTION, "OS command injection", "CWE-78", SEVERITY_CRITICAL},
+	{RULE_CODE_INJECTION, "Code injection", "CWE-94", SEVERITY_CRITICAL},
+	{RULE_FILE_INCLUSION, "File inclusion", "CWE-98", SEVERITY_HIGH},
+}
+
+// Get all rules in the order they are reported
+func GetRules() []*Rule {
+	return rules
+}
+
+func GetRule(id RuleID) *Rule {
+	for _, rule := range rules {
+		if rule.ID == id {
+			return rule
+		}
+	}
+	return nil
+}
+
+// Parse comma separated rule IDs, error if a rule doesn't exist
+func ParseRuleIDs(list string) ([]RuleID, error) {
+	ids := make([]RuleID, 0)
+	for _, item := range strings.Split(list, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+		if GetRule(RuleID(item)) == nil {
+			return nil, fmt.Errorf("unknown rule '%s'", item)
+		}
+		ids = append(ids, RuleID(item))
+	}
+	return ids, nil
+}
+
+// Check if the sink of the rule is html, checked with the same context and sanitizer logic
+func (r RuleID) IsHTML() bool {
+	return r == RULE_XSS || r == RULE_EMAIL_XSS
+}
+
+// Check if redirecting to the URL starting with prefix, followed by value
+// with the labels, can't go to another host
+func AcceptsRedirect(labels Labels, prefix string) bool {
+	if labels.Has(LABEL_NUMERIC) || IsFixedHostURLPrefix(prefix) {
+		return true
+	}
+	switch prefix {
+	case "":
+		return labels.Has(LABEL_URL_ENCODED | LABEL_SAFE_CHARSET | LABEL_RELATIVE_URL_CHECKED)
+	case "/":
+		// encoded value can't add another slash
+		return labels.Has(LABEL_URL_ENCODED | LABEL_SAFE_CHARSET)
+	}
+	return false
+}
+
+// Check if value with the labels can't contain line break to add another header
+func AcceptsHeader(labels Labels) bool {
+	return labels.Has(LABEL_CRLF_STRIPPED | LABEL_NUMERIC | LABEL_URL_ENCODED | LABEL_SAFE_CHARSET |
+		LABEL_EMAIL | LABEL_URL_VALIDATED | LABEL_JS_ESCAPED)
+}
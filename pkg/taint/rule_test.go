@@ -0,0 +1,98 @@
+package taint
+
+import "testing"
+
+func TestAcceptsRedirect(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels Labels
+		prefix string
+		want   bool
+	}{
+		{"raw URL", LABELS_RAW, "", false},
+		{"numeric", LABEL_NUMERIC, "", true},
+		{"relative URL checked", LABEL_RELATIVE_URL_CHECKED, "", true},
+		{"encoded URL", LABEL_URL_ENCODED, "", true},
+		{"raw after path", LABELS_RAW, "/profile?id=", true},
+		{"raw after slash", LABELS_RAW, "/", false},
+		{"encoded after slash", LABEL_URL_ENCODED, "/", true},
+		{"raw after host", LABELS_RAW, "https://example.com/", true},
+		{"raw in host", LABELS_RAW, "https://", false},
+		{"raw after protocol relative", LABELS_RAW, "//", false},
+		{"html escaped", LABEL_HTML_ESCAPED, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AcceptsRedirect(tt.labels, tt.prefix); got != tt.want {
+				t.Errorf("AcceptsRedirect(%s, %q) = %v, want %v", tt.labels, tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAcceptsHeader(t *testing.T) {
+	tests := []struct {
+		labels Labels
+		want   bool
+	}{
+		{LABELS_RAW, false},
+		{LABEL_HTML_ESCAPED, false},
+		{LABEL_CRLF_STRIPPED, true},
+		{LABEL_URL_ENCODED, true},
+		{LABEL_NUMERIC, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.labels.String(), func(t *testing.T) {
+			if got := AcceptsHeader(tt.labels); got != tt.want {
+				t.Errorf("AcceptsHeader() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRuleIDs(t *testing.T) {
+	ids, err := ParseRuleIDs(" XSS, open-redirect,,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != RULE_XSS || ids[1] != RULE_OPEN_REDIRECT {
+		t.Errorf("ParseRuleIDs() = %v, want [xss open-redirect]", ids)
+	}
+	if _, err := ParseRuleIDs("xss,unknown"); err == nil {
+		t.Errorf("ParseRuleIDs() of unknown rule doesn't fail")
+	}
+}
+
+func TestGetRule(t *testing.T) {
+	for _, rule := range GetRules() {
+		if GetRule(rule.ID) != rule {
+			t.Errorf("GetRule(%s) isn't the listed rule", rule.ID)
+		}
+		if rule.Name == "" || rule.CWE == "" || rule.Severity == "" {
+			t.Errorf("rule %s has no name, CWE or severity", rule.ID)
+		}
+	}
+	if GetRule("csrf") != nil {
+		t.Errorf("GetRule() of unknown rule isn't nil")
+	}
+}
+
+func TestIsHTML(t *testing.T) {
+	tests := []struct {
+		id   RuleID
+		want bool
+	}{
+		{RULE_XSS, true},
+		{RULE_EMAIL_XSS, true},
+		{RULE_OPEN_REDIRECT, false},
+		{RULE_HEADER_INJECTION, false},
+		{RULE_SQL_INJECTION, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.id), func(t *testing.T) {
+			if got := tt.id.IsHTML(); got != tt.want {
+				t.Errorf("IsHTML() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package taint
+
+import (
+	"strings"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+)
+
+// Apply builtin function which effect depend on its arguments to the labels,
+// return false if the function doesn't change the labels. Simple sanitizers are in the rule files
+func ApplyCall(funcName string, args []cfg.Operand, labels Labels) (Labels, bool) {
+	switch strings.ToLower(funcName) {
+	case "htmlspecialchars", "htmlentities":
+		return labels.Add(GetHTMLEscapeLabel(getArg(args, 1))), true
+	case "json_encode":
+		return labels.Add(LABEL_JS_ESCAPED | GetJSONHexLabels(getArg(args, 1))), true
+	case "str_replace", "str_ireplace":
+		if len(args) > 1 && replacesCRLF(args[0], args[1]) {
+			return labels.Add(LABEL_CRLF_STRIPPED), true
+		}
+	case "preg_replace":
+		if len(args) > 1 && regexReplacesCRLF(args[0], args[1]) {
+			return labels.Add(LABEL_CRLF_STRIPPED), true
+		}
+	}
+	return labels, false
+}
+
+// Get label of htmlspecialchars or htmlentities with the flags
+func GetHTMLEscapeLabel(flagsOper cfg.Operand) Labels {
+	if flagsOper == nil {
+		// ENT_QUOTES | ENT_SUBSTITUTE | ENT_HTML401 since PHP 8.1
+		return LABEL_HTML_ESCAPED
+	}
+	names, ok := cfg.GetConstNames(flagsOper)
+	if !ok {
+		return LABEL_HTML_ESCAPED_NOQUOTES
+	}
+	label := LABEL_HTML_ESCAPED_NOQUOTES
+	for _, name := range names {
+		switch name {
+		case "ENT_QUOTES":
+			return LABEL_HTML_ESCAPED
+		case "ENT_COMPAT":
+			label = LABEL_HTML_ESCAPED_DQUOTES
+		}
+	}
+	return label
+}
+
+// Get labels of JSON_HEX_* flags of json_encode, unresolved flags have none
+func GetJSONHexLabels(flagsOper cfg.Operand) Labels {
+	if flagsOper == nil {
+		return LABELS_RAW
+	}
+	names, ok := cfg.GetConstNames(flagsOper)
+	if !ok {
+		return LABELS_RAW
+	}
+	labels := LABELS_RAW
+	for _, name := range names {
+		for _, hf := range jsonHexFlags {
+			if name == hf.flag {
+				labels = labels.Add(hf.label)
+			}
+		}
+	}
+	return labels
+}
+
+// Check if str_replace search both \r and \n, and the replacement has no line break
+func replacesCRLF(searchOper, replaceOper cfg.Operand) bool {
+	search, ok := getConstStrings(searchOper)
+	if !ok {
+		return false
+	}
+	replace, ok := getConstStrings(replaceOper)
+	if !ok {
+		return false
+	}
+	for _, str := range replace {
+		if strings.ContainsAny(str, "\r\n") {
+			return false
+		}
+	}
+	cr, lf := false, false
+	for _, str := range search {
+		cr = cr || str == "\r"
+		lf = lf || str == "\n"
+	}
+	return cr && lf
+}
+
+// Check if preg_replace pattern match every \r and \n, and the replacement has no line break
+func regexReplacesCRLF(patternOper, replaceOper cfg.Operand) bool {
+	pattern, ok := cfg.GetConstString(patternOper)
+	if !ok || len(pattern) < 2 {
+		return false
+	}
+	if replace, ok := cfg.GetConstString(replaceOper); !ok || strings.ContainsAny(replace, "\r\n") {
+		return false
+	}
+	body := pattern[1:]
+	if end := strings.LastIndexByte(body, pattern[0]); end >= 0 {
+		body = body[:end]
+	}
+	// anchored pattern only replace at the start or end
+	if strings.HasPrefix(body, "^") || strings.HasSuffix(body, "$") {
+		return false
+	}
+	for _, class := range []string{"\\s", "\\v", "[:space:]", "[:cntrl:]"} {
+		if strings.Contains(body, class) {
+			return true
+		}
+	}
+	hasCR := strings.Contains(body, "\\r") || strings.Contains(body, "\r")
+	hasLF := strings.Contains(body, "\\n") || strings.Contains(body, "\n")
+	return hasCR && hasLF
+}
+
+// Get constant string or array of constant strings
+func getConstStrings(oper cfg.Operand) ([]string, bool) {
+	if str, ok := cfg.GetConstString(oper); ok {
+		return []string{str}, true
+	}
+	arr, ok := cfg.GetArrayLiteral(oper)
+	if !ok {
+		return nil, false
+	}
+	strs := make([]string, 0, len(arr.Vals))
+	for _, val := range arr.Vals {
+		str, ok := cfg.GetConstString(val)
+		if !ok {
+			return nil, false
+		}
+		strs = append(strs, str)
+	}
+	return strs, true
+}
@@ -0,0 +1,33 @@
+package taint
+
+import (
+	"testing"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+)
+
+func TestApplyCallCRLF(t *testing.T) {
+	crlf := arrayLiteral([]string{"0", "1"}, []cfg.Operand{cfg.NewOperandString("\r"), cfg.NewOperandString("\n")})
+	tests := []struct {
+		name     string
+		function string
+		args     []cfg.Operand
+		want     bool
+	}{
+		{"str_replace array", "str_replace", []cfg.Operand{crlf, cfg.NewOperandString("")}, true},
+		{"str_replace lf only", "str_replace", []cfg.Operand{cfg.NewOperandString("\n"), cfg.NewOperandString("")}, false},
+		{"str_replace with line break", "str_replace", []cfg.Operand{crlf, cfg.NewOperandString("\n")}, false},
+		{"preg_replace cr lf", "preg_replace", []cfg.Operand{cfg.NewOperandString(`/[\r\n]/`), cfg.NewOperandString("")}, true},
+		{"preg_replace spaces", "preg_replace", []cfg.Operand{cfg.NewOperandString(`/\s+/`), cfg.NewOperandString(" ")}, true},
+		{"preg_replace anchored", "preg_replace", []cfg.Operand{cfg.NewOperandString(`/\s+$/`), cfg.NewOperandString("")}, false},
+		{"preg_replace other", "preg_replace", []cfg.Operand{cfg.NewOperandString(`/[<>]/`), cfg.NewOperandString("")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels, _ := ApplyCall(tt.function, append(tt.args, cfg.NewTemporaryOperand(nil)), LABELS_RAW)
+			if got := labels.Has(LABEL_CRLF_STRIPPED); got != tt.want {
+				t.Errorf("crlf-stripped = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
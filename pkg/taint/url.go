@@ -0,0 +1,132 @@
+package taint
+
+import (
+	"strings"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+)
+
+var safeSchemes = map[string]struct{}{
+	"http": {}, "https": {}, "mailto": {}, "tel": {}, "ftp": {}, "sms": {},
+}
+
+var unsafeSchemes = []string{"javascript:", "vbscript:", "data:"}
+
+// Check if URL starting with the prefix can't be a javascript: URL,
+// such as https:// or a relative path
+func IsSafeURLPrefix(prefix string) bool {
+	prefix = strings.ToLower(prefix)
+	if prefix == "" {
+		return false
+	}
+	switch prefix[0] {
+	case '/', '?', '#':
+		return true
+	}
+	if strings.HasPrefix(prefix, "./") || strings.HasPrefix(prefix, "../") {
+		return true
+	}
+	if i := strings.IndexByte(prefix, ':'); i > 0 {
+		_, ok := safeSchemes[prefix[:i]]
+		return ok
+	}
+	return false
+}
+
+// Check if URL starting with the prefix can't go to another host,
+// such as /profile?id= or https://example.com/
+func IsFixedHostURLPrefix(prefix string) bool {
+	prefix = strings.ToLower(prefix)
+	if prefix == "" {
+		return false
+	}
+	switch prefix[0] {
+	case '/', '\\':
+		// //evil.com and /\evil.com are protocol relative
+		return len(prefix) > 1 && prefix[1] != '/' && prefix[1] != '\\'
+	case '?', '#':
+		return true
+	}
+	if i := strings.Index(prefix, "://"); i > 0 {
+		host := prefix[i+3:]
+		end := strings.IndexAny(host, "/?#\\")
+		return end > 0 && !strings.Contains(host[:end], "@")
+	}
+	// relative path such as profile.php?id=
+	colon := strings.IndexByte(prefix, ':')
+	path := strings.IndexAny(prefix, "/?#")
+	return path > 0 && (colon < 0 || path < colon)
+}
+
+// Check if the start of URL attribute value can still become an unsafe scheme
+func canStartUnsafeScheme(value string) bool {
+	for _, scheme := range unsafeSchemes {
+		if strings.HasPrefix(scheme, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check if assertion guarantee the value start with a safe URL prefix
+func IsSchemeValidated(assert cfg.Assertion) bool {
+	return assertsPrefix(assert, false, IsSafeURLPrefix)
+}
+
+// Check if assertion guarantee the value is a URL on the same host
+func IsRelativeURLValidated(assert cfg.Assertion) bool {
+	return assertsPrefix(assert, false, IsFixedHostURLPrefix)
+}
+
+// Check if assertion guarantee the value start with a prefix accepted by isSafe
+func assertsPrefix(assert cfg.Assertion, negated bool, isSafe func(string) bool) bool {
+	switch a := assert.(type) {
+	case *cfg.PrefixAssertion:
+		// value doesn't start with the prefix tell nothing
+		if a.IsNegated != negated || len(a.Prefixes) == 0 {
+			return false
+		}
+		for _, prefix := range a.Prefixes {
+			if !isSafe(prefix) {
+				return false
+			}
+		}
+		return true
+	case *cfg.CompositeAssertion:
+		negated = negated != a.IsNegated
+		// every or-ed assertion must validate, negation of and is or of negations
+		requireAll := (a.Mode == cfg.ASSERTION_MODE_UNION) != negated
+		if !requireAll && isPathOnlyCheck(a.AssertionList, negated) && isSafe("/path") {
+			return true
+		}
+		for _, child := range a.AssertionList {
+			validated := assertsPrefix(child, negated, isSafe)
+			if validated && !requireAll {
+				return true
+			}
+			if !validated && requireAll {
+				return false
+			}
+		}
+		return requireAll && len(a.AssertionList) > 0
+	}
+	return false
+}
+
+// Check for and-ed assertions that value start with / but not with //
+func isPathOnlyCheck(asserts []cfg.Assertion, negated bool) bool {
+	slash, doubleSlash := false, false
+	for _, assert := range asserts {
+		prefixAssert, ok := assert.(*cfg.PrefixAssertion)
+		if !ok || len(prefixAssert.Prefixes) != 1 {
+			continue
+		}
+		switch prefixAssert.Prefixes[0] {
+		case "/":
+			slash = slash || prefixAssert.IsNegated == negated
+		case "//":
+			doubleSlash = doubleSlash || prefixAssert.IsNegated != negated
+		}
+	}
+	return slash && doubleSlash
+}
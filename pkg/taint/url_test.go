@@ -0,0 +1,130 @@
+package taint
+
+import (
+	"testing"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+)
+
+func TestIsSafeURLPrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   bool
+	}{
+		{"", false},
+		{"/profile?id=", true},
+		{"?page=", true},
+		{"#", true},
+		{"./", true},
+		{"../img/", true},
+		{"https://", true},
+		{"HTTPS://example.com/", true},
+		{"mailto:", true},
+		{"javascript:", false},
+		{"data:", false},
+		{"http", false},
+		{"profile.php?id=", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			if got := IsSafeURLPrefix(tt.prefix); got != tt.want {
+				t.Errorf("IsSafeURLPrefix() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSchemeValidated(t *testing.T) {
+	https := cfg.NewPrefixAssertion([]string{"https://", "http://"}, false)
+	slash := cfg.NewPrefixAssertion([]string{"/"}, false)
+	doubleSlash := cfg.NewPrefixAssertion([]string{"//"}, false)
+	tests := []struct {
+		name   string
+		assert cfg.Assertion
+		want   bool
+	}{
+		{"starts with https", https, true},
+		{"doesn't start with https", https.GetNegation(), false},
+		{"starts with javascript", cfg.NewPrefixAssertion([]string{"javascript:"}, false), false},
+		{"one of the prefixes is unsafe", cfg.NewPrefixAssertion([]string{"https://", "data:"}, false), false},
+		{"starts with https or slash", cfg.NewCompositeAssertion([]cfg.Assertion{https, slash}, cfg.ASSERTION_MODE_UNION, false), true},
+		{"starts with https and something else", cfg.NewCompositeAssertion([]cfg.Assertion{https, cfg.NewTypeAssertion(cfg.NewOperandString("string"), false)}, cfg.ASSERTION_MODE_INTERSECTION, false), true},
+		{"starts with https or anything", cfg.NewCompositeAssertion([]cfg.Assertion{https, cfg.NewTypeAssertion(cfg.NewOperandString("string"), false)}, cfg.ASSERTION_MODE_UNION, false), false},
+		{"negated or of negations", cfg.NewCompositeAssertion([]cfg.Assertion{https.GetNegation(), slash.GetNegation()}, cfg.ASSERTION_MODE_INTERSECTION, true), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsSchemeValidated(tt.assert); got != tt.want {
+				t.Errorf("IsSchemeValidated() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// path only: starts with / and not with //
+	pathOnly := cfg.NewCompositeAssertion([]cfg.Assertion{slash, doubleSlash.GetNegation()}, cfg.ASSERTION_MODE_INTERSECTION, false)
+	if !IsRelativeURLValidated(pathOnly) {
+		t.Errorf("IsRelativeURLValidated() of path only check = false, want true")
+	}
+	if IsRelativeURLValidated(slash) {
+		t.Errorf("IsRelativeURLValidated() of / check = true, want false since //evil.com starts with /")
+	}
+}
+
+func TestURLAttrContext(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   ContextKind
+	}{
+		{`<a href="`, CONTEXT_URL_ATTR},
+		{`<img src='`, CONTEXT_URL_ATTR},
+		{`<form action=`, CONTEXT_URL_ATTR},
+		// constant prefix can still become javascript:
+		{`<a href="java`, CONTEXT_URL_ATTR},
+		{`<a href="/profile?id=`, CONTEXT_ATTR},
+		{`<a href="https://example.com/?q=`, CONTEXT_ATTR},
+		{`<a title="`, CONTEXT_ATTR},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			if got := GetContext(tt.prefix).Kind; got != tt.want {
+				t.Errorf("GetContext().Kind = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// escaping prevents breaking out of the attribute, not javascript: URL
+	context := GetContext(`<a href="`)
+	if !context.IsJavaScriptURL(LABEL_HTML_ESCAPED) {
+		t.Errorf("IsJavaScriptURL(html-escaped) = false, want true")
+	}
+	if context.IsJavaScriptURL(LABEL_HTML_ESCAPED | LABEL_URL_SCHEME_CHECKED) {
+		t.Errorf("IsJavaScriptURL(html-escaped, url-scheme-checked) = true, want false")
+	}
+}
+
+func TestIsFixedHostURLPrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   bool
+	}{
+		{"", false},
+		{"/", false},
+		{"/profile?id=", true},
+		{"//", false},
+		{"/\\", false},
+		{"?page=", true},
+		{"https://example.com/", true},
+		{"https://example.com", false},
+		{"https://user@example.com/", false},
+		{"https://", false},
+		{"profile.php?id=", true},
+		{"javascript:", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			if got := IsFixedHostURLPrefix(tt.prefix); got != tt.want {
+				t.Errorf("IsFixedHostURLPrefix() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
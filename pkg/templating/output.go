@@ -0,0 +1,63 @@
+package templating
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Variable holding the view data, the template variables are extracted from it
+const DATA_VAR = "__data"
+
+// Compiled PHP of a template, keeping the lines of the template
+type Output struct {
+	src       []byte
+	out       bytes.Buffer
+	sourceMap *SourceMap
+}
+
+// Create output starting with extracting the view data
+func NewOutput(src []byte) *Output {
+	o := &Output{src: src, sourceMap: &SourceMap{}}
+	o.Emit("<?php extract($"+DATA_VAR+"); ?>", 0, 0)
+	return o
+}
+
+func (o *Output) Bytes() []byte {
+	return o.out.Bytes()
+}
+
+func (o *Output) SourceMap() *SourceMap {
+	return o.sourceMap
+}
+
+// Copy template text as is
+func (o *Output) Copy(start, end int) {
+	if start >= end {
+		return
+	}
+	outStart := o.out.Len()
+	o.out.Write(o.src[start:end])
+	o.sourceMap.add(segment{outStart: outStart, outEnd: o.out.Len(), srcStart: start, srcEnd: end, verbatim: true})
+}
+
+// Write code generated from the template text between start and end
+func (o *Output) Emit(code string, start, end int) {
+	outStart := o.out.Len()
+	o.out.WriteString(code)
+	o.sourceMap.add(segment{outStart: outStart, outEnd: o.out.Len(), srcStart: start, srcEnd: end})
+}
+
+// Remove the template text, only its line breaks are kept
+func (o *Output) Drop(start, end int) {
+	o.Emit(strings.Repeat("\n", bytes.Count(o.src[start:end], []byte("\n"))), start, end)
+}
+
+// Write template comment from start to end with the text between textStart and textEnd
+// as PHP comment, kept for suppression annotations
+func (o *Output) Comment(start, textStart, textEnd, end int) {
+	o.Emit("<?php /*", start, textStart)
+	outStart := o.out.Len()
+	o.out.Write(bytes.ReplaceAll(o.src[textStart:textEnd], []byte("*/"), []byte("*_")))
+	o.sourceMap.add(segment{outStart: outStart, outEnd: o.out.Len(), srcStart: textStart, srcEnd: textEnd, verbatim: true})
+	o.Emit("*/ ?>", textEnd, end)
+}
@@ -0,0 +1,93 @@
+package templating
+
+import (
+	"sort"
+
+	"github.com/VKCOM/php-parser/pkg/position"
+	"github.com/rxhunter00/XSS-Taint/pkg/cfg"
+	"github.com/rxhunter00/XSS-Taint/pkg/cfgtraverser"
+)
+
+// Part of the compiled PHP and the template text it come from
+type segment struct {
+	outStart int
+	outEnd   int
+	srcStart int
+	srcEnd   int
+	// Copied as is from the template, otherwise generated
+	verbatim bool
+}
+
+// Map of the compiled PHP offsets back to the template offsets, the lines are the same
+type SourceMap struct {
+	segments []segment
+}
+
+func (m *SourceMap) add(seg segment) {
+	if seg.outStart == seg.outEnd {
+		return
+	}
+	m.segments = append(m.segments, seg)
+}
+
+// Get template offset of the compiled offset, generated code map to the start or the end
+// of the Blade syntax it is compiled from. End offset is exclusive
+func (m *SourceMap) MapOffset(offset int, isEnd bool) int {
+	lookup := offset
+	if isEnd && offset > 0 {
+		lookup--
+	}
+	i := sort.Search(len(m.segments), func(i int) bool {
+		return m.segments[i].outEnd > lookup
+	})
+	if i == len(m.segments) {
+		if i == 0 {
+			return offset
+		}
+		return m.segments[i-1].srcEnd
+	}
+	seg := m.segments[i]
+	switch {
+	case seg.verbatim:
+		return seg.srcStart + offset - seg.outStart
+	case isEnd:
+		return seg.srcEnd
+	}
+	return seg.srcStart
+}
+
+// Make the position of every op of the compiled template point to the template
+func (m *SourceMap) RemapPositions(script *cfg.Script) {
+	remapper := &positionRemapper{sourceMap: m, seen: make(map[*position.Position]struct{})}
+	traverser := cfgtraverser.NewTraverser()
+	traverser.AddBlockTraverser(remapper)
+	traverser.Traverse(script)
+}
+
+type positionRemapper struct {
+	cfgtraverser.NullTraverser
+
+	sourceMap *SourceMap
+	// positions can be shared by ops of the same node
+	seen map[*position.Position]struct{}
+}
+
+func (r *positionRemapper) EnterFunc(fn *cfg.Func) {
+	r.remap(fn.GetPosition())
+}
+
+func (r *positionRemapper) EnterOp(op cfg.Op, block *cfg.Block) {
+	r.remap(op.GetPosition())
+}
+
+func (r *positionRemapper) remap(pos *position.Position) {
+	if pos == nil {
+		return
+	}
+	if _, ok := r.seen[pos]; ok {
+		return
+	}
+	r.seen[pos] = struct{}{}
+	pos.StartPos = r.sourceMap.MapOffset(pos.StartPos, false)
+	pos.EndPos = r.sourceMap.MapOffset(pos.EndPos, true)
+}
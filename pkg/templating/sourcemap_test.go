@@ -0,0 +1,54 @@
+package templating
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMapOffset(t *testing.T) {
+	// <p>{{ $x }}</p> compiled to <p><?php echo e( $x ); ?></p>
+	src := "<p>{{ $x }}</p>"
+	o := NewOutput([]byte(src))
+	o.Copy(0, 3)
+	o.Emit("<?php echo e(", 3, 5)
+	o.Copy(5, 9)
+	o.Emit("); ?>", 9, 11)
+	o.Copy(11, len(src))
+	out := string(o.Bytes())
+
+	tests := []struct {
+		name   string
+		offset int
+		isEnd  bool
+		want   int
+	}{
+		{"extract of view data", 0, false, 0},
+		{"copied text", strings.Index(out, "<p>") + 1, false, 1},
+		{"copied expression", strings.Index(out, "$x"), false, 6},
+		{"end of copied expression", strings.Index(out, "$x") + 2, true, 8},
+		{"generated code", strings.Index(out, "echo"), false, 3},
+		{"end of generated code", strings.Index(out, "?></p>") + 2, true, 11},
+		{"text after generated code", strings.Index(out, "</p>"), false, 11},
+		{"end of output", len(out), true, len(src)},
+		{"after end of output", len(out) + 5, false, len(src)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := o.SourceMap().MapOffset(tt.offset, tt.isEnd); got != tt.want {
+				t.Errorf("MapOffset(%d, %v) = %d, want %d", tt.offset, tt.isEnd, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutputDropAndComment(t *testing.T) {
+	src := "@section('a')\n@endsection{{-- a */ b --}}"
+	commentStart := strings.Index(src, "{{--")
+	o := NewOutput([]byte(src))
+	o.Drop(0, commentStart)
+	o.Comment(commentStart, commentStart+4, len(src)-4, len(src))
+	want := "<?php extract($" + DATA_VAR + "); ?>\n<?php /* a *_ b */ ?>"
+	if got := string(o.Bytes()); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,425 @@
+package twig
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/templating"
+)
+
+const (
+	// Function rendering other template, called with name, variables and the context if it is passed
+	INCLUDE_FUNC = "twig_include"
+	// Function escaping the value with the strategy
+	ESCAPE_FUNC = "twig_escape_filter"
+)
+
+// Autoescape strategies, output is not escaped with STRATEGY_NONE
+const (
+	STRATEGY_HTML = "html"
+	STRATEGY_JS   = "js"
+	STRATEGY_CSS  = "css"
+	STRATEGY_NONE = ""
+)
+
+// Get autoescape strategy of the template by its extension before .twig, such as
+// js for app.js.twig, the default strategy of Symfony
+func GetStrategy(filePath string) string {
+	switch filepath.Ext(strings.TrimSuffix(filePath, ".twig")) {
+	case ".js":
+		return STRATEGY_JS
+	case ".css":
+		return STRATEGY_CSS
+	case ".txt":
+		return STRATEGY_NONE
+	}
+	return STRATEGY_HTML
+}
+
+// Compile Twig templates to PHP keeping the lines of the template. Only the output
+// bypassing the autoescape is echoed, such as {{ x|raw }} to <?php echo $x; ?>
+type Compiler struct {
+	// Strategies of the filters declared safe by the extensions
+	safeFilters map[string][]string
+}
+
+func NewCompiler() *Compiler {
+	return &Compiler{safeFilters: map[string][]string{}}
+}
+
+var (
+	filterPattern  = regexp.MustCompile(`new\s+\\?(?:Twig\\)?(?:TwigFilter|Twig_SimpleFilter)\s*\(\s*['"]([\w.]+)['"]`)
+	isSafePattern  = regexp.MustCompile(`['"]is_safe['"]\s*=>\s*(?:array\s*\(|\[)([^\])]*)`)
+	strategyString = regexp.MustCompile(`['"](\w+)['"]`)
+)
+
+// Read the filters declared with is_safe by Twig extension in PHP source, such as
+// new TwigFilter('markdown', ..., ['is_safe' => ['html']]). Filters escaping the value
+// first with pre_escape are not read
+func (c *Compiler) ReadFilters(src []byte) {
+	matches := filterPattern.FindAllSubmatchIndex(src, -1)
+	for i, match := range matches {
+		end := len(src)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		options := src[match[1]:end]
+		if j := bytes.IndexByte(options, ';'); j >= 0 {
+			options = options[:j]
+		}
+		isSafe := isSafePattern.FindSubmatch(options)
+		if isSafe == nil || bytes.Contains(options, []byte("pre_escape")) {
+			continue
+		}
+		name := string(src[match[2]:match[3]])
+		for _, strategy := range strategyString.FindAllSubmatch(isSafe[1], -1) {
+			c.safeFilters[name] = append(c.safeFilters[name], string(strategy[1]))
+		}
+	}
+}
+
+// Compile template with the autoescape strategy of the file
+func (c *Compiler) Compile(src []byte, filePath string) ([]byte, *templating.SourceMap) {
+	tc := &templateCompiler{
+		src:         src,
+		out:         templating.NewOutput(src),
+		safeFilters: c.safeFilters,
+		strategies:  []string{GetStrategy(filePath)},
+	}
+	tc.compile()
+	return tc.out.Bytes(), tc.out.SourceMap()
+}
+
+type templateCompiler struct {
+	src         []byte
+	out         *templating.Output
+	safeFilters map[string][]string
+	// strategy of the nested autoescape tags, the last one is current
+	strategies []string
+	// open if and for tags, for once its else is reached is for-else
+	blocks []string
+}
+
+func (c *templateCompiler) strategy() string {
+	return c.strategies[len(c.strategies)-1]
+}
+
+func (c *templateCompiler) compile() {
+	src := c.src
+	textStart := 0
+	for i := 0; i < len(src)-1; {
+		if src[i] != '{' || (src[i+1] != '{' && src[i+1] != '%' && src[i+1] != '#') {
+			i++
+			continue
+		}
+		var end int
+		switch src[i+1] {
+		case '{':
+			end = c.indexFrom(i+2, "}}")
+		case '%':
+			end = c.indexFrom(i+2, "%}")
+		default:
+			// comment may have unbalanced quotes
+			if end = bytes.Index(src[i+2:], []byte("#}")); end >= 0 {
+				end += i + 2
+			}
+		}
+		if end < 0 {
+			break
+		}
+		c.copyText(textStart, i)
+		tagEnd := end + 2
+		switch src[i+1] {
+		case '#':
+			c.out.Comment(i, i+2, end, tagEnd)
+		case '{':
+			c.compileOutput(i, trimTag(src[i+2:end]), tagEnd)
+		case '%':
+			tagEnd = c.compileTag(i, trimTag(src[i+2:end]), tagEnd)
+		}
+		i, textStart = tagEnd, tagEnd
+	}
+	c.copyText(textStart, len(src))
+}
+
+// Copy template text, <? such as of <?xml is echoed since Twig doesn't run PHP
+func (c *templateCompiler) copyText(start, end int) {
+	for start < end {
+		i := bytes.Index(c.src[start:end], []byte("<?"))
+		if i < 0 {
+			break
+		}
+		c.out.Copy(start, start+i)
+		c.out.Emit("<?php echo '<?'; ?>", start+i, start+i+2)
+		start += i + 2
+	}
+	c.out.Copy(start, end)
+}
+
+// Get index of the delimiter from the offset, skipping the strings, -1 if not found
+func (c *templateCompiler) indexFrom(from int, delim string) int {
+	var quote byte
+	for i := from; i < len(c.src); i++ {
+		ch := c.src[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case bytes.HasPrefix(c.src[i:], []byte(delim)):
+			return i
+		}
+	}
+	return -1
+}
+
+// Remove whitespace control modifiers and spaces of the tag content
+func trimTag(content []byte) string {
+	s := strings.TrimSpace(string(content))
+	s = strings.TrimLeft(s, "-~")
+	s = strings.TrimRight(s, "-~")
+	return strings.TrimSpace(s)
+}
+
+// Compile {{ expr }}, only the parts bypassing the escape are echoed
+func (c *templateCompiler) compileOutput(start int, content string, end int) {
+	e, err := c.parseExpr(content)
+	if err != nil {
+		c.out.Drop(start, end)
+		return
+	}
+	code := e.raw
+	if c.strategy() == STRATEGY_NONE {
+		code = e.code
+	}
+	if code == "" {
+		c.out.Drop(start, end)
+		return
+	}
+	c.emit(fmt.Sprintf("echo %s;", code), start, end)
+}
+
+func (c *templateCompiler) parseExpr(content string) (expr, error) {
+	p, err := newParser(content, c.strategy(), c.safeFilters)
+	if err != nil {
+		return expr{}, err
+	}
+	e, err := p.parseExpr()
+	if err == nil && !p.atEnd() {
+		err = fmt.Errorf("unexpected %q", p.peek().val)
+	}
+	return e, err
+}
+
+// Emit PHP code for the template text, keeping its lines
+func (c *templateCompiler) emit(code string, start, end int) {
+	lines := strings.Repeat("\n", bytes.Count(c.src[start:end], []byte("\n")))
+	c.out.Emit("<?php "+code+" ?>"+lines, start, end)
+}
+
+// Compile {% tag %}, return the end of the compiled text
+func (c *templateCompiler) compileTag(start int, content string, end int) int {
+	name, args := content, ""
+	if i := strings.IndexAny(content, " \t\r\n"); i >= 0 {
+		name, args = content[:i], strings.TrimSpace(content[i:])
+	}
+	code, err := c.compileTagCode(name, args)
+	switch {
+	case name == "verbatim" || name == "raw":
+		// text until the end tag is output as is
+		blockEnd := c.findEndTag(end, "end"+name)
+		c.out.Drop(start, end)
+		c.copyText(end, blockEnd)
+		if blockEnd == len(c.src) {
+			return blockEnd
+		}
+		tagEnd := c.indexFrom(blockEnd, "%}") + 2
+		c.out.Drop(blockEnd, tagEnd)
+		return tagEnd
+	case err != nil || code == "":
+		c.out.Drop(start, end)
+	default:
+		c.emit(code, start, end)
+	}
+	return end
+}
+
+// Find the start of the end tag such as {% endverbatim %}, end of the template if not found
+func (c *templateCompiler) findEndTag(from int, name string) int {
+	pattern := regexp.MustCompile(`\{%[-~]?\s*` + name + `\s*[-~]?%\}`)
+	loc := pattern.FindIndex(c.src[from:])
+	if loc == nil {
+		return len(c.src)
+	}
+	return from + loc[0]
+}
+
+// Get PHP of the tag, empty if the tag doesn't output or change any value
+func (c *templateCompiler) compileTagCode(name string, args string) (string, error) {
+	switch name {
+	case "if", "elseif":
+		if name == "if" {
+			c.blocks = append(c.blocks, "if")
+		}
+		cond, err := c.parseExpr(args)
+		if err != nil {
+			// keep the block structure
+			cond.code = "true"
+		}
+		return fmt.Sprintf("%s (%s):", name, cond.code), nil
+	case "else":
+		if len(c.blocks) > 0 && c.blocks[len(c.blocks)-1] == "for" {
+			// else of for is output when there is no item
+			c.blocks[len(c.blocks)-1] = "for-else"
+			return "endforeach; if (true):", nil
+		}
+		return "else:", nil
+	case "endif", "endfor":
+		block := ""
+		if len(c.blocks) > 0 {
+			block = c.blocks[len(c.blocks)-1]
+			c.blocks = c.blocks[:len(c.blocks)-1]
+		}
+		if name == "endfor" && block != "for-else" {
+			return "endforeach;", nil
+		}
+		return "endif;", nil
+	case "for":
+		c.blocks = append(c.blocks, "for")
+		code, err := c.compileFor(args)
+		if err != nil {
+			return "foreach ([] as $_):", nil
+		}
+		return code, nil
+	case "set":
+		return c.compileSet(args)
+	case "do":
+		e, err := c.parseExpr(args)
+		if err != nil {
+			return "", err
+		}
+		return e.code + ";", nil
+	case "include", "embed", "extends":
+		return c.compileInclude(name, args)
+	case "autoescape":
+		strategy := STRATEGY_HTML
+		switch arg := strings.Trim(args, `'" `); arg {
+		case "false":
+			strategy = STRATEGY_NONE
+		case "", "true":
+		default:
+			strategy = arg
+		}
+		c.strategies = append(c.strategies, strategy)
+	case "endautoescape":
+		if len(c.strategies) > 1 {
+			c.strategies = c.strategies[:len(c.strategies)-1]
+		}
+	}
+	// other tags such as block and macro output their content in place
+	return "", nil
+}
+
+// Compile {% for k, v in items %} to foreach
+func (c *templateCompiler) compileFor(args string) (string, error) {
+	p, err := newParser(args, c.strategy(), c.safeFilters)
+	if err != nil {
+		return "", err
+	}
+	value, err := p.expectName()
+	if err != nil {
+		return "", err
+	}
+	target := getVarCode(value)
+	if p.accept(",") {
+		if value, err = p.expectName(); err != nil {
+			return "", err
+		}
+		target += " => " + getVarCode(value)
+	}
+	if !p.isName("in") {
+		return "", fmt.Errorf("expected in")
+	}
+	p.next()
+	seq, err := p.parseBinary()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("foreach (%s as %s):", seq.code, target), nil
+}
+
+// Compile {% set a, b = x, y %}, set with block body is not compiled
+func (c *templateCompiler) compileSet(args string) (string, error) {
+	p, err := newParser(args, c.strategy(), c.safeFilters)
+	if err != nil {
+		return "", err
+	}
+	names := make([]string, 0)
+	for {
+		name, err := p.expectName()
+		if err != nil {
+			return "", err
+		}
+		names = append(names, name)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if !p.accept("=") {
+		return "", nil
+	}
+	assigns := make([]string, 0, len(names))
+	for i, name := range names {
+		if i > 0 {
+			if err := p.expect(","); err != nil {
+				return "", err
+			}
+		}
+		val, err := p.parseExpr()
+		if err != nil {
+			return "", err
+		}
+		assigns = append(assigns, fmt.Sprintf("%s = %s;", getVarCode(name), val.code))
+	}
+	return strings.Join(assigns, " "), nil
+}
+
+// Compile {% include 'x' with {...} only %}, the context is passed unless only is set
+func (c *templateCompiler) compileInclude(name string, args string) (string, error) {
+	p, err := newParser(args, c.strategy(), c.safeFilters)
+	if err != nil {
+		return "", err
+	}
+	tpl, err := p.parseExpr()
+	if err != nil {
+		return "", err
+	}
+	includeArgs := []string{tpl.code}
+	withContext := true
+	for !p.atEnd() {
+		switch {
+		case p.isName("with"):
+			p.next()
+			vars, err := p.parseExpr()
+			if err != nil {
+				return "", err
+			}
+			includeArgs = append(includeArgs, vars.code)
+		case p.isName("only"):
+			p.next()
+			withContext = false
+		case p.isName("ignore"), p.isName("missing"):
+			p.next()
+		default:
+			return "", fmt.Errorf("unexpected %q", p.peek().val)
+		}
+	}
+	return fmt.Sprintf("echo %s;", getIncludeCode(includeArgs, withContext)), nil
+}
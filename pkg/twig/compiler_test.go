@@ -0,0 +1,76 @@
+package twig
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGetStrategy(t *testing.T) {
+	tests := []struct {
+		filePath string
+		want     string
+	}{
+		{"templates/index.html.twig", STRATEGY_HTML},
+		{"templates/index.twig", STRATEGY_HTML},
+		{"templates/app.js.twig", STRATEGY_JS},
+		{"templates/app.css.twig", STRATEGY_CSS},
+		{"templates/mail.txt.twig", STRATEGY_NONE},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filePath, func(t *testing.T) {
+			if got := GetStrategy(tt.filePath); got != tt.want {
+				t.Errorf("GetStrategy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		filePath string
+		want     string
+	}{
+		{"escaped output", "{{ name }}", "index.html.twig", ""},
+		{"raw output", "{{ name|raw }}", "index.html.twig", "<?php echo $name; ?>"},
+		{"raw attribute", "{{ user.name|raw }}", "index.html.twig", "<?php echo $user['name']; ?>"},
+		{"escape filter", "{{ name|e }}", "index.html.twig", ""},
+		{"escape strategy before raw", "{{ name|escape('js')|raw }}", "index.html.twig", "<?php echo twig_escape_filter($name, 'js'); ?>"},
+		{"safe filter", "{{ body|markdown }}", "index.html.twig", "<?php echo twig_markdown($body); ?>"},
+		{"pre escaped safe filter", "{{ body|nl2p }}", "index.html.twig", ""},
+		{"autoescape false", "{% autoescape false %}{{ name }}{% endautoescape %}{{ name }}", "index.html.twig", "<?php echo $name; ?>"},
+		{"text template", "{{ name }}", "mail.txt.twig", "<?php echo $name; ?>"},
+		{"js template", "{{ name }}", "app.js.twig", ""},
+		{"raw branch of conditional", "{{ flag ? html|raw : text }}", "index.html.twig", "<?php echo ($flag ? $html : ''); ?>"},
+		{"set", "{% set x = name %}{{ x|raw }}", "index.html.twig", "<?php $x = $name; ?><?php echo $x; ?>"},
+		{"for else", "{% for item in items %}\n{{ item|raw }}\n{% else %}none{% endfor %}", "index.html.twig",
+			"<?php foreach ($items as $item): ?>\n<?php echo $item; ?>\n<?php endforeach; if (true): ?>none<?php endif; ?>"},
+		{"if", "{% if show %}{{ a|raw }}{% endif %}", "index.html.twig", "<?php if ($show): ?><?php echo $a; ?><?php endif; ?>"},
+		{"comment", "{# xss-taint-ignore: trusted #}", "index.html.twig", "<?php /* xss-taint-ignore: trusted */ ?>"},
+		{"include only", "{% include 'nav.html.twig' with {user: user} only %}", "index.html.twig", "<?php echo twig_include('nav.html.twig', ['user' => $user]); ?>"},
+		{"verbatim", "{% verbatim %}{{ name|raw }}{% endverbatim %}", "index.html.twig", "{{ name|raw }}"},
+		{"xml declaration", `<?xml version="1.0"?>`, "index.html.twig", `<?php echo '<?'; ?>xml version="1.0"?>`},
+	}
+	c := NewCompiler()
+	c.ReadFilters([]byte(`<?php
+return [
+    new TwigFilter('markdown', [$this, 'markdown'], ['is_safe' => ['html']]),
+    new TwigFilter('nl2p', [$this, 'nl2p'], ['is_safe' => ['html'], 'pre_escape' => 'html']),
+];`))
+	const prefix = "<?php extract($__data); ?>"
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, _ := c.Compile([]byte(tt.src), tt.filePath)
+			if !bytes.HasPrefix(out, []byte(prefix)) {
+				t.Fatalf("output %q doesn't extract the view data", out)
+			}
+			if got := string(out[len(prefix):]); got != tt.want {
+				t.Errorf("Compile() = %q, want %q", got, tt.want)
+			}
+			if bytes.Count(out, []byte("\n")) != bytes.Count([]byte(tt.src), []byte("\n")) {
+				t.Errorf("Compile() doesn't keep the lines")
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package twig
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenType int
+
+const (
+	TOKEN_NAME tokenType = iota
+	TOKEN_NUMBER
+	TOKEN_STRING
+	TOKEN_PUNCT
+	TOKEN_EOF
+)
+
+type token struct {
+	typ tokenType
+	val string
+	// Quote of the string
+	quote byte
+}
+
+// Operators and punctuation, longest first
+var punctuations = []string{
+	"...", "**", "//", "==", "!=", "<=", ">=", "??", "?:", "..", "=>",
+	"+", "-", "*", "/", "%", "~", "<", ">", "=", "!", "?", ":", ".", ",", "|",
+	"(", ")", "[", "]", "{", "}",
+}
+
+// Split Twig expression into tokens
+func tokenize(expr string) ([]token, error) {
+	tokens := make([]token, 0)
+	for i := 0; i < len(expr); {
+		ch := expr[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case isNameStart(ch):
+			start := i
+			for i < len(expr) && isNameChar(expr[i]) {
+				i++
+			}
+			// bitwise operators such as b-and
+			if name := expr[start:i]; name == "b" && i < len(expr) && expr[i] == '-' {
+				for _, op := range []string{"-and", "-or", "-xor"} {
+					if strings.HasPrefix(expr[i:], op) {
+						i += len(op)
+						break
+					}
+				}
+			}
+			tokens = append(tokens, token{typ: TOKEN_NAME, val: expr[start:i]})
+		case ch >= '0' && ch <= '9':
+			start := i
+			for i < len(expr) && (isDigit(expr[i]) || expr[i] == '_' ||
+				(expr[i] == '.' && i+1 < len(expr) && isDigit(expr[i+1]))) {
+				i++
+			}
+			tokens = append(tokens, token{typ: TOKEN_NUMBER, val: strings.ReplaceAll(expr[start:i], "_", "")})
+		case ch == '\'' || ch == '"':
+			start := i + 1
+			for i = start; i < len(expr) && expr[i] != ch; i++ {
+				if expr[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(expr) {
+				return nil, fmt.Errorf("unclosed string")
+			}
+			tokens = append(tokens, token{typ: TOKEN_STRING, val: expr[start:i], quote: ch})
+			i++
+		default:
+			found := false
+			for _, punct := range punctuations {
+				if strings.HasPrefix(expr[i:], punct) {
+					tokens = append(tokens, token{typ: TOKEN_PUNCT, val: punct})
+					i += len(punct)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unexpected character %q", ch)
+			}
+		}
+	}
+	return append(tokens, token{typ: TOKEN_EOF}), nil
+}
+
+func isNameStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || ch >= 0x80
+}
+
+func isNameChar(ch byte) bool {
+	return isNameStart(ch) || isDigit(ch)
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
@@ -0,0 +1,659 @@
+package twig
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rxhunter00/XSS-Taint/pkg/templating"
+)
+
+// Translated PHP of a Twig expression
+type expr struct {
+	code string
+	// PHP of the parts output without escaping, empty if every part is escaped
+	raw string
+}
+
+// PHP of binary operators, applied left to right since only the flow of values matters
+var binaryOperators = map[string]string{
+	"or": "(%s || %s)", "and": "(%s && %s)", "b-or": "(%s | %s)", "b-xor": "(%s ^ %s)", "b-and": "(%s & %s)",
+	"==": "(%s == %s)", "!=": "(%s != %s)", "<": "(%s < %s)", ">": "(%s > %s)", "<=": "(%s <= %s)", ">=": "(%s >= %s)",
+	"in": "in_array(%s, %s)", "not in": "!in_array(%s, %s)", "matches": "preg_match(%[2]s, %[1]s)",
+	"starts with": "str_starts_with(%s, %s)", "ends with": "str_ends_with(%s, %s)", "..": "range(%s, %s)",
+	"+": "(%s + %s)", "-": "(%s - %s)", "~": "(%s . %s)", "*": "(%s * %s)", "/": "(%s / %s)",
+	"//": "intdiv(%s, %s)", "%": "(%s %% %s)", "**": "(%s ** %s)",
+}
+
+// Tests with two words name, such as same as
+var twoWordTests = map[string]string{"same": "as", "divisible": "by"}
+
+// Filters with PHP equivalent taking the value as first argument
+var phpFilters = map[string]string{
+	"upper": "strtoupper", "lower": "strtolower", "trim": "trim", "striptags": "strip_tags",
+	"url_encode": "rawurlencode", "json_encode": "json_encode", "length": "count", "abs": "abs",
+	"round": "round", "number_format": "number_format", "capitalize": "ucfirst", "title": "ucwords",
+	"keys": "array_keys", "merge": "array_merge", "reverse": "array_reverse", "format": "sprintf",
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	// Current autoescape strategy, empty if disabled
+	strategy string
+	// Strategies of the filters declared safe
+	safeFilters map[string][]string
+}
+
+func newParser(src string, strategy string, safeFilters map[string][]string) (*parser, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	return &parser{tokens: tokens, strategy: strategy, safeFilters: safeFilters}, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(offset int) token {
+	if p.pos+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.typ != TOKEN_EOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) isPunct(val string) bool {
+	tok := p.peek()
+	return tok.typ == TOKEN_PUNCT && tok.val == val
+}
+
+func (p *parser) isName(val string) bool {
+	tok := p.peek()
+	return tok.typ == TOKEN_NAME && tok.val == val
+}
+
+// Skip the punctuation if it is next
+func (p *parser) accept(val string) bool {
+	if p.isPunct(val) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(val string) error {
+	if !p.accept(val) {
+		return fmt.Errorf("expected %q, found %q", val, p.peek().val)
+	}
+	return nil
+}
+
+func (p *parser) expectName() (string, error) {
+	tok := p.next()
+	if tok.typ != TOKEN_NAME {
+		return "", fmt.Errorf("expected name, found %q", tok.val)
+	}
+	return tok.val, nil
+}
+
+func (p *parser) atEnd() bool {
+	return p.peek().typ == TOKEN_EOF
+}
+
+func (p *parser) parseExpr() (expr, error) {
+	cond, err := p.parseBinary()
+	if err != nil {
+		return expr{}, err
+	}
+	switch {
+	case p.accept("?"):
+		then, err := p.parseExpr()
+		if err != nil {
+			return expr{}, err
+		}
+		otherwise := expr{code: "''"}
+		if p.accept(":") {
+			if otherwise, err = p.parseExpr(); err != nil {
+				return expr{}, err
+			}
+		}
+		return newConditional("(%s ? %s : %s)", cond.code, then, otherwise), nil
+	case p.accept("?:"):
+		otherwise, err := p.parseExpr()
+		if err != nil {
+			return expr{}, err
+		}
+		return newConditional("(%s ?: %s)", "", cond, otherwise), nil
+	case p.accept("??"):
+		otherwise, err := p.parseExpr()
+		if err != nil {
+			return expr{}, err
+		}
+		return newConditional("(%s ?? %s)", "", cond, otherwise), nil
+	}
+	return cond, nil
+}
+
+// Each branch of conditional is escaped separately, the raw output only has the raw branches
+func newConditional(format string, cond string, then expr, otherwise expr) expr {
+	args := []interface{}{then.code, otherwise.code}
+	rawArgs := []interface{}{orEmpty(then.raw), orEmpty(otherwise.raw)}
+	if cond != "" {
+		args = append([]interface{}{cond}, args...)
+		rawArgs = append([]interface{}{cond}, rawArgs...)
+	}
+	e := expr{code: fmt.Sprintf(format, args...)}
+	if then.raw != "" || otherwise.raw != "" {
+		e.raw = fmt.Sprintf(format, rawArgs...)
+	}
+	return e
+}
+
+func orEmpty(code string) string {
+	if code == "" {
+		return "''"
+	}
+	return code
+}
+
+func (p *parser) parseBinary() (expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return expr{}, err
+	}
+	for {
+		if p.isName("is") {
+			p.next()
+			if left, err = p.parseTest(left); err != nil {
+				return expr{}, err
+			}
+			continue
+		}
+		op, ok := p.getBinaryOperator()
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return expr{}, err
+		}
+		left = expr{code: fmt.Sprintf(binaryOperators[op], left.code, right.code)}
+	}
+}
+
+// Get and skip the next binary operator
+func (p *parser) getBinaryOperator() (string, bool) {
+	tok := p.peek()
+	switch {
+	case tok.typ == TOKEN_PUNCT:
+		if _, ok := binaryOperators[tok.val]; ok {
+			p.next()
+			return tok.val, true
+		}
+	case tok.typ == TOKEN_NAME:
+		op := tok.val
+		switch tok.val {
+		case "not", "starts", "ends":
+			next := p.peekAt(1)
+			if next.typ != TOKEN_NAME {
+				return "", false
+			}
+			op = tok.val + " " + next.val
+		}
+		if _, ok := binaryOperators[op]; ok {
+			p.pos += len(strings.Fields(op))
+			return op, true
+		}
+	}
+	return "", false
+}
+
+// Parse test after is, such as is defined or is not same as(x)
+func (p *parser) parseTest(left expr) (expr, error) {
+	negated := p.isName("not")
+	if negated {
+		p.next()
+	}
+	name, err := p.expectName()
+	if err != nil {
+		return expr{}, err
+	}
+	if second, ok := twoWordTests[name]; ok && p.isName(second) {
+		p.next()
+	}
+	args := []string{left.code}
+	if p.isPunct("(") {
+		testArgs, err := p.parseArgs()
+		if err != nil {
+			return expr{}, err
+		}
+		args = append(args, testArgs...)
+	}
+	code := fmt.Sprintf("twig_test_%s(%s)", name, strings.Join(args, ", "))
+	if negated {
+		code = "!" + code
+	}
+	return expr{code: code}, nil
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	switch {
+	case p.isName("not"):
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return expr{}, err
+		}
+		return expr{code: "!" + operand.code}, nil
+	case p.isPunct("-"), p.isPunct("+"):
+		op := p.next().val
+		operand, err := p.parseUnary()
+		if err != nil {
+			return expr{}, err
+		}
+		return expr{code: op + operand.code}, nil
+	}
+	primary, err := p.parsePrimary()
+	if err != nil {
+		return expr{}, err
+	}
+	return p.parsePostfix(primary)
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	tok := p.next()
+	switch tok.typ {
+	case TOKEN_NUMBER:
+		return expr{code: tok.val}, nil
+	case TOKEN_STRING:
+		return p.parseString(tok)
+	case TOKEN_NAME:
+		switch strings.ToLower(tok.val) {
+		case "true", "false", "null":
+			return expr{code: strings.ToLower(tok.val)}, nil
+		case "none":
+			return expr{code: "null"}, nil
+		}
+		if p.isPunct("=>") {
+			return p.parseArrow([]string{tok.val})
+		}
+		if p.isPunct("(") {
+			return p.parseFunction(tok.val)
+		}
+		return expr{code: getVarCode(tok.val)}, nil
+	case TOKEN_PUNCT:
+		switch tok.val {
+		case "(":
+			if params, ok := p.getArrowParams(); ok {
+				return p.parseArrow(params)
+			}
+			inner, err := p.parseExpr()
+			if err != nil {
+				return expr{}, err
+			}
+			if err := p.expect(")"); err != nil {
+				return expr{}, err
+			}
+			// parentheses keep the raw output
+			e := expr{code: "(" + inner.code + ")"}
+			if inner.raw != "" {
+				e.raw = "(" + inner.raw + ")"
+			}
+			return e, nil
+		case "[":
+			return p.parseArray()
+		case "{":
+			return p.parseHash()
+		}
+	}
+	return expr{}, fmt.Errorf("unexpected %q", tok.val)
+}
+
+func getVarCode(name string) string {
+	if name == "_context" {
+		return "$" + templating.DATA_VAR
+	}
+	return "$" + name
+}
+
+// Translate string, interpolation of double quoted string such as "#{x}" is concatenated
+func (p *parser) parseString(tok token) (expr, error) {
+	if tok.quote == '\'' || !strings.Contains(tok.val, "#{") {
+		return expr{code: quote(unescape(tok.val))}, nil
+	}
+	parts := make([]string, 0)
+	rest := tok.val
+	for {
+		start := strings.Index(rest, "#{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start:], "}")
+		if end < 0 {
+			break
+		}
+		end += start
+		if start > 0 {
+			parts = append(parts, quote(unescape(rest[:start])))
+		}
+		inner, err := newParser(rest[start+2:end], p.strategy, p.safeFilters)
+		if err != nil {
+			return expr{}, err
+		}
+		e, err := inner.parseExpr()
+		if err != nil {
+			return expr{}, err
+		}
+		parts = append(parts, e.code)
+		rest = rest[end+1:]
+	}
+	if rest != "" {
+		parts = append(parts, quote(unescape(rest)))
+	}
+	return expr{code: "(" + strings.Join(parts, " . ") + ")"}, nil
+}
+
+func unescape(s string) string {
+	return strings.NewReplacer(`\'`, `'`, `\"`, `"`, `\\`, `\`).Replace(s)
+}
+
+// Quote as PHP single quoted string
+func quote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// Get parameters of arrow function after (, false if it is not arrow function
+func (p *parser) getArrowParams() ([]string, bool) {
+	params := make([]string, 0)
+	for i := 0; ; i += 2 {
+		tok := p.peekAt(i)
+		if tok.typ == TOKEN_PUNCT && tok.val == ")" && i == 0 {
+			break
+		}
+		if tok.typ != TOKEN_NAME {
+			return nil, false
+		}
+		params = append(params, tok.val)
+		sep := p.peekAt(i + 1)
+		if sep.typ != TOKEN_PUNCT {
+			return nil, false
+		}
+		if sep.val == ")" {
+			if arrow := p.peekAt(i + 2); arrow.typ != TOKEN_PUNCT || arrow.val != "=>" {
+				return nil, false
+			}
+			p.pos += i + 2
+			return params, true
+		}
+		if sep.val != "," {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// Parse arrow function body after the parameters, such as x => x.name
+func (p *parser) parseArrow(params []string) (expr, error) {
+	if err := p.expect("=>"); err != nil {
+		return expr{}, err
+	}
+	body, err := p.parseExpr()
+	if err != nil {
+		return expr{}, err
+	}
+	vars := make([]string, 0, len(params))
+	for _, param := range params {
+		vars = append(vars, getVarCode(param))
+	}
+	return expr{code: fmt.Sprintf("fn(%s) => %s", strings.Join(vars, ", "), body.code)}, nil
+}
+
+func (p *parser) parseArray() (expr, error) {
+	items := make([]string, 0)
+	for !p.accept("]") {
+		spread := p.accept("...")
+		item, err := p.parseExpr()
+		if err != nil {
+			return expr{}, err
+		}
+		if spread {
+			item.code = "..." + item.code
+		}
+		items = append(items, item.code)
+		if !p.accept(",") {
+			if err := p.expect("]"); err != nil {
+				return expr{}, err
+			}
+			break
+		}
+	}
+	return expr{code: "[" + strings.Join(items, ", ") + "]"}, nil
+}
+
+// Parse hash such as {name: x, 'key': y, (expr): z, shorthand}
+func (p *parser) parseHash() (expr, error) {
+	items := make([]string, 0)
+	for !p.accept("}") {
+		var key string
+		tok := p.peek()
+		switch {
+		case p.accept("..."):
+			item, err := p.parseExpr()
+			if err != nil {
+				return expr{}, err
+			}
+			items = append(items, "..."+item.code)
+		case tok.typ == TOKEN_NAME && (p.peekAt(1).val == "," || p.peekAt(1).val == "}"):
+			// {name} is {name: name}
+			p.next()
+			items = append(items, quote(tok.val)+" => "+getVarCode(tok.val))
+		default:
+			switch tok.typ {
+			case TOKEN_NAME:
+				p.next()
+				key = quote(tok.val)
+			case TOKEN_NUMBER:
+				p.next()
+				key = tok.val
+			default:
+				keyExpr, err := p.parsePrimary()
+				if err != nil {
+					return expr{}, err
+				}
+				key = keyExpr.code
+			}
+			if err := p.expect(":"); err != nil {
+				return expr{}, err
+			}
+			val, err := p.parseExpr()
+			if err != nil {
+				return expr{}, err
+			}
+			items = append(items, key+" => "+val.code)
+		}
+		if !p.accept(",") {
+			if err := p.expect("}"); err != nil {
+				return expr{}, err
+			}
+			break
+		}
+	}
+	return expr{code: "[" + strings.Join(items, ", ") + "]"}, nil
+}
+
+// Parse arguments in parentheses, name of named argument is dropped
+func (p *parser) parseArgs() ([]string, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	args := make([]string, 0)
+	for !p.accept(")") {
+		if p.peek().typ == TOKEN_NAME {
+			if next := p.peekAt(1); next.typ == TOKEN_PUNCT && (next.val == "=" || next.val == ":") {
+				p.pos += 2
+			}
+		}
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg.code)
+		if !p.accept(",") {
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	return args, nil
+}
+
+func (p *parser) parseFunction(name string) (expr, error) {
+	args, err := p.parseArgs()
+	if err != nil {
+		return expr{}, err
+	}
+	switch name {
+	case "include":
+		// output of other template is not escaped again
+		code := getIncludeCode(args, true)
+		return expr{code: code, raw: code}, nil
+	case "attribute":
+		if len(args) > 1 {
+			return expr{code: fmt.Sprintf("%s[%s]", args[0], args[1])}, nil
+		}
+	}
+	return expr{code: fmt.Sprintf("%s(%s)", name, strings.Join(args, ", "))}, nil
+}
+
+// Get PHP of including template with the variables, passing the current context
+// as third argument if withContext
+func getIncludeCode(args []string, withContext bool) string {
+	name, vars := "''", "[]"
+	if len(args) > 0 {
+		name = args[0]
+	}
+	if len(args) > 1 {
+		vars = args[1]
+	}
+	if len(args) > 2 && args[2] == "false" {
+		withContext = false
+	}
+	if withContext {
+		return fmt.Sprintf("%s(%s, %s, $%s)", INCLUDE_FUNC, name, vars, templating.DATA_VAR)
+	}
+	return fmt.Sprintf("%s(%s, %s)", INCLUDE_FUNC, name, vars)
+}
+
+func (p *parser) parsePostfix(e expr) (expr, error) {
+	for {
+		switch {
+		case p.accept("."):
+			tok := p.next()
+			if tok.typ != TOKEN_NAME && tok.typ != TOKEN_NUMBER {
+				return expr{}, fmt.Errorf("unexpected %q after .", tok.val)
+			}
+			if tok.typ == TOKEN_NAME && p.isPunct("(") {
+				args, err := p.parseArgs()
+				if err != nil {
+					return expr{}, err
+				}
+				e = expr{code: fmt.Sprintf("%s->%s(%s)", e.code, tok.val, strings.Join(args, ", "))}
+			} else if tok.typ == TOKEN_NAME {
+				e = expr{code: fmt.Sprintf("%s[%s]", e.code, quote(tok.val))}
+			} else {
+				e = expr{code: fmt.Sprintf("%s[%s]", e.code, tok.val)}
+			}
+		case p.accept("["):
+			// slice [start:length] keeps the value
+			var key expr
+			var err error
+			if !p.isPunct(":") {
+				if key, err = p.parseExpr(); err != nil {
+					return expr{}, err
+				}
+			}
+			if p.accept(":") {
+				if !p.isPunct("]") {
+					if _, err = p.parseExpr(); err != nil {
+						return expr{}, err
+					}
+				}
+				key.code = ""
+			}
+			if err := p.expect("]"); err != nil {
+				return expr{}, err
+			}
+			if key.code == "" {
+				e = expr{code: fmt.Sprintf("twig_slice(%s)", e.code)}
+			} else {
+				e = expr{code: fmt.Sprintf("%s[%s]", e.code, key.code)}
+			}
+		case p.accept("|"):
+			name, err := p.expectName()
+			if err != nil {
+				return expr{}, err
+			}
+			args := make([]string, 0)
+			if p.isPunct("(") {
+				if args, err = p.parseArgs(); err != nil {
+					return expr{}, err
+				}
+			}
+			e = p.applyFilter(name, e, args)
+		default:
+			return e, nil
+		}
+	}
+}
+
+// Translate filter applied to the value, raw and the filters declared safe for the
+// current strategy are output without escaping
+func (p *parser) applyFilter(name string, e expr, args []string) expr {
+	switch name {
+	case "raw":
+		return expr{code: e.code, raw: e.code}
+	case "escape", "e":
+		strategy := "'html'"
+		if len(args) > 0 {
+			strategy = args[0]
+		}
+		return expr{code: fmt.Sprintf("%s(%s, %s)", ESCAPE_FUNC, e.code, strategy)}
+	case "nl2br":
+		// the value is escaped before
+		return expr{code: fmt.Sprintf("nl2br(%s(%s, 'html'))", ESCAPE_FUNC, e.code)}
+	case "default":
+		otherwise := "''"
+		if len(args) > 0 {
+			otherwise = args[0]
+		}
+		return expr{code: fmt.Sprintf("(%s ?: %s)", e.code, otherwise)}
+	}
+	funcName, ok := phpFilters[name]
+	if !ok {
+		funcName = "twig_" + name
+	}
+	code := fmt.Sprintf("%s(%s)", funcName, strings.Join(append([]string{e.code}, args...), ", "))
+	if p.isSafeFilter(name) {
+		return expr{code: code, raw: code}
+	}
+	return expr{code: code}
+}
+
+func (p *parser) isSafeFilter(name string) bool {
+	for _, strategy := range p.safeFilters[name] {
+		if strategy == "all" || strategy == p.strategy {
+			return true
+		}
+	}
+	return false
+}